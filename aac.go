@@ -0,0 +1,138 @@
+package yurit
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"time"
+)
+
+//AACMetadata is a collection of metadata from a raw AAC (.aac) file made up of
+//ADTS frames, including tags and frame information.
+type AACMetadata struct {
+	//apeTags is always nil, only ID3 tags are read from raw AAC files
+	apeID3Tags
+	fileSize    int64
+	frameHeader aacADTSHeader
+	totalFrames int
+	//totalSamples and audioSize are the sums of the samples and the lengths of
+	//all the frames found while scanning the file
+	totalSamples int64
+	audioSize    int64
+}
+
+// ReadFromAAC reads tags and ADTS frame information from a raw AAC file,
+// returning the resulting metadata in a Metadata implementation, or non-nil
+// error if there was a problem.
+func ReadFromAAC(file *os.File) (*AACMetadata, error) {
+	var (
+		m AACMetadata
+	)
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	m.fileSize = stat.Size()
+	//Extract any ID3v2 tags, if any. This leaves the file positioned right
+	//after the tag, or at the beginning of the file if there is no tag.
+	id3v2, err := ReadID3v2Tags(file)
+	if err != nil {
+		return nil, err
+	}
+	m.id3v2Tags = id3v2
+	//Find and read the first encountered frame header, then scan through the
+	//rest of the frames to count them
+	err = m.readFrames(file)
+	if err != nil {
+		return nil, err
+	}
+	//Look for an ID3v1 tag at the end of the file
+	id3v1, err := ReadID3v1Tags(file)
+	if err != nil {
+		return nil, err
+	}
+	m.id3v1tags = id3v1
+	return &m, nil
+}
+
+//readFrames finds the first ADTS frame in r and then walks through the frames
+//from there using their frame lengths. The walk stops at the end of the file or
+//as soon as the frames lose sync, e.g. at an ID3v1 tag.
+func (m *AACMetadata) readFrames(r io.Reader) error {
+	//The buffer must hold a whole frame plus the sync word of the next one
+	br := bufio.NewReaderSize(r, adtsMaxFrameLength+2)
+	h, err := syncADTSFrame(br)
+	if err != nil {
+		return err
+	}
+	m.frameHeader = h
+	for h.FrameLength() >= adtsHeaderLength {
+		_, err = br.Discard(h.FrameLength())
+		if err != nil {
+			//Truncated final frame, which is not counted
+			break
+		}
+		m.totalFrames++
+		m.totalSamples += int64(h.SamplesPerFrame())
+		m.audioSize += int64(h.FrameLength())
+		b, err := br.Peek(adtsHeaderLength)
+		if err != nil || !isADTSSync(b) {
+			break
+		}
+		h = processADTSHeader(b)
+	}
+	return nil
+}
+
+//AudioObjectType returns the AAC profile of the stream, e.g. AAC LC.
+func (m AACMetadata) AudioObjectType() string {
+	return m.frameHeader.AudioObjectType()
+}
+
+//AverageBitrate returns the average bitrate of the file in bits per second,
+//calculated from the total length of all ADTS frames, headers included.
+func (m AACMetadata) AverageBitrate() int {
+	durationInSeconds := m.Duration().Seconds()
+	if durationInSeconds == 0 {
+		return 0
+	}
+	return int(float64(m.audioSize*8) / durationInSeconds)
+}
+
+func (m AACMetadata) Channels() int {
+	return m.frameHeader.Channels()
+}
+
+//Chapters returns the chapters from the CHAP frames of the ID3v2 tag.
+func (m AACMetadata) Chapters() []Chapter {
+	return chaptersFromID3v2(m.id3v2Tags, m.Duration())
+}
+
+//Duration returns the length of the audio based on the number of samples in
+//all of the ADTS frames found in the file.
+func (m AACMetadata) Duration() time.Duration {
+	sr := m.frameHeader.SampleRate()
+	if sr <= 0 {
+		return time.Duration(0)
+	}
+	seconds := float64(m.totalSamples) / float64(sr)
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func (m AACMetadata) FileType() FileType {
+	return AAC
+}
+
+//FrameHeader returns the information from the first ADTS header in the file.
+func (m AACMetadata) FrameHeader() map[string]interface{} {
+	return m.frameHeader
+}
+
+func (m AACMetadata) SampleRate() int {
+	return m.frameHeader.SampleRate()
+}
+
+//TotalFrames returns the number of ADTS frames found in the file.
+func (m AACMetadata) TotalFrames() int {
+	return m.totalFrames
+}
@@ -0,0 +1,122 @@
+package yurit
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+	"time"
+)
+
+//adtsTestFrame builds an AAC LC frame at 44.1 kHz in stereo, with length bytes
+//in total including the header.
+func adtsTestFrame(length int) []byte {
+	b := make([]byte, length)
+	copy(b, []byte{
+		0xFF, 0xF1,
+		1<<6 | 4<<2,
+		2<<6 | byte(length>>11)&0x03,
+		byte(length >> 3),
+		byte(length&0x07)<<5 | 0x1F,
+		0xFC,
+	})
+	return b
+}
+
+func TestProcessADTSHeader(t *testing.T) {
+	h := processADTSHeader(adtsTestFrame(371))
+	if h.Version() != "MPEG-4" || h.AudioObjectType() != "AAC LC" {
+		t.Errorf("Version(), AudioObjectType() = %q, %q, expected %q, %q", h.Version(), h.AudioObjectType(), "MPEG-4", "AAC LC")
+	}
+	if h.SampleRate() != 44100 || h.Channels() != 2 {
+		t.Errorf("SampleRate(), Channels() = %v, %v, expected %v, %v", h.SampleRate(), h.Channels(), 44100, 2)
+	}
+	if h.FrameLength() != 371 || h.SamplesPerFrame() != 1024 || h.Protected() {
+		t.Errorf("FrameLength(), SamplesPerFrame(), Protected() = %v, %v, %v, expected %v, %v, %v", h.FrameLength(), h.SamplesPerFrame(), h.Protected(), 371, 1024, false)
+	}
+
+	//MPEG-2, protected, AAC Main, 8 kHz, 8 channels and 2 raw data blocks
+	h = processADTSHeader([]byte{0xFF, 0xF8, 0<<6 | 11<<2 | 1, 3 << 6, 0x01, 0x00, 0x01})
+	if h.Version() != "MPEG-2" || h.AudioObjectType() != "AAC Main" || !h.Protected() {
+		t.Errorf("Version(), AudioObjectType(), Protected() = %q, %q, %v, expected %q, %q, %v", h.Version(), h.AudioObjectType(), h.Protected(), "MPEG-2", "AAC Main", true)
+	}
+	if h.SampleRate() != 8000 || h.Channels() != 8 || h.SamplesPerFrame() != 2048 {
+		t.Errorf("SampleRate(), Channels(), SamplesPerFrame() = %v, %v, %v, expected %v, %v, %v", h.SampleRate(), h.Channels(), h.SamplesPerFrame(), 8000, 8, 2048)
+	}
+}
+
+func TestSyncADTSFrame(t *testing.T) {
+	frame := adtsTestFrame(100)
+	tests := []struct {
+		input      []byte
+		offset     int
+		makesError bool
+	}{
+		{bytes.Join([][]byte{frame, frame}, nil), 0, false},
+		//A single frame that ends the stream
+		{frame, 0, false},
+		{bytes.Join([][]byte{{0x00, 0xFF, 0x01}, frame, frame}, nil), 3, false},
+		//Junk that looks like a header, but isn't followed by another one
+		{bytes.Join([][]byte{adtsTestFrame(20)[:10], frame, frame}, nil), 10, false},
+		{bytes.Join([][]byte{frame[:7], {0x00, 0x00}}, nil), 0, true},
+		{[]byte{0xFF, 0xF1, 0x00}, 0, true},
+	}
+
+	for ii, tt := range tests {
+		br := bufio.NewReaderSize(bytes.NewReader(tt.input), adtsMaxFrameLength+2)
+		h, err := syncADTSFrame(br)
+		if (err != nil) != tt.makesError {
+			t.Errorf("[%d] syncADTSFrame() expected error: %v - Error was: %v", ii, tt.makesError, err)
+			continue
+		}
+		if tt.makesError {
+			continue
+		}
+		if offset := len(tt.input) - br.Buffered(); offset != tt.offset || h.FrameLength() != 100 {
+			t.Errorf("[%d] syncADTSFrame() stopped at %d with frame length %d, expected %d, %d", ii, offset, h.FrameLength(), tt.offset, 100)
+		}
+	}
+}
+
+func TestAACReadFrames(t *testing.T) {
+	tests := []struct {
+		input        []byte
+		totalFrames  int
+		totalSamples int64
+		audioSize    int64
+	}{
+		{bytes.Join([][]byte{adtsTestFrame(400), adtsTestFrame(300), adtsTestFrame(500)}, nil), 3, 3072, 1200},
+		//The truncated final frame isn't counted
+		{bytes.Join([][]byte{adtsTestFrame(400), adtsTestFrame(300), adtsTestFrame(500)[:200]}, nil), 2, 2048, 700},
+		//Nor is anything after the frames lose sync
+		{bytes.Join([][]byte{adtsTestFrame(400), adtsTestFrame(300), []byte("TAG"), make([]byte, 125)}, nil), 2, 2048, 700},
+	}
+
+	for ii, tt := range tests {
+		var m AACMetadata
+		err := m.readFrames(bytes.NewReader(tt.input))
+		if err != nil {
+			t.Errorf("[%d] readFrames() returned error: %v", ii, err)
+			continue
+		}
+		if m.totalFrames != tt.totalFrames || m.totalSamples != tt.totalSamples || m.audioSize != tt.audioSize {
+			t.Errorf("[%d] readFrames() found %v frames, %v samples, %v bytes, expected %v, %v, %v", ii, m.totalFrames, m.totalSamples, m.audioSize, tt.totalFrames, tt.totalSamples, tt.audioSize)
+		}
+	}
+
+	var m AACMetadata
+	frames := make([][]byte, 43)
+	for i := range frames {
+		frames[i] = adtsTestFrame(417)
+	}
+	err := m.readFrames(bytes.NewReader(bytes.Join(frames, nil)))
+	if err != nil {
+		t.Fatalf("readFrames() returned error: %v", err)
+	}
+	seconds := float64(43*1024) / 44100
+	if d, expected := m.Duration(), time.Duration(seconds*float64(time.Second)); d != expected {
+		t.Errorf("Duration() = %v, expected %v", d, expected)
+	}
+	if b, expected := m.AverageBitrate(), int(float64(43*417*8)/seconds); b != expected {
+		t.Errorf("AverageBitrate() = %v, expected %v", b, expected)
+	}
+}
@@ -0,0 +1,151 @@
+package yurit
+
+import (
+	"bufio"
+	"io"
+)
+
+//adtsHeaderLength is the length of an ADTS header without the optional CRC.
+const adtsHeaderLength = 7
+
+//adtsMaxFrameLength is the largest frame length that fits in the 13 bit field.
+const adtsMaxFrameLength = 1<<13 - 1
+
+//aacADTSHeader represents the information contained in an ADTS frame header,
+//which precedes every frame of a raw AAC (.aac) stream. Most of this
+//information is the same for all frames in a stream, with the exception of the
+//frame length and buffer fullness.
+//https://wiki.multimedia.cx/index.php/ADTS
+type aacADTSHeader map[string]interface{}
+
+//syncADTSFrame moves br forward to the first ADTS header that is either
+//followed by another ADTS header at the end of its frame, or whose frame ends
+//exactly at the end of the stream. A sync word on its own is too easily found
+//in junk at the start of a file. The header is peeked, not read, so br is left
+//at the start of the frame.
+func syncADTSFrame(br *bufio.Reader) (aacADTSHeader, error) {
+	for {
+		b, err := br.Peek(adtsHeaderLength)
+		if err != nil {
+			return nil, err
+		}
+		if isADTSSync(b) {
+			h := processADTSHeader(b)
+			l := h.FrameLength()
+			if l >= adtsHeaderLength {
+				next, err := br.Peek(l + 2)
+				if err == nil && isADTSSync(next[l:]) || err == io.EOF && len(next) == l {
+					return h, nil
+				}
+			}
+		}
+		_, err = br.Discard(1)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+//isADTSSync reports whether b starts with an ADTS sync word and a layer of 0.
+func isADTSSync(b []byte) bool {
+	return len(b) >= 2 && b[0] == 0xFF && b[1]&0xF6 == 0xF0
+}
+
+//processADTSHeader converts 7 header bytes into an aacADTSHeader. The bytes
+//are expected to start with a valid sync word.
+//Bits: AAAAAAAA AAAABCCD EEFFFFGH HHIJKLMM MMMMMMMM MMMOOOOO OOOOOOPP
+//A = Sync word (all 1s), B = MPEG version, C = Layer (always 0),
+//D = Protection absent, E = Profile, F = Sampling frequency index,
+//G = Private bit, H = Channel configuration, I = Originality, J = Home,
+//K = Copyright ID bit, L = Copyright ID start, M = Frame length,
+//O = Buffer fullness, P = Number of raw data blocks in frame - 1
+func processADTSHeader(b []byte) aacADTSHeader {
+	h := aacADTSHeader{}
+	h[VersionKey] = (b[1] >> 3) & 0x01                                          //AAAAB>>> & 00000001
+	h["protectionAbsent"] = b[1] & 0x01                                         //AAAABCCD & 00000001
+	h["profile"] = (b[2] >> 6) & 0x03                                           //EE>>>>>> & 00000011
+	h[SampleRateKey] = (b[2] >> 2) & 0x0F                                       //EEFFFF>> & 00001111
+	h["private"] = (b[2] >> 1) & 0x01                                           //EEFFFFG> & 00000001
+	h[ChannelsKey] = ((b[2] & 0x01) << 2) | (b[3] >> 6)                         //H + HH>>>>>>
+	h["frameLength"] = (int(b[3]&0x03) << 11) | (int(b[4]) << 3) | int(b[5]>>5) //13 bits
+	h["bufferFullness"] = (int(b[5]&0x1F) << 6) | int(b[6]>>2)                  //11 bits
+	h["rawDataBlocks"] = int(b[6]&0x03) + 1
+	return h
+}
+
+//AudioObjectType returns the name of the MPEG-4 audio object type, which is
+//the profile plus one.
+func (h aacADTSHeader) AudioObjectType() string {
+	p, ok := h["profile"].(byte)
+	if !ok {
+		return ""
+	}
+	switch p {
+	case 0:
+		return "AAC Main"
+	case 1:
+		return "AAC LC"
+	case 2:
+		return "AAC SSR"
+	case 3:
+		return "AAC LTP"
+	}
+	return ""
+}
+
+//Channels returns the number of channels for the channel configuration. A
+//configuration of 0 means that the channels are defined in the stream itself,
+//in which case 0 is returned.
+func (h aacADTSHeader) Channels() int {
+	c, ok := h[ChannelsKey].(byte)
+	if !ok {
+		return 0
+	}
+	if c == 7 {
+		return 8
+	}
+	return int(c)
+}
+
+//FrameLength returns the length of the frame, including the header.
+func (h aacADTSHeader) FrameLength() int {
+	l, _ := h["frameLength"].(int)
+	return l
+}
+
+func (h aacADTSHeader) Protected() bool {
+	p, ok := h["protectionAbsent"].(byte)
+	//0 bit means protected is true, 1 means false
+	return ok && p == 0
+}
+
+func (h aacADTSHeader) SampleRate() int {
+	sampleRateIndex, ok := h[SampleRateKey].(byte)
+	if !ok {
+		return 0
+	}
+	var adtsSampleRates = []int{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+	if int(sampleRateIndex) >= len(adtsSampleRates) {
+		return 0
+	}
+	return adtsSampleRates[sampleRateIndex]
+}
+
+//SamplesPerFrame returns the number of samples in the frame. Each raw data
+//block holds 1024 samples.
+func (h aacADTSHeader) SamplesPerFrame() int {
+	b, _ := h["rawDataBlocks"].(int)
+	return b * 1024
+}
+
+//Version returns the MPEG version, which is either MPEG-4 or MPEG-2.
+func (h aacADTSHeader) Version() string {
+	v, ok := h[VersionKey].(byte)
+	if !ok {
+		return ""
+	}
+	if v == 0 {
+		return "MPEG-4"
+	}
+	return "MPEG-2"
+}
@@ -0,0 +1,176 @@
+package yurit
+
+//apeID3Tags holds the tags of a file format that has no tag format of its own,
+//but may carry an APEv2 tag, an ID3v2 tag and an ID3v1 tag, e.g. Musepack,
+//True Audio and raw AAC. It is embedded in the metadata of those formats. Each
+//value comes from the APEv2 tag if there is one, else from the ID3v2 tag, else
+//from the ID3v1 tag.
+type apeID3Tags struct {
+	apeTags   *apeTags
+	id3v2Tags *id3v2Tags
+	id3v1tags id3v1tags
+}
+
+//tagsSize returns the number of bytes that all of the tags take up in the file.
+func (t apeID3Tags) tagsSize() int64 {
+	var size int64
+	if t.id3v2Tags != nil {
+		size += 10 + int64(t.id3v2Tags.header.size)
+	}
+	if t.id3v1tags != nil {
+		size += 128
+	}
+	if t.apeTags != nil {
+		size += t.apeTags.Size()
+	}
+	return size
+}
+
+func (t apeID3Tags) Album() string {
+	if t.apeTags != nil {
+		return t.apeTags.Album()
+	} else if t.id3v2Tags != nil {
+		return t.id3v2Tags.Album()
+	} else if t.id3v1tags != nil {
+		return t.id3v1tags.Album()
+	}
+	return ""
+}
+
+func (t apeID3Tags) AlbumArtist() string {
+	if t.apeTags != nil {
+		return t.apeTags.AlbumArtist()
+	} else if t.id3v2Tags != nil {
+		return t.id3v2Tags.AlbumArtist()
+	}
+	//No equivalent value for ID3v1
+	return ""
+}
+
+func (t apeID3Tags) Artist() string {
+	if t.apeTags != nil {
+		return t.apeTags.Artist()
+	} else if t.id3v2Tags != nil {
+		return t.id3v2Tags.Artist()
+	} else if t.id3v1tags != nil {
+		return t.id3v1tags.Artist()
+	}
+	return ""
+}
+
+func (t apeID3Tags) Comment() string {
+	if t.apeTags != nil {
+		return t.apeTags.Comment()
+	} else if t.id3v2Tags != nil {
+		return t.id3v2Tags.Comment()
+	} else if t.id3v1tags != nil {
+		return t.id3v1tags.Comment()
+	}
+	return ""
+}
+
+func (t apeID3Tags) Composer() string {
+	if t.apeTags != nil {
+		return t.apeTags.Composer()
+	} else if t.id3v2Tags != nil {
+		return t.id3v2Tags.Composer()
+	}
+	//No equivalent value for ID3v1
+	return ""
+}
+
+func (t apeID3Tags) Disc() (int, int) {
+	if t.apeTags != nil {
+		return t.apeTags.Disc()
+	} else if t.id3v2Tags != nil {
+		return t.id3v2Tags.Disc()
+	}
+	//No equivalent value for ID3v1
+	return 0, 0
+}
+
+func (t apeID3Tags) Format() Format {
+	if t.apeTags != nil {
+		return t.apeTags.Format()
+	} else if t.id3v2Tags != nil {
+		return t.id3v2Tags.Format()
+	} else if t.id3v1tags != nil {
+		return t.id3v1tags.Format()
+	}
+	return UnknownFormat
+}
+
+func (t apeID3Tags) Genre() string {
+	if t.apeTags != nil {
+		return t.apeTags.Genre()
+	} else if t.id3v2Tags != nil {
+		return t.id3v2Tags.Genre()
+	} else if t.id3v1tags != nil {
+		return t.id3v1tags.Genre()
+	}
+	return ""
+}
+
+func (t apeID3Tags) Lyrics() string {
+	if t.apeTags != nil {
+		return t.apeTags.Lyrics()
+	} else if t.id3v2Tags != nil {
+		return t.id3v2Tags.Lyrics()
+	}
+	//No equivalent value for ID3v1
+	return ""
+}
+
+func (t apeID3Tags) Picture() *Picture {
+	if t.apeTags != nil {
+		return t.apeTags.Picture()
+	} else if t.id3v2Tags != nil {
+		return t.id3v2Tags.Picture()
+	}
+	//No equivalent value for ID3v1
+	return nil
+}
+
+func (t apeID3Tags) Raw() map[string]interface{} {
+	if t.apeTags != nil {
+		return t.apeTags.Raw()
+	} else if t.id3v2Tags != nil {
+		return t.id3v2Tags.Raw()
+	} else if t.id3v1tags != nil {
+		return t.id3v1tags.Raw()
+	}
+	return nil
+}
+
+func (t apeID3Tags) Title() string {
+	if t.apeTags != nil {
+		return t.apeTags.Title()
+	} else if t.id3v2Tags != nil {
+		return t.id3v2Tags.Title()
+	} else if t.id3v1tags != nil {
+		return t.id3v1tags.Title()
+	}
+	return ""
+}
+
+func (t apeID3Tags) Track() (int, int) {
+	if t.apeTags != nil {
+		return t.apeTags.Track()
+	} else if t.id3v2Tags != nil {
+		return t.id3v2Tags.Track()
+	} else if t.id3v1tags != nil {
+		return t.id3v1tags.Track()
+	}
+	return 0, 0
+}
+
+func (t apeID3Tags) Year() int {
+	if t.apeTags != nil {
+		return t.apeTags.Year()
+	} else if t.id3v2Tags != nil {
+		return t.id3v2Tags.Year()
+	} else if t.id3v1tags != nil {
+		return t.id3v1tags.Year()
+	}
+	return 0
+}
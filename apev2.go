@@ -0,0 +1,234 @@
+package yurit
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+//apeTags holds an APEv2 (or APEv1) tag, which is usually found at the end of
+//Musepack, True Audio, Monkey's Audio and WavPack files, and sometimes MP3s.
+//Item keys are case insensitive, so they are stored in lowercase.
+//https://wiki.hydrogenaud.io/index.php?title=APEv2_specification
+type apeTags struct {
+	version int
+	size    int64
+	items   map[string]interface{}
+}
+
+const (
+	apeTagID           = "APETAGEX"
+	apeTagFooterLength = 32
+	//Flags in the header/footer
+	apeTagHasHeader = 1 << 31
+	//Item types in the item flags
+	apeItemTypeMask   = 0x06
+	apeItemTypeBinary = 0x02
+)
+
+//ReadAPEv2Tags reads an APE tag from the end of the io.ReadSeeker, skipping over
+//an ID3v1 tag if there is one. If there is no APE tag, returns nil.
+func ReadAPEv2Tags(r io.ReadSeeker) (*apeTags, error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	//The APE tag is located right before the ID3v1 tag if there is one
+	if end >= 128 {
+		_, err = r.Seek(-128, io.SeekEnd)
+		if err != nil {
+			return nil, err
+		}
+		b, err := readBytes(r, 3)
+		if err != nil {
+			return nil, err
+		}
+		if string(b) == "TAG" {
+			end -= 128
+		}
+	}
+	if end < apeTagFooterLength {
+		return nil, nil
+	}
+	_, err = r.Seek(end-apeTagFooterLength, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	footer, err := readBytes(r, apeTagFooterLength)
+	if err != nil {
+		return nil, err
+	}
+	if string(footer[0:8]) != apeTagID {
+		return nil, nil
+	}
+	t := apeTags{}
+	t.version = int(getUint32LittleAsInt64(footer[8:12]))
+	//Tag size includes the items and the footer but not the header
+	tagSize := getUint32LittleAsInt64(footer[12:16])
+	itemCount := getUint32LittleAsInt64(footer[16:20])
+	flags := getUint32LittleAsInt64(footer[20:24])
+	if tagSize < apeTagFooterLength || tagSize > end {
+		return nil, fmt.Errorf("invalid APE tag size: %d", tagSize)
+	}
+	t.size = tagSize
+	if flags&apeTagHasHeader != 0 {
+		t.size += apeTagFooterLength
+	}
+	_, err = r.Seek(end-tagSize, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	b, err := readBytes(r, uint(tagSize-apeTagFooterLength))
+	if err != nil {
+		return nil, err
+	}
+	t.items, err = processAPEItems(b, int(itemCount))
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+//processAPEItems reads count items from b. Each item is made up of a 4 byte
+//value length, 4 bytes of flags, a NUL terminated key and then the value.
+func processAPEItems(b []byte, count int) (map[string]interface{}, error) {
+	items := map[string]interface{}{}
+	offset := 0
+	for i := 0; i < count; i++ {
+		if len(b) < offset+8 {
+			return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", offset+8, len(b))
+		}
+		valueLen := int(getUint32LittleAsInt64(b[offset : offset+4]))
+		itemFlags := getUint32LittleAsInt64(b[offset+4 : offset+8])
+		offset += 8
+		keyLen := bytes.IndexByte(b[offset:], 0)
+		if keyLen < 0 {
+			return nil, fmt.Errorf("invalid APE item key")
+		}
+		key := strings.ToLower(string(b[offset : offset+keyLen]))
+		offset += keyLen + 1
+		if valueLen < 0 || len(b) < offset+valueLen {
+			return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", offset+valueLen, len(b))
+		}
+		value := b[offset : offset+valueLen]
+		offset += valueLen
+		if itemFlags&apeItemTypeMask == apeItemTypeBinary {
+			if strings.HasPrefix(key, "cover art") {
+				items[key] = processAPECoverArt(key, value)
+			} else {
+				items[key] = value
+			}
+			continue
+		}
+		//Text and external links are both UTF-8. Multiple values are separated by
+		//NUL and kept as they are.
+		items[key] = string(value)
+	}
+	return items, nil
+}
+
+//processAPECoverArt converts a binary cover art item, which holds a NUL
+//terminated file name followed by the image data, into a Picture.
+func processAPECoverArt(key string, b []byte) *Picture {
+	p := &Picture{Type: key}
+	name := b
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		name = b[:i]
+		p.Data = b[i+1:]
+	}
+	p.Description = string(name)
+	if i := strings.LastIndex(p.Description, "."); i >= 0 {
+		p.Ext = strings.ToLower(p.Description[i+1:])
+	}
+	switch p.Ext {
+	case "jpg", "jpeg":
+		p.MIMEType = "image/jpeg"
+	case "png":
+		p.MIMEType = "image/png"
+	}
+	//Cover Art (Front) corresponds to the front cover picture type
+	if key == "cover art (front)" {
+		p.Type = pictureTypes[0x03]
+	}
+	return p
+}
+
+//getString returns the first value of the text item with the given key.
+func (t apeTags) getString(k string) string {
+	s, _ := t.items[k].(string)
+	if i := strings.IndexByte(s, 0); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
+
+func (t apeTags) Album() string {
+	return t.getString("album")
+}
+
+func (t apeTags) AlbumArtist() string {
+	return t.getString("album artist")
+}
+
+func (t apeTags) Artist() string {
+	return t.getString("artist")
+}
+
+func (t apeTags) Comment() string {
+	return t.getString("comment")
+}
+
+func (t apeTags) Composer() string {
+	return t.getString("composer")
+}
+
+func (t apeTags) Disc() (int, int) {
+	return parseXofN(t.getString("disc"))
+}
+
+func (t apeTags) Format() Format {
+	return APEv2
+}
+
+func (t apeTags) Genre() string {
+	return t.getString("genre")
+}
+
+func (t apeTags) Lyrics() string {
+	return t.getString("lyrics")
+}
+
+func (t apeTags) Picture() *Picture {
+	p, _ := t.items["cover art (front)"].(*Picture)
+	return p
+}
+
+func (t apeTags) Raw() map[string]interface{} {
+	return t.items
+}
+
+//Size returns the number of bytes that the tag takes up in the file, including
+//its header and footer.
+func (t apeTags) Size() int64 {
+	return t.size
+}
+
+func (t apeTags) Title() string {
+	return t.getString("title")
+}
+
+func (t apeTags) Track() (int, int) {
+	return parseXofN(t.getString("track"))
+}
+
+func (t apeTags) Year() int {
+	//Year may hold a full date, e.g. 2006-01-02, so only use the first 4 digits
+	s := t.getString("year")
+	if len(s) > 4 {
+		s = s[:4]
+	}
+	year, _ := strconv.Atoi(s)
+	return year
+}
@@ -0,0 +1,86 @@
+package yurit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func apeTestItem(key, value string, flags uint32) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint32(b[0:4], uint32(len(value)))
+	binary.LittleEndian.PutUint32(b[4:8], flags)
+	b = append(b, key...)
+	b = append(b, 0)
+	return append(b, value...)
+}
+
+func apeTestTag(items []byte, count int, withHeader bool) []byte {
+	headerFooter := func(flags uint32) []byte {
+		b := []byte(apeTagID)
+		v := make([]byte, 24)
+		binary.LittleEndian.PutUint32(v[0:4], 2000)
+		binary.LittleEndian.PutUint32(v[4:8], uint32(len(items)+apeTagFooterLength))
+		binary.LittleEndian.PutUint32(v[8:12], uint32(count))
+		binary.LittleEndian.PutUint32(v[12:16], flags)
+		return append(b, v...)
+	}
+	var b []byte
+	if withHeader {
+		b = append(b, headerFooter(apeTagHasHeader|1<<29)...)
+		b = append(b, items...)
+		return append(b, headerFooter(apeTagHasHeader)...)
+	}
+	b = append(b, items...)
+	return append(b, headerFooter(0)...)
+}
+
+func TestReadAPEv2Tags(t *testing.T) {
+	audio := bytes.Repeat([]byte{0xFF}, 200)
+	id3v1 := append([]byte("TAG"), make([]byte, 125)...)
+	items := append(apeTestItem("Title", "Song", 0), apeTestItem("Track", "3/12", 0)...)
+	items = append(items, apeTestItem("ARTIST", "A\x00B", 0)...)
+	withHeader := apeTestTag(items, 3, true)
+	withoutHeader := apeTestTag(items, 3, false)
+
+	tests := []struct {
+		input  []byte
+		found  bool
+		size   int64
+		title  string
+		artist string
+		track  int
+		total  int
+	}{
+		{audio, false, 0, "", "", 0, 0},
+		{append(append([]byte{}, audio...), withHeader...), true, int64(len(withHeader)), "Song", "A", 3, 12},
+		{append(append(append([]byte{}, audio...), withoutHeader...), id3v1...), true, int64(len(withoutHeader)), "Song", "A", 3, 12},
+	}
+
+	for ii, tt := range tests {
+		tags, err := ReadAPEv2Tags(bytes.NewReader(tt.input))
+		if err != nil {
+			t.Errorf("[%d] ReadAPEv2Tags() returned unexpected error: %v", ii, err)
+			continue
+		}
+		if (tags != nil) != tt.found {
+			t.Errorf("[%d] ReadAPEv2Tags() found = %v, expected %v", ii, tags != nil, tt.found)
+			continue
+		}
+		if tags == nil {
+			continue
+		}
+		if tags.Size() != tt.size {
+			t.Errorf("[%d] ReadAPEv2Tags() size = %d, expected %d", ii, tags.Size(), tt.size)
+		}
+		if tags.Title() != tt.title {
+			t.Errorf("[%d] ReadAPEv2Tags() title = %q, expected %q", ii, tags.Title(), tt.title)
+		}
+		if tags.Artist() != tt.artist {
+			t.Errorf("[%d] ReadAPEv2Tags() artist = %q, expected %q", ii, tags.Artist(), tt.artist)
+		}
+		if track, total := tags.Track(); track != tt.track || total != tt.total {
+			t.Errorf("[%d] ReadAPEv2Tags() track = (%d, %d), expected (%d, %d)", ii, track, total, tt.track, tt.total)
+		}
+	}
+}
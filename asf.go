@@ -0,0 +1,647 @@
+package yurit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//asfGUID converts a GUID from its canonical string form into the byte order
+//used in ASF files, where the first three groups are little endian.
+func asfGUID(s string) [16]byte {
+	var g [16]byte
+	b, _ := hex.DecodeString(strings.Replace(s, "-", "", -1))
+	copy(g[:], b)
+	g[0], g[1], g[2], g[3] = g[3], g[2], g[1], g[0]
+	g[4], g[5] = g[5], g[4]
+	g[6], g[7] = g[7], g[6]
+	return g
+}
+
+//ASF object GUIDs that are used by this package, see the ASF specification for
+//the full list.
+var (
+	asfHeaderObjectGUID        = asfGUID("75B22630-668E-11CF-A6D9-00AA0062CE6C")
+	asfFilePropertiesGUID      = asfGUID("8CABDCA1-A947-11CF-8EE4-00C00C205365")
+	asfStreamPropertiesGUID    = asfGUID("B7DC0791-A9B7-11CF-8EE6-00C00C205365")
+	asfContentDescriptionGUID  = asfGUID("75B22633-668E-11CF-A6D9-00AA0062CE6C")
+	asfExtendedContentDescGUID = asfGUID("D2D0A440-E307-11D2-97F0-00A0C95EA850")
+	asfHeaderExtensionGUID     = asfGUID("5FBF03B5-A92E-11CF-8EE3-00C00C205365")
+	asfMetadataGUID            = asfGUID("C5F8CBEA-5BAF-4877-8467-AA8C44FA4CCA")
+	asfMetadataLibraryGUID     = asfGUID("44231C94-9498-49D1-A141-1D134E457054")
+	asfMarkerGUID              = asfGUID("F487CD01-A951-11CF-8EE6-00C00C205365")
+	asfAudioMediaGUID          = asfGUID("F8699E40-5B4D-11CF-A8FD-00805F5C442B")
+)
+
+//asfHeaderObjectMinimumLength is the length of the header object's GUID, size,
+//number of header objects and two reserved bytes.
+const asfHeaderObjectMinimumLength = 30
+
+//ASF attribute data types used by the Extended Content Description, Metadata
+//and Metadata Library objects.
+const (
+	asfUnicodeType   = 0
+	asfByteArrayType = 1
+	asfBoolType      = 2
+	asfDWordType     = 3
+	asfQWordType     = 4
+	asfWordType      = 5
+	asfGUIDType      = 6
+)
+
+//ASFMetadata is a collection of metadata and other useful data from an ASF
+//container, which is used by Windows Media Audio (.wma) files.
+type ASFMetadata struct {
+	fileProperties     asfFileProperties
+	streamProperties   asfStreamProperties
+	contentDescription map[string]string
+	attributes         asfAttributes
+	markers            []asfMarker
+}
+
+// ReadASF reads ASF metadata from the io.ReadSeeker, returning the resulting
+// metadata in a Metadata implementation, or non-nil error if there was a problem.
+func ReadASF(r io.ReadSeeker) (*ASFMetadata, error) {
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.Seek(start, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	b, err := readBytes(r, uint(asfHeaderObjectMinimumLength))
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(b[0:16], asfHeaderObjectGUID[:]) {
+		return nil, errors.New("expected ASF header object")
+	}
+	//The size is checked against the file before anything is allocated for it
+	headerSize := binary.LittleEndian.Uint64(b[16:24])
+	if headerSize < uint64(asfHeaderObjectMinimumLength) || headerSize > uint64(end-start) {
+		return nil, fmt.Errorf("invalid ASF header object size: %d", headerSize)
+	}
+	//The header object holds all of the objects we are interested in, the data
+	//object which follows it only holds packets of audio data.
+	b, err = readBytes(r, uint(headerSize)-uint(asfHeaderObjectMinimumLength))
+	if err != nil {
+		return nil, err
+	}
+	m := &ASFMetadata{attributes: asfAttributes{}}
+	err = m.processASFObjects(b)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+//processASFObjects iterates through a list of ASF objects and processes the
+//ones that we are interested in.
+func (m *ASFMetadata) processASFObjects(b []byte) error {
+	offset := 0
+	for offset+24 <= len(b) {
+		var guid [16]byte
+		copy(guid[:], b[offset:offset+16])
+		size := binary.LittleEndian.Uint64(b[offset+16 : offset+24])
+		if size < 24 || uint64(len(b)-offset) < size {
+			return fmt.Errorf("invalid ASF object size: %d", size)
+		}
+		data := b[offset+24 : offset+int(size)]
+		var err error
+		switch guid {
+		case asfFilePropertiesGUID:
+			m.fileProperties, err = processASFFileProperties(data)
+		case asfStreamPropertiesGUID:
+			//Keep the first audio stream only
+			if m.streamProperties == nil {
+				m.streamProperties, err = processASFStreamProperties(data)
+			}
+		case asfContentDescriptionGUID:
+			m.contentDescription, err = processASFContentDescription(data)
+		case asfExtendedContentDescGUID:
+			err = m.attributes.processExtendedContentDescription(data)
+		case asfHeaderExtensionGUID:
+			//Header extension data is 16 reserved bytes, 2 more reserved bytes and
+			//4 bytes of data size followed by more objects
+			if err = checkLen(data, 22); err == nil {
+				err = m.processASFObjects(data[22:])
+			}
+		case asfMetadataGUID, asfMetadataLibraryGUID:
+			err = m.attributes.processMetadataLibrary(data)
+		case asfMarkerGUID:
+			m.markers, err = processASFMarkers(data)
+		}
+		if err != nil {
+			return err
+		}
+		offset += int(size)
+	}
+	return nil
+}
+
+//asfFileProperties holds global information about an ASF file.
+type asfFileProperties map[string]interface{}
+
+func processASFFileProperties(b []byte) (asfFileProperties, error) {
+	if err := checkLen(b, 80); err != nil {
+		return nil, err
+	}
+	fp := asfFileProperties{}
+	fp["fileSize"] = int64(binary.LittleEndian.Uint64(b[16:24]))
+	fp["dataPacketsCount"] = int64(binary.LittleEndian.Uint64(b[32:40]))
+	//Durations are in 100-nanosecond units, preroll is in milliseconds
+	fp["playDuration"] = int64(binary.LittleEndian.Uint64(b[40:48]))
+	fp["sendDuration"] = int64(binary.LittleEndian.Uint64(b[48:56]))
+	fp["preroll"] = int64(binary.LittleEndian.Uint64(b[56:64]))
+	fp[FlagsKey] = binary.LittleEndian.Uint32(b[64:68])
+	fp["minimumDataPacketSize"] = int64(binary.LittleEndian.Uint32(b[68:72]))
+	fp["maximumDataPacketSize"] = int64(binary.LittleEndian.Uint32(b[72:76]))
+	fp[MaximumBitrateKey] = int64(binary.LittleEndian.Uint32(b[76:80]))
+	return fp, nil
+}
+
+//Duration returns the play duration minus the preroll, which is included in the
+//play duration but not played.
+func (fp asfFileProperties) Duration() time.Duration {
+	d, ok := fp["playDuration"].(int64)
+	if !ok {
+		return time.Duration(0)
+	}
+	p, _ := fp["preroll"].(int64)
+	duration := time.Duration(d*100) - time.Duration(p)*time.Millisecond
+	if duration < 0 {
+		return time.Duration(0)
+	}
+	return duration
+}
+
+//asfMarker is a named position in an ASF file, from the Marker object.
+type asfMarker struct {
+	//presentationTime is in 100-nanosecond units and includes the preroll
+	presentationTime int64
+	description      string
+}
+
+//processASFMarkers reads the markers in a Marker object, which follow 16
+//reserved bytes, the marker count, 2 more reserved bytes and the name of the
+//object.
+func processASFMarkers(b []byte) ([]asfMarker, error) {
+	if err := checkLen(b, 24); err != nil {
+		return nil, err
+	}
+	count := int(binary.LittleEndian.Uint32(b[16:20]))
+	offset := 24 + int(binary.LittleEndian.Uint16(b[22:24]))
+	var markers []asfMarker
+	for i := 0; i < count; i++ {
+		//Offset (8), presentation time (8), entry length (2), send time (4),
+		//flags (4) and description length in characters (4)
+		if err := checkLen(b, uint(offset+30)); err != nil {
+			return nil, err
+		}
+		presentationTime := int64(binary.LittleEndian.Uint64(b[offset+8 : offset+16]))
+		descriptionLen := int(binary.LittleEndian.Uint32(b[offset+26:offset+30])) * 2
+		offset += 30
+		if err := checkLen(b, uint(offset+descriptionLen)); err != nil {
+			return nil, err
+		}
+		description, err := decodeASFString(b[offset : offset+descriptionLen])
+		if err != nil {
+			return nil, err
+		}
+		markers = append(markers, asfMarker{presentationTime: presentationTime, description: description})
+		offset += descriptionLen
+	}
+	return markers, nil
+}
+
+//asfStreamProperties holds information about the first audio stream in an ASF
+//file, taken from its WAVEFORMATEX structure.
+type asfStreamProperties map[string]interface{}
+
+func processASFStreamProperties(b []byte) (asfStreamProperties, error) {
+	if err := checkLen(b, 54); err != nil {
+		return nil, err
+	}
+	//Not an audio stream, ignore it
+	if !bytes.Equal(b[0:16], asfAudioMediaGUID[:]) {
+		return nil, nil
+	}
+	typeSpecificLen := int(binary.LittleEndian.Uint32(b[40:44]))
+	sp := asfStreamProperties{}
+	sp["streamNumber"] = int(binary.LittleEndian.Uint16(b[48:50]) & 0x7F)
+	if err := checkLen(b, uint(54+typeSpecificLen)); err != nil {
+		return nil, err
+	}
+	wf := b[54 : 54+typeSpecificLen]
+	if err := checkLen(wf, 16); err != nil {
+		return nil, err
+	}
+	sp["codecID"] = int(binary.LittleEndian.Uint16(wf[0:2]))
+	sp[ChannelsKey] = int(binary.LittleEndian.Uint16(wf[2:4]))
+	sp[SampleRateKey] = int(binary.LittleEndian.Uint32(wf[4:8]))
+	sp[AverageBitrateKey] = int(binary.LittleEndian.Uint32(wf[8:12])) * 8
+	sp["blockAlign"] = int(binary.LittleEndian.Uint16(wf[12:14]))
+	sp[SampleSizeKey] = int(binary.LittleEndian.Uint16(wf[14:16]))
+	return sp, nil
+}
+
+//Codec returns the name of the audio codec for the most common WAVE format
+//codec IDs found in ASF files.
+func (sp asfStreamProperties) Codec() string {
+	id, ok := sp["codecID"].(int)
+	if !ok {
+		return ""
+	}
+	switch id {
+	case 0x0160:
+		return "WMA v1"
+	case 0x0161:
+		return "WMA v2"
+	case 0x0162:
+		return "WMA Pro"
+	case 0x0163:
+		return "WMA Lossless"
+	case 0x000A:
+		return "WMA Voice"
+	case 0x0055:
+		return "MP3"
+	case 0x0001:
+		return "PCM"
+	}
+	return fmt.Sprintf("0x%04X", id)
+}
+
+//processASFContentDescription reads the Content Description object which holds
+//the title, author, copyright, description and rating as UTF-16 strings.
+func processASFContentDescription(b []byte) (map[string]string, error) {
+	if err := checkLen(b, 10); err != nil {
+		return nil, err
+	}
+	cd := map[string]string{}
+	offset := 10
+	for i, name := range []string{"title", "author", "copyright", "description", "rating"} {
+		l := int(binary.LittleEndian.Uint16(b[i*2 : i*2+2]))
+		if err := checkLen(b, uint(offset+l)); err != nil {
+			return nil, err
+		}
+		s, err := decodeASFString(b[offset : offset+l])
+		if err != nil {
+			return nil, err
+		}
+		cd[name] = s
+		offset += l
+	}
+	return cd, nil
+}
+
+//asfAttributes holds the named attributes (e.g. WM/AlbumTitle) found in the
+//Extended Content Description, Metadata and Metadata Library objects.
+//Attribute names are kept as they appear in the file.
+type asfAttributes map[string]interface{}
+
+//processExtendedContentDescription reads the attributes in an Extended Content
+//Description object. These take precedence over attributes of the same name in
+//the Metadata and Metadata Library objects.
+func (a asfAttributes) processExtendedContentDescription(b []byte) error {
+	if err := checkLen(b, 2); err != nil {
+		return err
+	}
+	count := int(binary.LittleEndian.Uint16(b[0:2]))
+	offset := 2
+	for i := 0; i < count; i++ {
+		if err := checkLen(b, uint(offset+2)); err != nil {
+			return err
+		}
+		nameLen := int(binary.LittleEndian.Uint16(b[offset : offset+2]))
+		offset += 2
+		if err := checkLen(b, uint(offset+nameLen+4)); err != nil {
+			return err
+		}
+		name, err := decodeASFString(b[offset : offset+nameLen])
+		if err != nil {
+			return err
+		}
+		offset += nameLen
+		dataType := int(binary.LittleEndian.Uint16(b[offset : offset+2]))
+		dataLen := int(binary.LittleEndian.Uint16(b[offset+2 : offset+4]))
+		offset += 4
+		if err := checkLen(b, uint(offset+dataLen)); err != nil {
+			return err
+		}
+		value, err := processASFAttributeValue(name, dataType, b[offset:offset+dataLen], true)
+		if err != nil {
+			return err
+		}
+		a[name] = value
+		offset += dataLen
+	}
+	return nil
+}
+
+//processMetadataLibrary reads the attributes in a Metadata or Metadata Library
+//object, which share the same layout. Attributes that have already been found
+//are not overwritten.
+func (a asfAttributes) processMetadataLibrary(b []byte) error {
+	if err := checkLen(b, 2); err != nil {
+		return err
+	}
+	count := int(binary.LittleEndian.Uint16(b[0:2]))
+	offset := 2
+	for i := 0; i < count; i++ {
+		//Language list index (2), stream number (2), name length (2), data type
+		//(2) and data length (4)
+		if err := checkLen(b, uint(offset+12)); err != nil {
+			return err
+		}
+		nameLen := int(binary.LittleEndian.Uint16(b[offset+4 : offset+6]))
+		dataType := int(binary.LittleEndian.Uint16(b[offset+6 : offset+8]))
+		dataLen := int(binary.LittleEndian.Uint32(b[offset+8 : offset+12]))
+		offset += 12
+		if err := checkLen(b, uint(offset+nameLen+dataLen)); err != nil {
+			return err
+		}
+		name, err := decodeASFString(b[offset : offset+nameLen])
+		if err != nil {
+			return err
+		}
+		offset += nameLen
+		value, err := processASFAttributeValue(name, dataType, b[offset:offset+dataLen], false)
+		if err != nil {
+			return err
+		}
+		if _, ok := a[name]; !ok {
+			a[name] = value
+		}
+		offset += dataLen
+	}
+	return nil
+}
+
+//processASFAttributeValue converts the data of an attribute according to its
+//data type. Booleans are 4 bytes long in the Extended Content Description
+//object, but only 2 bytes long elsewhere.
+func processASFAttributeValue(name string, dataType int, b []byte, longBool bool) (interface{}, error) {
+	switch dataType {
+	case asfUnicodeType:
+		return decodeASFString(b)
+	case asfByteArrayType:
+		if name == "WM/Picture" {
+			return processASFPicture(b)
+		}
+		return b, nil
+	case asfBoolType:
+		if longBool {
+			if err := checkLen(b, 4); err != nil {
+				return nil, err
+			}
+			return binary.LittleEndian.Uint32(b) != 0, nil
+		}
+		if err := checkLen(b, 2); err != nil {
+			return nil, err
+		}
+		return binary.LittleEndian.Uint16(b) != 0, nil
+	case asfDWordType:
+		if err := checkLen(b, 4); err != nil {
+			return nil, err
+		}
+		return int(binary.LittleEndian.Uint32(b)), nil
+	case asfQWordType:
+		if err := checkLen(b, 8); err != nil {
+			return nil, err
+		}
+		return int64(binary.LittleEndian.Uint64(b)), nil
+	case asfWordType:
+		if err := checkLen(b, 2); err != nil {
+			return nil, err
+		}
+		return int(binary.LittleEndian.Uint16(b)), nil
+	case asfGUIDType:
+		return b, nil
+	}
+	return nil, fmt.Errorf("unknown ASF attribute data type: %d", dataType)
+}
+
+//processASFPicture reads a WM/Picture attribute.
+//Picture type (1), data length (4), MIME type (null terminated UTF-16),
+//description (null terminated UTF-16), picture data
+func processASFPicture(b []byte) (*Picture, error) {
+	if err := checkLen(b, 5); err != nil {
+		return nil, err
+	}
+	picType := b[0]
+	dataLen := int(binary.LittleEndian.Uint32(b[1:5]))
+	rest := b[5:]
+	mimeType, rest, err := splitASFString(rest)
+	if err != nil {
+		return nil, err
+	}
+	desc, rest, err := splitASFString(rest)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLen(rest, uint(dataLen)); err != nil {
+		return nil, err
+	}
+	var ext string
+	switch mimeType {
+	case "image/jpeg":
+		ext = "jpg"
+	case "image/png":
+		ext = "png"
+	case "image/gif":
+		ext = "gif"
+	}
+	return &Picture{
+		Ext:         ext,
+		MIMEType:    mimeType,
+		Type:        pictureTypes[picType],
+		Description: desc,
+		Data:        rest[:dataLen],
+	}, nil
+}
+
+//splitASFString reads a null terminated UTF-16 string from the start of b and
+//returns it along with the remaining bytes.
+func splitASFString(b []byte) (string, []byte, error) {
+	for i := 0; i+1 < len(b); i += 2 {
+		if b[i] == 0 && b[i+1] == 0 {
+			s, err := decodeUTF16(b[:i], binary.LittleEndian)
+			return s, b[i+2:], err
+		}
+	}
+	return "", nil, errors.New("invalid encoding: expected null terminated UTF-16 string")
+}
+
+//decodeASFString decodes a UTF-16LE string, which is usually null terminated.
+func decodeASFString(b []byte) (string, error) {
+	if len(b)%2 != 0 {
+		b = b[:len(b)-1]
+	}
+	s, err := decodeUTF16(b, binary.LittleEndian)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(s, "\x00"), nil
+}
+
+func (a asfAttributes) getString(name string) string {
+	switch v := a[name].(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	}
+	return ""
+}
+
+func (m ASFMetadata) Album() string {
+	return m.attributes.getString("WM/AlbumTitle")
+}
+
+func (m ASFMetadata) AlbumArtist() string {
+	return m.attributes.getString("WM/AlbumArtist")
+}
+
+func (m ASFMetadata) Artist() string {
+	return m.contentDescription["author"]
+}
+
+//Attributes returns all attributes found in the Extended Content Description,
+//Metadata and Metadata Library objects.
+func (m ASFMetadata) Attributes() map[string]interface{} {
+	return m.attributes
+}
+
+func (m ASFMetadata) AverageBitrate() int {
+	if b, ok := m.streamProperties[AverageBitrateKey].(int); ok && b != 0 {
+		return b
+	}
+	b, _ := m.fileProperties[MaximumBitrateKey].(int64)
+	return int(b)
+}
+
+//Chapters returns the markers of the Marker object as chapters, with the
+//preroll taken off their presentation times.
+func (m ASFMetadata) Chapters() []Chapter {
+	if len(m.markers) == 0 {
+		return nil
+	}
+	preroll, _ := m.fileProperties["preroll"].(int64)
+	chapters := make([]Chapter, len(m.markers))
+	for i, marker := range m.markers {
+		start := time.Duration(marker.presentationTime*100) - time.Duration(preroll)*time.Millisecond
+		if start < 0 {
+			start = 0
+		}
+		chapters[i] = Chapter{Title: marker.description, Start: start}
+	}
+	return completeChapters(chapters, m.Duration())
+}
+
+//Codec returns the name of the audio codec used by the first audio stream.
+func (m ASFMetadata) Codec() string {
+	return m.streamProperties.Codec()
+}
+
+func (m ASFMetadata) Comment() string {
+	return m.contentDescription["description"]
+}
+
+func (m ASFMetadata) Composer() string {
+	return m.attributes.getString("WM/Composer")
+}
+
+//ContentDescription returns the title, author, copyright, description and
+//rating found in the Content Description object.
+func (m ASFMetadata) ContentDescription() map[string]string {
+	return m.contentDescription
+}
+
+func (m ASFMetadata) Disc() (int, int) {
+	return parseXofN(m.attributes.getString("WM/PartOfSet"))
+}
+
+func (m ASFMetadata) Duration() time.Duration {
+	return m.fileProperties.Duration()
+}
+
+func (m ASFMetadata) FileProperties() map[string]interface{} {
+	return m.fileProperties
+}
+
+func (m ASFMetadata) FileType() FileType {
+	return WMA
+}
+
+func (m ASFMetadata) Format() Format {
+	if m.contentDescription != nil || len(m.attributes) > 0 {
+		return ASF
+	}
+	return UnknownFormat
+}
+
+func (m ASFMetadata) Genre() string {
+	return m.attributes.getString("WM/Genre")
+}
+
+func (m ASFMetadata) Lyrics() string {
+	return m.attributes.getString("WM/Lyrics")
+}
+
+func (m ASFMetadata) Picture() *Picture {
+	p, _ := m.attributes["WM/Picture"].(*Picture)
+	return p
+}
+
+func (m ASFMetadata) Raw() map[string]interface{} {
+	raw := map[string]interface{}{}
+	for k, v := range m.contentDescription {
+		raw[k] = v
+	}
+	for k, v := range m.attributes {
+		raw[k] = v
+	}
+	return raw
+}
+
+//StreamProperties returns information about the first audio stream.
+func (m ASFMetadata) StreamProperties() map[string]interface{} {
+	return m.streamProperties
+}
+
+func (m ASFMetadata) Title() string {
+	return m.contentDescription["title"]
+}
+
+func (m ASFMetadata) Track() (int, int) {
+	if _, ok := m.attributes["WM/TrackNumber"]; ok {
+		return parseXofN(m.attributes.getString("WM/TrackNumber"))
+	}
+	//WM/Track is the older, zero based, track number
+	if t, err := strconv.Atoi(m.attributes.getString("WM/Track")); err == nil {
+		return t + 1, 0
+	}
+	return 0, 0
+}
+
+func (m ASFMetadata) Year() int {
+	y := m.attributes.getString("WM/Year")
+	if len(y) >= 4 {
+		year, _ := strconv.Atoi(y[:4])
+		return year
+	}
+	return 0
+}
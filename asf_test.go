@@ -0,0 +1,359 @@
+package yurit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+func asfTestObject(guid [16]byte, data ...[]byte) []byte {
+	content := bytes.Join(data, nil)
+	b := append([]byte{}, guid[:]...)
+	b = append(b, asfTestUint64(uint64(24+len(content)))...)
+	return append(b, content...)
+}
+
+//asfTestString encodes s as a null terminated UTF-16LE string.
+func asfTestString(s string) []byte {
+	var b []byte
+	for _, x := range utf16.Encode([]rune(s + "\x00")) {
+		b = append(b, asfTestUint16(x)...)
+	}
+	return b
+}
+
+func asfTestUint16(x uint16) []byte {
+	b := make([]byte, 2)
+	binary.LittleEndian.PutUint16(b, x)
+	return b
+}
+
+func asfTestUint32(x uint32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, x)
+	return b
+}
+
+func asfTestUint64(x uint64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, x)
+	return b
+}
+
+func asfTestFileProperties(playDuration, preroll uint64) []byte {
+	b := make([]byte, 80)
+	binary.LittleEndian.PutUint64(b[16:24], 123456)
+	binary.LittleEndian.PutUint64(b[32:40], 42)
+	binary.LittleEndian.PutUint64(b[40:48], playDuration)
+	binary.LittleEndian.PutUint64(b[56:64], preroll)
+	binary.LittleEndian.PutUint32(b[64:68], 2)
+	binary.LittleEndian.PutUint32(b[76:80], 192000)
+	return b
+}
+
+func asfTestStreamProperties(mediaType [16]byte, codecID uint16) []byte {
+	wf := bytes.Join([][]byte{
+		asfTestUint16(codecID),
+		asfTestUint16(2),
+		asfTestUint32(44100),
+		asfTestUint32(16000),
+		asfTestUint16(2973),
+		asfTestUint16(16),
+	}, nil)
+	b := make([]byte, 54)
+	copy(b, mediaType[:])
+	binary.LittleEndian.PutUint32(b[40:44], uint32(len(wf)))
+	binary.LittleEndian.PutUint16(b[48:50], 1)
+	return append(b, wf...)
+}
+
+func asfTestContentDescription(fields ...string) []byte {
+	var lengths, strs []byte
+	for _, f := range fields {
+		s := asfTestString(f)
+		lengths = append(lengths, asfTestUint16(uint16(len(s)))...)
+		strs = append(strs, s...)
+	}
+	return append(lengths, strs...)
+}
+
+func asfTestDescriptor(name string, dataType uint16, value []byte) []byte {
+	n := asfTestString(name)
+	return bytes.Join([][]byte{
+		asfTestUint16(uint16(len(n))), n,
+		asfTestUint16(dataType), asfTestUint16(uint16(len(value))), value,
+	}, nil)
+}
+
+func asfTestMetadataRecord(name string, dataType uint16, value []byte) []byte {
+	n := asfTestString(name)
+	return bytes.Join([][]byte{
+		asfTestUint16(0), asfTestUint16(0), asfTestUint16(uint16(len(n))),
+		asfTestUint16(dataType), asfTestUint32(uint32(len(value))), n, value,
+	}, nil)
+}
+
+//asfTestMarkers returns the data of a Marker object holding a marker for each
+//pair of presentation time and description.
+func asfTestMarkers(markers ...interface{}) []byte {
+	b := bytes.Join([][]byte{make([]byte, 16), asfTestUint32(uint32(len(markers) / 2)), make([]byte, 2), asfTestUint16(0)}, nil)
+	for i := 0; i+1 < len(markers); i += 2 {
+		d := asfTestString(markers[i+1].(string))
+		b = append(b, bytes.Join([][]byte{
+			asfTestUint64(0),
+			asfTestUint64(markers[i].(uint64)),
+			asfTestUint16(uint16(18 + len(d))),
+			asfTestUint32(0),
+			asfTestUint32(0),
+			asfTestUint32(uint32(len(d) / 2)),
+			d,
+		}, nil)...)
+	}
+	return b
+}
+
+func TestProcessASFFileProperties(t *testing.T) {
+	fp, err := processASFFileProperties(asfTestFileProperties(1823000000, 3000))
+	if err != nil {
+		t.Fatalf("processASFFileProperties() returned error: %v", err)
+	}
+	if fp["fileSize"] != int64(123456) || fp["dataPacketsCount"] != int64(42) || fp[MaximumBitrateKey] != int64(192000) {
+		t.Errorf("processASFFileProperties() = %v", fp)
+	}
+	if d, expected := fp.Duration(), 179300*time.Millisecond; d != expected {
+		t.Errorf("Duration() = %v, expected %v", d, expected)
+	}
+
+	//A preroll longer than the play duration
+	fp, _ = processASFFileProperties(asfTestFileProperties(10000, 3000))
+	if d := fp.Duration(); d != 0 {
+		t.Errorf("Duration() = %v, expected %v", d, 0)
+	}
+
+	_, err = processASFFileProperties(make([]byte, 79))
+	if err == nil {
+		t.Errorf("processASFFileProperties() expected error for short object")
+	}
+}
+
+func TestProcessASFStreamProperties(t *testing.T) {
+	tests := []struct {
+		input      []byte
+		expected   asfStreamProperties
+		codec      string
+		makesError bool
+	}{
+		{asfTestStreamProperties(asfAudioMediaGUID, 0x0161), asfStreamProperties{
+			"streamNumber":    1,
+			"codecID":         0x0161,
+			ChannelsKey:       2,
+			SampleRateKey:     44100,
+			AverageBitrateKey: 128000,
+			"blockAlign":      2973,
+			SampleSizeKey:     16,
+		}, "WMA v2", false},
+		{asfTestStreamProperties(asfHeaderObjectGUID, 0x0161), nil, "", false},
+		{asfTestStreamProperties(asfAudioMediaGUID, 0x0161)[:60], nil, "", true},
+		{make([]byte, 53), nil, "", true},
+	}
+
+	for ii, tt := range tests {
+		sp, err := processASFStreamProperties(tt.input)
+		if (err != nil) != tt.makesError {
+			t.Errorf("[%d] processASFStreamProperties() expected error: %v - Error was: %v", ii, tt.makesError, err)
+			continue
+		}
+		if !reflect.DeepEqual(sp, tt.expected) {
+			t.Errorf("[%d] processASFStreamProperties() = %v, expected %v", ii, sp, tt.expected)
+		}
+		if sp.Codec() != tt.codec {
+			t.Errorf("[%d] Codec() = %q, expected %q", ii, sp.Codec(), tt.codec)
+		}
+	}
+}
+
+func TestProcessASFContentDescription(t *testing.T) {
+	b := asfTestContentDescription("Title", "Author", "", "Déscription", "")
+	cd, err := processASFContentDescription(b)
+	if err != nil {
+		t.Fatalf("processASFContentDescription() returned error: %v", err)
+	}
+	expected := map[string]string{
+		"title":       "Title",
+		"author":      "Author",
+		"copyright":   "",
+		"description": "Déscription",
+		"rating":      "",
+	}
+	if !reflect.DeepEqual(cd, expected) {
+		t.Errorf("processASFContentDescription() = %v, expected %v", cd, expected)
+	}
+
+	_, err = processASFContentDescription(b[:len(b)-4])
+	if err == nil {
+		t.Errorf("processASFContentDescription() expected error for truncated strings")
+	}
+}
+
+func TestProcessExtendedContentDescription(t *testing.T) {
+	picture := bytes.Join([][]byte{
+		{0x03}, asfTestUint32(3), asfTestString("image/png"), asfTestString("Front"), []byte("png"),
+	}, nil)
+	b := bytes.Join([][]byte{
+		asfTestUint16(5),
+		asfTestDescriptor("WM/AlbumTitle", asfUnicodeType, asfTestString("Album")),
+		asfTestDescriptor("WM/TrackNumber", asfDWordType, asfTestUint32(7)),
+		asfTestDescriptor("IsVBR", asfBoolType, asfTestUint32(1)),
+		asfTestDescriptor("WM/EncodingTime", asfQWordType, asfTestUint64(1<<40)),
+		asfTestDescriptor("WM/Picture", asfByteArrayType, picture),
+	}, nil)
+	a := asfAttributes{}
+	err := a.processExtendedContentDescription(b)
+	if err != nil {
+		t.Fatalf("processExtendedContentDescription() returned error: %v", err)
+	}
+	expected := asfAttributes{
+		"WM/AlbumTitle":   "Album",
+		"WM/TrackNumber":  7,
+		"IsVBR":           true,
+		"WM/EncodingTime": int64(1 << 40),
+		"WM/Picture": &Picture{
+			Ext:         "png",
+			MIMEType:    "image/png",
+			Type:        pictureTypes[0x03],
+			Description: "Front",
+			Data:        []byte("png"),
+		},
+	}
+	if !reflect.DeepEqual(a, expected) {
+		t.Errorf("processExtendedContentDescription() = %v, expected %v", a, expected)
+	}
+
+	//Metadata Library attributes don't replace the ones already found
+	b = bytes.Join([][]byte{
+		asfTestUint16(2),
+		asfTestMetadataRecord("WM/AlbumTitle", asfUnicodeType, asfTestString("Other")),
+		asfTestMetadataRecord("WM/SharedUserRating", asfWordType, asfTestUint16(99)),
+	}, nil)
+	err = a.processMetadataLibrary(b)
+	if err != nil {
+		t.Fatalf("processMetadataLibrary() returned error: %v", err)
+	}
+	if a["WM/AlbumTitle"] != "Album" || a["WM/SharedUserRating"] != 99 {
+		t.Errorf("processMetadataLibrary() = %v", a)
+	}
+
+	errs := [][]byte{
+		{0x01},
+		bytes.Join([][]byte{asfTestUint16(1), asfTestDescriptor("X", 9, nil)}, nil),
+		bytes.Join([][]byte{asfTestUint16(1), asfTestDescriptor("X", asfDWordType, []byte{1})}, nil),
+		bytes.Join([][]byte{asfTestUint16(2), asfTestDescriptor("X", asfUnicodeType, nil)}, nil),
+	}
+	for ii, input := range errs {
+		err = asfAttributes{}.processExtendedContentDescription(input)
+		if err == nil {
+			t.Errorf("[%d] processExtendedContentDescription(%v) expected error", ii, input)
+		}
+	}
+}
+
+func asfTestFile() []byte {
+	extension := asfTestObject(asfHeaderExtensionGUID, make([]byte, 22), asfTestObject(asfMetadataLibraryGUID,
+		asfTestUint16(1), asfTestMetadataRecord("WM/Year", asfUnicodeType, asfTestString("1999")),
+	))
+	objects := bytes.Join([][]byte{
+		asfTestObject(asfFilePropertiesGUID, asfTestFileProperties(1823000000, 3000)),
+		asfTestObject(asfStreamPropertiesGUID, asfTestStreamProperties(asfAudioMediaGUID, 0x0162)),
+		asfTestObject(asfContentDescriptionGUID, asfTestContentDescription("Title", "Artist", "", "", "")),
+		asfTestObject(asfExtendedContentDescGUID, asfTestUint16(2),
+			asfTestDescriptor("WM/AlbumTitle", asfUnicodeType, asfTestString("Album")),
+			asfTestDescriptor("WM/TrackNumber", asfUnicodeType, asfTestString("3/10")),
+		),
+		extension,
+		//Marker times include the 3 second preroll
+		asfTestObject(asfMarkerGUID, asfTestMarkers(uint64(30000000), "Intro", uint64(630000000), "Verse")),
+	}, nil)
+	header := asfTestObject(asfHeaderObjectGUID, asfTestUint32(6), []byte{1, 2}, objects)
+	//The data object follows, it isn't read
+	return append(header, make([]byte, 50)...)
+}
+
+func TestReadASF(t *testing.T) {
+	m, err := ReadASF(bytes.NewReader(asfTestFile()))
+	if err != nil {
+		t.Fatalf("ReadASF() returned error: %v", err)
+	}
+	if m.FileType() != WMA || m.Format() != ASF {
+		t.Errorf("FileType(), Format() = %v, %v, expected %v, %v", m.FileType(), m.Format(), WMA, ASF)
+	}
+	if m.Title() != "Title" || m.Artist() != "Artist" || m.Album() != "Album" {
+		t.Errorf("Title(), Artist(), Album() = %q, %q, %q, expected %q, %q, %q", m.Title(), m.Artist(), m.Album(), "Title", "Artist", "Album")
+	}
+	if x, n := m.Track(); x != 3 || n != 10 {
+		t.Errorf("Track() = %v, %v, expected %v, %v", x, n, 3, 10)
+	}
+	if m.Year() != 1999 {
+		t.Errorf("Year() = %v, expected %v", m.Year(), 1999)
+	}
+	if m.Codec() != "WMA Pro" || m.AverageBitrate() != 128000 {
+		t.Errorf("Codec(), AverageBitrate() = %q, %v, expected %q, %v", m.Codec(), m.AverageBitrate(), "WMA Pro", 128000)
+	}
+	if d, expected := m.Duration(), 179300*time.Millisecond; d != expected {
+		t.Errorf("Duration() = %v, expected %v", d, expected)
+	}
+	chapters := []Chapter{
+		{Index: 1, Title: "Intro", Start: 0, End: time.Minute},
+		{Index: 2, Title: "Verse", Start: time.Minute, End: 179300 * time.Millisecond},
+	}
+	if !reflect.DeepEqual(m.Chapters(), chapters) {
+		t.Errorf("Chapters() = %v, expected %v", m.Chapters(), chapters)
+	}
+}
+
+func TestProcessASFMarkers(t *testing.T) {
+	b := asfTestMarkers(uint64(0), "Intro", uint64(600000000), "Verse")
+	tests := []struct {
+		input      []byte
+		expected   []asfMarker
+		makesError bool
+	}{
+		{b, []asfMarker{{0, "Intro"}, {600000000, "Verse"}}, false},
+		{asfTestMarkers(), nil, false},
+		{b[:len(b)-2], nil, true},
+		{b[:30], nil, true},
+		{b[:20], nil, true},
+	}
+
+	for ii, tt := range tests {
+		markers, err := processASFMarkers(tt.input)
+		if (err != nil) != tt.makesError {
+			t.Errorf("[%d] processASFMarkers(%v) expected error: %v - Error was: %v", ii, tt.input, tt.makesError, err)
+			continue
+		}
+		if !reflect.DeepEqual(markers, tt.expected) {
+			t.Errorf("[%d] processASFMarkers(%v) = %v, expected %v", ii, tt.input, markers, tt.expected)
+		}
+	}
+}
+
+func TestReadASFInvalidHeader(t *testing.T) {
+	b := asfTestFile()
+	tests := [][]byte{
+		b[:20],
+		append(make([]byte, 16), b[16:]...),
+		//Header object sizes that are too small or larger than the file
+		append(append(b[:16:16], asfTestUint64(20)...), b[24:]...),
+		append(append(b[:16:16], asfTestUint64(1<<62)...), b[24:]...),
+		append(append(b[:16:16], asfTestUint64(uint64(len(b)+1))...), b[24:]...),
+	}
+
+	for ii, input := range tests {
+		_, err := ReadASF(bytes.NewReader(input))
+		if err == nil {
+			t.Errorf("[%d] ReadASF() expected error", ii)
+		}
+	}
+}
@@ -0,0 +1,507 @@
+package yurit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//cafDescription holds the information from the audio description chunk
+//('desc') of a CAF file.
+type cafDescription map[string]interface{}
+
+//cafPacketTable holds the header of the packet table chunk ('pakt') of a CAF
+//file. The packet sizes that follow the header are not kept.
+type cafPacketTable map[string]interface{}
+
+//cafInfo holds the key/value strings from the information chunk ('info') of a
+//CAF file. Keys are stored in lowercase.
+type cafInfo map[string]string
+
+//cafMarker is a marker from the marker chunk ('mark') or a region chunk
+//('regn') of a CAF file. The SMPTE time and channel are not kept.
+type cafMarker struct {
+	markerType    string
+	framePosition float64
+	id            int
+}
+
+//cafRegion is a region from the region chunk ('regn') of a CAF file.
+type cafRegion struct {
+	id      int
+	markers []cafMarker
+}
+
+//CAFMetadata is a collection of metadata from a Core Audio Format (.caf) file.
+//https://developer.apple.com/library/archive/documentation/MusicAudio/Reference/CAFSpec/CAF_spec/CAF_spec.html
+type CAFMetadata struct {
+	description cafDescription
+	packetTable cafPacketTable
+	info        cafInfo
+	markers     []cafMarker
+	regions     []cafRegion
+	//strs holds the strings chunk ('strg'), which names markers and regions
+	strs map[int]string
+	//dataSize is the size of the audio data in the data chunk, not including
+	//the edit count
+	dataSize int64
+}
+
+const (
+	cafFileHeaderLength  = 8
+	cafChunkHeaderLength = 12
+	cafMarkerLength      = 28
+)
+
+// ReadCAF reads the chunks from a Core Audio Format file, returning the
+// resulting metadata in a Metadata implementation, or non-nil error if there
+// was a problem.
+func ReadCAF(r io.ReadSeeker) (*CAFMetadata, error) {
+	b, err := readBytes(r, cafFileHeaderLength)
+	if err != nil {
+		return nil, err
+	}
+	if string(b[0:4]) != "caff" {
+		return nil, errors.New("expected 'caff'")
+	}
+	if v := getUint16AsInt(b[4:6]); v != 1 {
+		return nil, fmt.Errorf("unsupported CAF version: %d", v)
+	}
+	//Chunk sizes are checked against the end of the file before reading chunks
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.Seek(start, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	m := CAFMetadata{}
+	for {
+		b, err := readBytes(r, cafChunkHeaderLength)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		chunkType := string(b[0:4])
+		size := getInt64(b[4:12])
+		pos, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		if chunkType == "data" && size == -1 {
+			//Only the data chunk may have a size of -1, which means that it runs to
+			//the end of the file, so it must be the last chunk
+			m.setDataSize(end - pos)
+			break
+		}
+		if size < 0 {
+			return nil, fmt.Errorf("invalid CAF chunk size: %d", size)
+		}
+		if chunkType == "data" {
+			m.setDataSize(size)
+		} else if size > end-pos {
+			return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", size, end-pos)
+		}
+		var read int64
+		switch chunkType {
+		case "desc":
+			m.description, err = readCAFDescription(r, size)
+			read = size
+		case "pakt":
+			m.packetTable, err = readCAFPacketTable(r, size)
+			read = 24
+		case "info":
+			m.info, err = readCAFInfo(r, size)
+			read = size
+		case "mark":
+			m.markers, err = readCAFMarkerChunk(r, size)
+			read = size
+		case "regn":
+			m.regions, err = readCAFRegionChunk(r, size)
+			read = size
+		case "strg":
+			m.strs, err = readCAFStrings(r, size)
+			read = size
+		}
+		if err != nil {
+			return nil, err
+		}
+		_, err = r.Seek(size-read, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if m.description == nil {
+		return nil, errors.New("missing CAF audio description chunk")
+	}
+	return &m, nil
+}
+
+//setDataSize sets the size of the audio data from the size of the data chunk,
+//in which the audio data is preceded by a 4 byte edit count.
+func (m *CAFMetadata) setDataSize(chunkSize int64) {
+	if chunkSize >= 4 {
+		m.dataSize = chunkSize - 4
+	}
+}
+
+//readCAFDescription reads the audio description chunk.
+//Bytes: 8 sample rate (float64), 4 format ID, 4 format flags, 4 bytes per
+//packet, 4 frames per packet, 4 channels per frame, 4 bits per channel
+func readCAFDescription(r io.Reader, size int64) (cafDescription, error) {
+	if size < 32 {
+		return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", 32, size)
+	}
+	b, err := readBytes(r, uint(size))
+	if err != nil {
+		return nil, err
+	}
+	d := cafDescription{}
+	d[SampleRateKey] = getFloat64(b[0:8])
+	d[FormatKey] = string(b[8:12])
+	d[FlagsKey] = getUint32AsInt64(b[12:16])
+	d[BytesPerPacketKey] = getUint32AsInt64(b[16:20])
+	d["framesPerPacket"] = getUint32AsInt64(b[20:24])
+	d[ChannelsKey] = int(getUint32AsInt64(b[24:28]))
+	d["bitsPerChannel"] = int(getUint32AsInt64(b[28:32]))
+	return d, nil
+}
+
+//readCAFPacketTable reads the header of the packet table chunk. Only the 24
+//byte header is read from r.
+//Bytes: 8 number of packets, 8 number of valid frames, 4 priming frames,
+//4 remainder frames
+func readCAFPacketTable(r io.Reader, size int64) (cafPacketTable, error) {
+	if size < 24 {
+		return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", 24, size)
+	}
+	b, err := readBytes(r, 24)
+	if err != nil {
+		return nil, err
+	}
+	p := cafPacketTable{}
+	p["numberPackets"] = getInt64(b[0:8])
+	p["numberValidFrames"] = getInt64(b[8:16])
+	p["primingFrames"] = getInt32AsInt(b[16:20])
+	p["remainderFrames"] = getInt32AsInt(b[20:24])
+	return p, nil
+}
+
+//readCAFInfo reads the information chunk, which is a 4 byte number of entries
+//followed by pairs of NUL terminated UTF-8 key and value strings.
+func readCAFInfo(r io.Reader, size int64) (cafInfo, error) {
+	if size < 4 {
+		return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", 4, size)
+	}
+	b, err := readBytes(r, uint(size))
+	if err != nil {
+		return nil, err
+	}
+	numEntries := getUint32AsInt64(b[0:4])
+	strs := strings.Split(string(b[4:]), "\x00")
+	info := cafInfo{}
+	for i := int64(0); i < numEntries && int(2*i+1) < len(strs); i++ {
+		info[strings.ToLower(strs[2*i])] = strs[2*i+1]
+	}
+	return info, nil
+}
+
+//readCAFMarkerChunk reads the marker chunk, which is a 4 byte SMPTE time type
+//and a 4 byte number of markers followed by the markers.
+func readCAFMarkerChunk(r io.Reader, size int64) ([]cafMarker, error) {
+	if size < 8 {
+		return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", 8, size)
+	}
+	b, err := readBytes(r, uint(size))
+	if err != nil {
+		return nil, err
+	}
+	markers, _, err := processCAFMarkers(b[8:], getUint32AsInt64(b[4:8]))
+	return markers, err
+}
+
+//readCAFRegionChunk reads the region chunk, which is a 4 byte SMPTE time type
+//and a 4 byte number of regions followed by the regions. Each region is a 4
+//byte ID, 4 bytes of flags and a 4 byte number of markers followed by the
+//markers.
+func readCAFRegionChunk(r io.Reader, size int64) ([]cafRegion, error) {
+	if size < 8 {
+		return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", 8, size)
+	}
+	b, err := readBytes(r, uint(size))
+	if err != nil {
+		return nil, err
+	}
+	count := getUint32AsInt64(b[4:8])
+	b = b[8:]
+	var regions []cafRegion
+	for i := int64(0); i < count; i++ {
+		if len(b) < 12 {
+			return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", 12, len(b))
+		}
+		region := cafRegion{id: int(getUint32AsInt64(b[0:4]))}
+		var n int
+		region.markers, n, err = processCAFMarkers(b[12:], getUint32AsInt64(b[8:12]))
+		if err != nil {
+			return nil, err
+		}
+		regions = append(regions, region)
+		b = b[12+n:]
+	}
+	return regions, nil
+}
+
+//processCAFMarkers reads count markers from the start of b, returning them
+//along with the number of bytes read.
+//Bytes: 4 marker type, 8 frame position (float64), 4 marker ID, 8 SMPTE time,
+//4 channel
+func processCAFMarkers(b []byte, count int64) ([]cafMarker, int, error) {
+	if int64(len(b)/cafMarkerLength) < count {
+		return nil, 0, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", count*cafMarkerLength, len(b))
+	}
+	markers := make([]cafMarker, count)
+	for i := range markers {
+		x := b[i*cafMarkerLength:]
+		markers[i] = cafMarker{
+			markerType:    string(x[0:4]),
+			framePosition: getFloat64(x[4:12]),
+			id:            getInt32AsInt(x[12:16]),
+		}
+	}
+	return markers, len(markers) * cafMarkerLength, nil
+}
+
+//readCAFStrings reads the strings chunk, which is a 4 byte number of entries
+//followed by an ID and a byte offset for each entry and then the NUL terminated
+//UTF-8 strings that the offsets point into.
+//Bytes: 4 string ID, 8 offset
+func readCAFStrings(r io.Reader, size int64) (map[int]string, error) {
+	if size < 4 {
+		return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", 4, size)
+	}
+	b, err := readBytes(r, uint(size))
+	if err != nil {
+		return nil, err
+	}
+	count := getUint32AsInt64(b[0:4])
+	if int64((len(b)-4)/12) < count {
+		return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", 4+count*12, len(b))
+	}
+	data := b[4+count*12:]
+	strs := map[int]string{}
+	for i := int64(0); i < count; i++ {
+		x := b[4+i*12:]
+		offset := getInt64(x[4:12])
+		if offset < 0 || offset >= int64(len(data)) {
+			continue
+		}
+		s := data[offset:]
+		if end := bytes.IndexByte(s, 0); end >= 0 {
+			s = s[:end]
+		}
+		strs[int(getUint32AsInt64(x[0:4]))] = string(s)
+	}
+	return strs, nil
+}
+
+func (m CAFMetadata) Album() string {
+	return m.info["album"]
+}
+
+func (m CAFMetadata) AlbumArtist() string {
+	//No album artist key is defined for CAF
+	return ""
+}
+
+func (m CAFMetadata) Artist() string {
+	return m.info["artist"]
+}
+
+//AverageBitrate returns the average bitrate of the audio data in bits per
+//second.
+func (m CAFMetadata) AverageBitrate() int {
+	durationInSeconds := m.Duration().Seconds()
+	if durationInSeconds == 0 {
+		return 0
+	}
+	return int(float64(m.dataSize*8) / durationInSeconds)
+}
+
+func (m CAFMetadata) Channels() int {
+	c, _ := m.description[ChannelsKey].(int)
+	return c
+}
+
+//Chapters returns the regions of the region chunk as chapters, running from
+//their start marker to their end marker. If there are no regions then the
+//markers of the marker chunk are returned as chapters instead. Chapters are
+//named from the strings chunk.
+func (m CAFMetadata) Chapters() []Chapter {
+	sr, _ := m.description[SampleRateKey].(float64)
+	if sr <= 0 {
+		return nil
+	}
+	toDuration := func(framePosition float64) time.Duration {
+		return time.Duration(framePosition / sr * float64(time.Second))
+	}
+	var chapters []Chapter
+	if len(m.regions) > 0 {
+		for _, region := range m.regions {
+			if len(region.markers) == 0 {
+				continue
+			}
+			c := Chapter{Title: m.strs[region.id], Start: toDuration(region.markers[0].framePosition)}
+			for _, marker := range region.markers {
+				switch marker.markerType {
+				case "rbeg":
+					c.Start = toDuration(marker.framePosition)
+				case "rend":
+					c.End = toDuration(marker.framePosition)
+				}
+			}
+			chapters = append(chapters, c)
+		}
+	} else {
+		for _, marker := range m.markers {
+			chapters = append(chapters, Chapter{Title: m.strs[marker.id], Start: toDuration(marker.framePosition)})
+		}
+	}
+	if len(chapters) == 0 {
+		return nil
+	}
+	sort.SliceStable(chapters, func(i, j int) bool {
+		return chapters[i].Start < chapters[j].Start
+	})
+	return completeChapters(chapters, m.Duration())
+}
+
+//Codec returns the format ID from the audio description, e.g. lpcm, aac or
+//alac.
+func (m CAFMetadata) Codec() string {
+	f, _ := m.description[FormatKey].(string)
+	return strings.TrimSpace(f)
+}
+
+func (m CAFMetadata) Comment() string {
+	return m.info["comments"]
+}
+
+func (m CAFMetadata) Composer() string {
+	return m.info["composer"]
+}
+
+//Description returns the information from the audio description chunk.
+func (m CAFMetadata) Description() map[string]interface{} {
+	return m.description
+}
+
+func (m CAFMetadata) Disc() (int, int) {
+	//No disc key is defined for CAF
+	return 0, 0
+}
+
+//Duration returns the exact length of the audio based on the number of valid
+//frames in the packet table, or the size of the audio data if the packets are
+//all the same size and there is no packet table.
+func (m CAFMetadata) Duration() time.Duration {
+	sr, _ := m.description[SampleRateKey].(float64)
+	if sr <= 0 {
+		return time.Duration(0)
+	}
+	frames := m.TotalFrames()
+	return time.Duration(float64(frames) / sr * float64(time.Second))
+}
+
+func (m CAFMetadata) FileType() FileType {
+	return CAF
+}
+
+func (m CAFMetadata) Format() Format {
+	if m.info != nil {
+		return CAFInfo
+	}
+	return UnknownFormat
+}
+
+func (m CAFMetadata) Genre() string {
+	return m.info["genre"]
+}
+
+//Info returns the key/value strings from the information chunk.
+func (m CAFMetadata) Info() map[string]string {
+	return m.info
+}
+
+func (m CAFMetadata) Lyrics() string {
+	//No lyrics key is defined for CAF
+	return ""
+}
+
+//PacketTable returns the header of the packet table chunk, which holds the
+//number of valid frames and the priming and remainder frames.
+func (m CAFMetadata) PacketTable() map[string]interface{} {
+	return m.packetTable
+}
+
+func (m CAFMetadata) Picture() *Picture {
+	return nil
+}
+
+func (m CAFMetadata) Raw() map[string]interface{} {
+	raw := map[string]interface{}{}
+	for k, v := range m.info {
+		raw[k] = v
+	}
+	return raw
+}
+
+//SampleRate returns the sample rate of the audio. CAF stores the sample rate as
+//a float64, which is kept as it is in Description.
+func (m CAFMetadata) SampleRate() int {
+	sr, _ := m.description[SampleRateKey].(float64)
+	return int(sr)
+}
+
+func (m CAFMetadata) Title() string {
+	return m.info["title"]
+}
+
+//TotalFrames returns the number of sample frames in the audio data.
+func (m CAFMetadata) TotalFrames() int64 {
+	if n, ok := m.packetTable["numberValidFrames"].(int64); ok {
+		return n
+	}
+	bytesPerPacket, _ := m.description[BytesPerPacketKey].(int64)
+	framesPerPacket, _ := m.description["framesPerPacket"].(int64)
+	if bytesPerPacket > 0 && framesPerPacket > 0 {
+		return m.dataSize / bytesPerPacket * framesPerPacket
+	}
+	return 0
+}
+
+func (m CAFMetadata) Track() (int, int) {
+	return parseXofN(m.info["track number"])
+}
+
+func (m CAFMetadata) Year() int {
+	year, _ := strconv.Atoi(m.info["year"])
+	if year == 0 {
+		//Recorded date is a full date, e.g. 2006-01-02
+		s := m.info["recorded date"]
+		if len(s) >= 4 {
+			year, _ = strconv.Atoi(s[:4])
+		}
+	}
+	return year
+}
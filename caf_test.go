@@ -0,0 +1,229 @@
+package yurit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func cafTestChunk(chunkType string, size int64, data []byte) []byte {
+	b := make([]byte, cafChunkHeaderLength)
+	copy(b, chunkType)
+	binary.BigEndian.PutUint64(b[4:12], uint64(size))
+	return append(b, data...)
+}
+
+func cafTestDescription(format string, bytesPerPacket, framesPerPacket uint32) []byte {
+	b := make([]byte, 32)
+	binary.BigEndian.PutUint64(b[0:8], math.Float64bits(44100))
+	copy(b[8:12], format)
+	binary.BigEndian.PutUint32(b[16:20], bytesPerPacket)
+	binary.BigEndian.PutUint32(b[20:24], framesPerPacket)
+	binary.BigEndian.PutUint32(b[24:28], 2)
+	binary.BigEndian.PutUint32(b[28:32], 16)
+	return cafTestChunk("desc", int64(len(b)), b)
+}
+
+func cafTestPacketTable(packets, validFrames int64, priming, remainder int32) []byte {
+	b := make([]byte, 24)
+	binary.BigEndian.PutUint64(b[0:8], uint64(packets))
+	binary.BigEndian.PutUint64(b[8:16], uint64(validFrames))
+	binary.BigEndian.PutUint32(b[16:20], uint32(priming))
+	binary.BigEndian.PutUint32(b[20:24], uint32(remainder))
+	//Packet sizes follow the header
+	b = append(b, bytes.Repeat([]byte{0x10}, int(packets))...)
+	return cafTestChunk("pakt", int64(len(b)), b)
+}
+
+func cafTestInfo(pairs ...string) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(len(pairs)/2))
+	for _, s := range pairs {
+		b = append(append(b, s...), 0)
+	}
+	return cafTestChunk("info", int64(len(b)), b)
+}
+
+func cafTestMarker(markerType string, framePosition float64, id int32) []byte {
+	b := make([]byte, cafMarkerLength)
+	copy(b, markerType)
+	binary.BigEndian.PutUint64(b[4:12], math.Float64bits(framePosition))
+	binary.BigEndian.PutUint32(b[12:16], uint32(id))
+	return b
+}
+
+func cafTestMarkers(markers ...[]byte) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint32(b[4:8], uint32(len(markers)))
+	b = append(b, bytes.Join(markers, nil)...)
+	return cafTestChunk("mark", int64(len(b)), b)
+}
+
+func cafTestRegion(id uint32, markers ...[]byte) []byte {
+	b := make([]byte, 12)
+	binary.BigEndian.PutUint32(b[0:4], id)
+	binary.BigEndian.PutUint32(b[8:12], uint32(len(markers)))
+	return append(b, bytes.Join(markers, nil)...)
+}
+
+func cafTestRegions(regions ...[]byte) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint32(b[4:8], uint32(len(regions)))
+	b = append(b, bytes.Join(regions, nil)...)
+	return cafTestChunk("regn", int64(len(b)), b)
+}
+
+//cafTestStrings returns a strings chunk with an entry for each pair of ID and
+//string.
+func cafTestStrings(entries ...interface{}) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(len(entries)/2))
+	var data []byte
+	for i := 0; i+1 < len(entries); i += 2 {
+		e := make([]byte, 12)
+		binary.BigEndian.PutUint32(e[0:4], uint32(entries[i].(int)))
+		binary.BigEndian.PutUint64(e[4:12], uint64(len(data)))
+		b = append(b, e...)
+		data = append(append(data, entries[i+1].(string)...), 0)
+	}
+	b = append(b, data...)
+	return cafTestChunk("strg", int64(len(b)), b)
+}
+
+func TestReadCAF(t *testing.T) {
+	header := []byte{'c', 'a', 'f', 'f', 0, 1, 0, 0}
+	lpcm := cafTestDescription("lpcm", 4, 1)
+	aac := cafTestDescription("aac ", 0, 1024)
+	info := cafTestInfo("title", "Memo", "Artist", "Someone", "recorded date", "2019-03-04 10:11:12")
+	//0.1 seconds of 16 bit stereo audio, after the edit count
+	audio := make([]byte, 4+4410*4)
+	free := cafTestChunk("free", 6, make([]byte, 6))
+
+	tests := []struct {
+		input    []byte
+		codec    string
+		frames   int64
+		duration time.Duration
+		bitrate  int
+		title    string
+	}{
+		{bytes.Join([][]byte{header, lpcm, info, cafTestChunk("data", int64(len(audio)), audio), free}, nil), "lpcm", 4410, 100 * time.Millisecond, 1411200, "Memo"},
+		//A data chunk of unknown size runs to the end of the file
+		{bytes.Join([][]byte{header, lpcm, cafTestChunk("data", -1, audio)}, nil), "lpcm", 4410, 100 * time.Millisecond, 1411200, ""},
+		{bytes.Join([][]byte{header, aac, cafTestPacketTable(5, 4410, 2112, 598), cafTestChunk("data", -1, make([]byte, 4+80))}, nil), "aac", 4410, 100 * time.Millisecond, 6400, ""},
+	}
+
+	for ii, tt := range tests {
+		m, err := ReadCAF(bytes.NewReader(tt.input))
+		if err != nil {
+			t.Errorf("[%d] ReadCAF() returned error: %v", ii, err)
+			continue
+		}
+		if m.Codec() != tt.codec || m.TotalFrames() != tt.frames {
+			t.Errorf("[%d] Codec(), TotalFrames() = %q, %v, expected %q, %v", ii, m.Codec(), m.TotalFrames(), tt.codec, tt.frames)
+		}
+		if m.SampleRate() != 44100 || m.Channels() != 2 {
+			t.Errorf("[%d] SampleRate(), Channels() = %v, %v, expected %v, %v", ii, m.SampleRate(), m.Channels(), 44100, 2)
+		}
+		if m.Duration() != tt.duration || m.AverageBitrate() != tt.bitrate {
+			t.Errorf("[%d] Duration(), AverageBitrate() = %v, %v, expected %v, %v", ii, m.Duration(), m.AverageBitrate(), tt.duration, tt.bitrate)
+		}
+		if m.Title() != tt.title {
+			t.Errorf("[%d] Title() = %q, expected %q", ii, m.Title(), tt.title)
+		}
+	}
+
+	m, err := ReadCAF(bytes.NewReader(tests[0].input))
+	if err != nil {
+		t.Fatalf("ReadCAF() returned error: %v", err)
+	}
+	if m.Artist() != "Someone" || m.Year() != 2019 || m.Format() != CAFInfo {
+		t.Errorf("Artist(), Year(), Format() = %q, %v, %v, expected %q, %v, %v", m.Artist(), m.Year(), m.Format(), "Someone", 2019, CAFInfo)
+	}
+	m, err = ReadCAF(bytes.NewReader(tests[2].input))
+	if err != nil {
+		t.Fatalf("ReadCAF() returned error: %v", err)
+	}
+	if p := m.PacketTable(); p["primingFrames"] != 2112 || p["remainderFrames"] != 598 {
+		t.Errorf("PacketTable() = %v, expected %v priming and %v remainder frames", p, 2112, 598)
+	}
+}
+
+func TestReadCAFErrors(t *testing.T) {
+	header := []byte{'c', 'a', 'f', 'f', 0, 1, 0, 0}
+	lpcm := cafTestDescription("lpcm", 4, 1)
+	tests := [][]byte{
+		[]byte{'c', 'a', 'f', 'f'},
+		append([]byte{'R', 'I', 'F', 'F', 0, 1, 0, 0}, lpcm...),
+		append([]byte{'c', 'a', 'f', 'f', 0, 2, 0, 0}, lpcm...),
+		//No audio description
+		bytes.Join([][]byte{header, cafTestChunk("data", 8, make([]byte, 8))}, nil),
+		//Only the data chunk may have a negative size
+		bytes.Join([][]byte{header, lpcm, cafTestChunk("free", -1, nil)}, nil),
+		bytes.Join([][]byte{header, cafTestChunk("desc", 16, make([]byte, 16))}, nil),
+		//Chunk sizes larger than the file
+		[]byte("caff\x00\x01\x00\x00desc\x7f\xff\xff\xff\xff\xff\xff\xff"),
+		bytes.Join([][]byte{header, lpcm, cafTestChunk("info", 1<<40, []byte{0, 0, 0, 1})}, nil),
+		bytes.Join([][]byte{header, lpcm, cafTestChunk("strg", 1<<40, []byte{0, 0, 0, 1})}, nil),
+	}
+
+	for ii, input := range tests {
+		_, err := ReadCAF(bytes.NewReader(input))
+		if err == nil {
+			t.Errorf("[%d] ReadCAF(%v) expected error", ii, input)
+		}
+	}
+}
+
+func TestCAFChapters(t *testing.T) {
+	header := []byte{'c', 'a', 'f', 'f', 0, 1, 0, 0}
+	lpcm := cafTestDescription("lpcm", 4, 1)
+	//1 second of audio
+	data := cafTestChunk("data", 4+44100*4, make([]byte, 4+44100*4))
+	strs := cafTestStrings(1, "One", 2, "Two", 3, "Three")
+
+	tests := []struct {
+		input    []byte
+		expected []Chapter
+	}{
+		{bytes.Join([][]byte{header, lpcm, data}, nil), nil},
+		//Markers are sorted by position
+		{bytes.Join([][]byte{header, lpcm, strs, cafTestMarkers(
+			cafTestMarker("\x00\x00\x00\x00", 22050, 2),
+			cafTestMarker("\x00\x00\x00\x00", 0, 1),
+		), data}, nil), []Chapter{
+			{Index: 1, Title: "One", Start: 0, End: 500 * time.Millisecond},
+			{Index: 2, Title: "Two", Start: 500 * time.Millisecond, End: time.Second},
+		}},
+		//Regions take precedence over markers
+		{bytes.Join([][]byte{header, lpcm, data, cafTestMarkers(cafTestMarker("\x00\x00\x00\x00", 0, 1)), cafTestRegions(
+			cafTestRegion(2, cafTestMarker("rbeg", 4410, 0), cafTestMarker("rend", 22050, 0)),
+			cafTestRegion(3, cafTestMarker("rbeg", 33075, 0)),
+		), strs}, nil), []Chapter{
+			{Index: 1, Title: "Two", Start: 100 * time.Millisecond, End: 500 * time.Millisecond},
+			{Index: 2, Title: "Three", Start: 750 * time.Millisecond, End: time.Second},
+		}},
+	}
+
+	for ii, tt := range tests {
+		m, err := ReadCAF(bytes.NewReader(tt.input))
+		if err != nil {
+			t.Errorf("[%d] ReadCAF() returned error: %v", ii, err)
+			continue
+		}
+		if !reflect.DeepEqual(m.Chapters(), tt.expected) {
+			t.Errorf("[%d] Chapters() = %v, expected %v", ii, m.Chapters(), tt.expected)
+		}
+	}
+
+	//A marker count larger than the chunk
+	b := cafTestMarkers(cafTestMarker("rbeg", 0, 1))
+	binary.BigEndian.PutUint32(b[cafChunkHeaderLength+4:], 2)
+	_, err := ReadCAF(bytes.NewReader(bytes.Join([][]byte{header, lpcm, b}, nil)))
+	if err == nil {
+		t.Errorf("ReadCAF() expected error for marker count larger than the chunk")
+	}
+}
@@ -0,0 +1,69 @@
+package yurit
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+//completeChapters numbers the chapters from 1 in the order given and sets the
+//end of any chapter without one to the start of the next chapter, or to the end
+//of the audio for the last chapter.
+func completeChapters(chapters []Chapter, duration time.Duration) []Chapter {
+	for i := range chapters {
+		chapters[i].Index = i + 1
+		if chapters[i].End != 0 {
+			continue
+		}
+		if i+1 < len(chapters) {
+			chapters[i].End = chapters[i+1].Start
+		} else if duration > chapters[i].Start {
+			chapters[i].End = duration
+		}
+	}
+	return chapters
+}
+
+//parseChapterTime parses a chapter time in the form HH:MM:SS.sss, as used by
+//Vorbis comment chapters and cue sheets. The hours and the fraction of a second
+//may be left out. If the time can't be parsed then false is returned.
+func parseChapterTime(s string) (time.Duration, bool) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, false
+	}
+	var d time.Duration
+	for i, p := range parts {
+		unit := time.Minute
+		if len(parts) == 3 && i == 0 {
+			unit = time.Hour
+		}
+		if i == len(parts)-1 {
+			seconds, err := strconv.ParseFloat(p, 64)
+			if err != nil || seconds < 0 {
+				return 0, false
+			}
+			d += time.Duration(seconds * float64(time.Second))
+			break
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		d += time.Duration(n) * unit
+	}
+	return d, true
+}
+
+//chaptersFromID3v2 converts the chapters from the CHAP frames of an ID3v2 tag
+//into Chapters, in the order given by id3v2Tags.Chapters.
+func chaptersFromID3v2(t *id3v2Tags, duration time.Duration) []Chapter {
+	if t == nil {
+		return nil
+	}
+	var chapters []Chapter
+	for _, c := range t.Chapters() {
+		chapters = append(chapters, Chapter{Title: c.Title(), Start: c.StartTime, End: c.EndTime})
+	}
+	return completeChapters(chapters, duration)
+}
@@ -0,0 +1,52 @@
+package yurit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseChapterTime(t *testing.T) {
+	tests := []struct {
+		input string
+		d     time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"12", 0, false},
+		{"00:00:00.000", 0, true},
+		{"01:02:03.500", time.Hour + 2*time.Minute + 3500*time.Millisecond, true},
+		{"02:03", 2*time.Minute + 3*time.Second, true},
+		{"1:2:3:4", 0, false},
+		{"00:xx:00", 0, false},
+	}
+
+	for ii, tt := range tests {
+		d, ok := parseChapterTime(tt.input)
+		if d != tt.d || ok != tt.ok {
+			t.Errorf("[%d] parseChapterTime(%q) = %v, %v, expected %v, %v", ii, tt.input, d, ok, tt.d, tt.ok)
+		}
+	}
+}
+
+func TestVorbisCommentChapters(t *testing.T) {
+	vc := vorbisComment{
+		"title":          "Book",
+		"chapter002":     "00:10:00.000",
+		"chapter002name": "Second",
+		"chapter001":     "00:00:00.000",
+		"chapter001name": "First",
+		"chapter003":     "invalid",
+		//The same chapter as chapter002, in another spelling
+		"chapter2":     "00:10:00.000",
+		"chapter2name": "Second",
+	}
+	chapters := completeChapters(vc.Chapters(), 15*time.Minute)
+	expected := []Chapter{
+		{Index: 1, Title: "First", Start: 0, End: 10 * time.Minute},
+		{Index: 2, Title: "Second", Start: 10 * time.Minute, End: 15 * time.Minute},
+	}
+	if !reflect.DeepEqual(chapters, expected) {
+		t.Errorf("Chapters() = %v, expected %v", chapters, expected)
+	}
+}
@@ -0,0 +1,344 @@
+//Package cue parses cue sheets and splits the audio they describe into
+//individual tracks, each with its own yurit.Metadata.
+//https://wiki.hydrogenaud.io/index.php?title=Cue_sheet
+package cue
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+//FramesPerSecond is the number of CD frames per second, the unit of the mm:ss:ff
+//times used in cue sheets.
+const FramesPerSecond = 75
+
+//Sheet is a parsed cue sheet. Rem holds the REM comments of the sheet, such as
+//GENRE, DATE, DISCID and COMMENT, keyed by the upper case name of the comment.
+type Sheet struct {
+	Catalog    string
+	Title      string
+	Performer  string
+	Songwriter string
+	Rem        map[string]string
+	Files      []File
+}
+
+//File is a FILE command of a cue sheet and the tracks within the file.
+type File struct {
+	Name   string
+	Type   string
+	Tracks []Track
+}
+
+//Track is a TRACK command of a cue sheet. Rem holds the REM comments given
+//within the track, keyed by the upper case name of the comment.
+type Track struct {
+	Number     int
+	DataType   string
+	Title      string
+	Performer  string
+	Songwriter string
+	ISRC       string
+	Flags      []string
+	Pregap     time.Duration
+	Postgap    time.Duration
+	Rem        map[string]string
+	Indices    []Index
+}
+
+//Index is an INDEX command of a cue sheet. Frames is the position of the index
+//point from the beginning of its file in CD frames.
+type Index struct {
+	Number int
+	Frames int64
+}
+
+//Time returns the position of the index point from the beginning of its file.
+func (i Index) Time() time.Duration {
+	return framesToDuration(i.Frames)
+}
+
+//Start returns the position of the track from the beginning of its file, which
+//is its INDEX 01, or the first index point if there is no INDEX 01.
+func (t Track) Start() time.Duration {
+	for _, i := range t.Indices {
+		if i.Number == 1 {
+			return i.Time()
+		}
+	}
+	if len(t.Indices) > 0 {
+		return t.Indices[0].Time()
+	}
+	return time.Duration(0)
+}
+
+//Genre returns the REM GENRE comment of the cue sheet.
+func (s Sheet) Genre() string {
+	return s.Rem["GENRE"]
+}
+
+//Date returns the REM DATE comment of the cue sheet.
+func (s Sheet) Date() string {
+	return s.Rem["DATE"]
+}
+
+//DiscID returns the REM DISCID comment of the cue sheet, which is the freedb
+//disc ID.
+func (s Sheet) DiscID() string {
+	return s.Rem["DISCID"]
+}
+
+//Comment returns the REM COMMENT comment of the cue sheet.
+func (s Sheet) Comment() string {
+	return s.Rem["COMMENT"]
+}
+
+//Read reads and parses a cue sheet from r. Cue sheets are often not encoded in
+//UTF-8, so the encoding is detected: UTF-16 and UTF-8 are recognized by their
+//byte order marks and anything that isn't valid UTF-8 is decoded as
+//Windows-1252. Use Parse for cue sheets in other encodings.
+func Read(r io.Reader) (*Sheet, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	s, err := decode(b)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(s)
+}
+
+//Parse parses the text of a cue sheet. Unknown commands are ignored.
+func Parse(text string) (*Sheet, error) {
+	s := &Sheet{Rem: map[string]string{}}
+	var (
+		f *File
+		t *Track
+	)
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		fields := splitLine(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		command := strings.ToUpper(fields[0])
+		args := fields[1:]
+		//Every command other than REM and FLAGS needs at least one argument
+		if len(args) == 0 && command != "REM" && command != "FLAGS" {
+			continue
+		}
+		switch command {
+		case "CATALOG":
+			s.Catalog = args[0]
+		case "TITLE":
+			if t != nil {
+				t.Title = args[0]
+			} else {
+				s.Title = args[0]
+			}
+		case "PERFORMER":
+			if t != nil {
+				t.Performer = args[0]
+			} else {
+				s.Performer = args[0]
+			}
+		case "SONGWRITER":
+			if t != nil {
+				t.Songwriter = args[0]
+			} else {
+				s.Songwriter = args[0]
+			}
+		case "REM":
+			if len(args) < 2 {
+				continue
+			}
+			if t != nil {
+				t.Rem[strings.ToUpper(args[0])] = strings.Join(args[1:], " ")
+			} else {
+				s.Rem[strings.ToUpper(args[0])] = strings.Join(args[1:], " ")
+			}
+		case "FILE":
+			s.Files = append(s.Files, File{Name: args[0]})
+			f = &s.Files[len(s.Files)-1]
+			if len(args) > 1 {
+				f.Type = strings.ToUpper(args[1])
+			}
+			t = nil
+		case "TRACK":
+			if f == nil {
+				return nil, fmt.Errorf("line %d: TRACK before FILE", lineNumber)
+			}
+			n, err := strconv.Atoi(args[0])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid track number: %q", lineNumber, args[0])
+			}
+			f.Tracks = append(f.Tracks, Track{Number: n, Rem: map[string]string{}})
+			t = &f.Tracks[len(f.Tracks)-1]
+			if len(args) > 1 {
+				t.DataType = strings.ToUpper(args[1])
+			}
+		case "INDEX":
+			if t == nil || len(args) < 2 {
+				return nil, fmt.Errorf("line %d: invalid INDEX", lineNumber)
+			}
+			n, err := strconv.Atoi(args[0])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid index number: %q", lineNumber, args[0])
+			}
+			frames, err := parseTime(args[1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+			t.Indices = append(t.Indices, Index{Number: n, Frames: frames})
+		case "ISRC":
+			if t != nil {
+				t.ISRC = args[0]
+			}
+		case "FLAGS":
+			if t != nil {
+				for _, flag := range args {
+					t.Flags = append(t.Flags, strings.ToUpper(flag))
+				}
+			}
+		case "PREGAP", "POSTGAP":
+			if t == nil {
+				continue
+			}
+			frames, err := parseTime(args[0])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+			if command == "PREGAP" {
+				t.Pregap = framesToDuration(frames)
+			} else {
+				t.Postgap = framesToDuration(frames)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(s.Files) == 0 {
+		return nil, errors.New("expected 'FILE'")
+	}
+	return s, nil
+}
+
+//splitLine splits a cue sheet line into fields, keeping quoted strings together
+//without their quotes.
+func splitLine(line string) []string {
+	var (
+		fields  []string
+		field   strings.Builder
+		quoted  bool
+		inField bool
+	)
+	for _, r := range strings.TrimSpace(line) {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			inField = true
+		case (r == ' ' || r == '\t') && !quoted:
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+		default:
+			field.WriteRune(r)
+			inField = true
+		}
+	}
+	if inField {
+		fields = append(fields, field.String())
+	}
+	return fields
+}
+
+//parseTime parses a cue sheet time in the form mm:ss:ff and returns it as a
+//number of CD frames.
+func parseTime(s string) (int64, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid time: %q", s)
+	}
+	var n [3]int64
+	for i, p := range parts {
+		v, err := strconv.ParseInt(p, 10, 64)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("invalid time: %q", s)
+		}
+		n[i] = v
+	}
+	return (n[0]*60+n[1])*FramesPerSecond + n[2], nil
+}
+
+//framesToDuration converts a number of CD frames to a duration.
+func framesToDuration(frames int64) time.Duration {
+	return time.Duration(frames) * time.Second / FramesPerSecond
+}
+
+//decode converts the bytes of a cue sheet to a string, detecting the encoding
+//as described for Read.
+func decode(b []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(b, []byte{0xEF, 0xBB, 0xBF}):
+		return string(b[3:]), nil
+	case bytes.HasPrefix(b, []byte{0xFF, 0xFE}):
+		return decodeUTF16(b[2:], false)
+	case bytes.HasPrefix(b, []byte{0xFE, 0xFF}):
+		return decodeUTF16(b[2:], true)
+	case utf8.Valid(b):
+		return string(b), nil
+	}
+	return decodeWindows1252(b), nil
+}
+
+//decodeUTF16 decodes UTF-16 text without its byte order mark.
+func decodeUTF16(b []byte, bigEndian bool) (string, error) {
+	if len(b)%2 != 0 {
+		return "", errors.New("invalid encoding: odd number of bytes in UTF-16 text")
+	}
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i < len(b); i += 2 {
+		if bigEndian {
+			u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+		} else {
+			u = append(u, uint16(b[i+1])<<8|uint16(b[i]))
+		}
+	}
+	return string(utf16.Decode(u)), nil
+}
+
+//windows1252 maps the bytes 0x80 to 0x9F of Windows-1252 to runes. The other
+//bytes are the same as in ISO-8859-1.
+var windows1252 = [32]rune{
+	'€', '\u0081', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '\u008D', 'Ž', '\u008F',
+	'\u0090', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '\u009D', 'ž', 'Ÿ',
+}
+
+//decodeWindows1252 decodes Windows-1252 text, which is the most common encoding
+//of cue sheets that aren't UTF-8.
+func decodeWindows1252(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		if c >= 0x80 && c < 0xA0 {
+			sb.WriteRune(windows1252[c-0x80])
+		} else {
+			sb.WriteRune(rune(c))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,165 @@
+package cue
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/slotheroo/yurit"
+)
+
+const testSheet = `REM GENRE "Progressive Rock"
+REM DATE 1999
+REM DISCID 860B640B
+REM COMMENT "ExactAudioCopy v0.99pb5"
+CATALOG 0724384260027
+PERFORMER "The Band"
+TITLE "The Album"
+FILE "The Album.flac" WAVE
+  TRACK 01 AUDIO
+    TITLE "First"
+    ISRC GBAYE9900001
+    INDEX 01 00:00:00
+  TRACK 02 AUDIO
+    TITLE "Second"
+    PERFORMER "The Band feat. Someone"
+    FLAGS DCP
+    INDEX 00 03:00:00
+    INDEX 01 03:02:37
+`
+
+// testMetadata is the metadata of a whole audio file for the tests.
+type testMetadata struct {
+	duration time.Duration
+}
+
+func (m testMetadata) Album() string               { return "Tagged Album" }
+func (m testMetadata) AlbumArtist() string         { return "Tagged Album Artist" }
+func (m testMetadata) Artist() string              { return "Tagged Artist" }
+func (m testMetadata) AverageBitrate() int         { return 900000 }
+func (m testMetadata) Chapters() []yurit.Chapter   { return nil }
+func (m testMetadata) Comment() string             { return "" }
+func (m testMetadata) Composer() string            { return "Tagged Composer" }
+func (m testMetadata) Disc() (int, int)            { return 1, 2 }
+func (m testMetadata) Duration() time.Duration     { return m.duration }
+func (m testMetadata) FileType() yurit.FileType    { return yurit.FLAC }
+func (m testMetadata) Format() yurit.Format        { return yurit.VORBIS }
+func (m testMetadata) Genre() string               { return "" }
+func (m testMetadata) Lyrics() string              { return "" }
+func (m testMetadata) Picture() *yurit.Picture     { return nil }
+func (m testMetadata) Raw() map[string]interface{} { return nil }
+func (m testMetadata) Title() string               { return "Tagged Title" }
+func (m testMetadata) Track() (int, int)           { return 0, 0 }
+func (m testMetadata) Year() int                   { return 2000 }
+
+func TestParse(t *testing.T) {
+	s, err := Parse(testSheet)
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+	expected := &Sheet{
+		Catalog:   "0724384260027",
+		Title:     "The Album",
+		Performer: "The Band",
+		Rem: map[string]string{
+			"GENRE":   "Progressive Rock",
+			"DATE":    "1999",
+			"DISCID":  "860B640B",
+			"COMMENT": "ExactAudioCopy v0.99pb5",
+		},
+		Files: []File{{
+			Name: "The Album.flac",
+			Type: "WAVE",
+			Tracks: []Track{
+				{Number: 1, DataType: "AUDIO", Title: "First", ISRC: "GBAYE9900001", Rem: map[string]string{}, Indices: []Index{{1, 0}}},
+				{Number: 2, DataType: "AUDIO", Title: "Second", Performer: "The Band feat. Someone", Flags: []string{"DCP"}, Rem: map[string]string{}, Indices: []Index{{0, 180 * 75}, {1, 182*75 + 37}}},
+			},
+		}},
+	}
+	if !reflect.DeepEqual(s, expected) {
+		t.Errorf("Parse() = %+v, expected %+v", s, expected)
+	}
+
+	for ii, text := range []string{"", "TRACK 01 AUDIO", "FILE a.wav WAVE\nTRACK xx AUDIO", "FILE a.wav WAVE\nTRACK 01 AUDIO\nINDEX 01 00:00"} {
+		if _, err := Parse(text); err == nil {
+			t.Errorf("[%d] Parse(%q) expected error", ii, text)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		input []byte
+		title string
+	}{
+		{[]byte("TITLE \"Caf\xc3\xa9\"\nFILE a.wav WAVE"), "Café"},
+		{[]byte("\xef\xbb\xbfTITLE \"Caf\xc3\xa9\"\nFILE a.wav WAVE"), "Café"},
+		{[]byte("TITLE \"Caf\xe9 \x80\"\nFILE a.wav WAVE"), "Café €"},
+		{[]byte("\xff\xfeT\x00I\x00T\x00L\x00E\x00 \x00\xe9\x00\n\x00F\x00I\x00L\x00E\x00 \x00a\x00"), "é"},
+		{[]byte("\xfe\xff\x00T\x00I\x00T\x00L\x00E\x00 \x00\xe9\x00\n\x00F\x00I\x00L\x00E\x00 \x00a"), "é"},
+	}
+
+	for ii, tt := range tests {
+		s, err := Read(bytes.NewReader(tt.input))
+		if err != nil {
+			t.Errorf("[%d] Read(%q) returned error: %v", ii, tt.input, err)
+			continue
+		}
+		if s.Title != tt.title {
+			t.Errorf("[%d] Read(%q).Title = %q, expected %q", ii, tt.input, s.Title, tt.title)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	s, err := Parse(testSheet)
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+	tracks, err := s.Split("", testMetadata{duration: 5 * time.Minute})
+	if err != nil {
+		t.Fatalf("Split() returned error: %v", err)
+	}
+	if len(tracks) != 2 {
+		t.Fatalf("Split() returned %d tracks, expected 2", len(tracks))
+	}
+	second := 182*time.Second + 37*time.Second/75
+	tests := []struct {
+		m        yurit.Metadata
+		title    string
+		artist   string
+		track    int
+		duration time.Duration
+	}{
+		{tracks[0], "First", "The Band", 1, second},
+		{tracks[1], "Second", "The Band feat. Someone", 2, 5*time.Minute - second},
+	}
+	for ii, tt := range tests {
+		if tt.m.Title() != tt.title {
+			t.Errorf("[%d] Title() = %q, expected %q", ii, tt.m.Title(), tt.title)
+		}
+		if tt.m.Artist() != tt.artist {
+			t.Errorf("[%d] Artist() = %q, expected %q", ii, tt.m.Artist(), tt.artist)
+		}
+		if n, total := tt.m.Track(); n != tt.track || total != 2 {
+			t.Errorf("[%d] Track() = %d, %d, expected %d, %d", ii, n, total, tt.track, 2)
+		}
+		if tt.m.Duration() != tt.duration {
+			t.Errorf("[%d] Duration() = %v, expected %v", ii, tt.m.Duration(), tt.duration)
+		}
+		if tt.m.Album() != "The Album" || tt.m.AlbumArtist() != "The Band" || tt.m.Genre() != "Progressive Rock" || tt.m.Year() != 1999 {
+			t.Errorf("[%d] expected album values from the cue sheet", ii)
+		}
+		if tt.m.Composer() != "Tagged Composer" || tt.m.FileType() != yurit.FLAC {
+			t.Errorf("[%d] expected fallback values from the audio metadata", ii)
+		}
+	}
+
+	if _, err := s.Split("Other.flac", testMetadata{}); err == nil {
+		t.Errorf("Split() with unknown file expected error")
+	}
+	if _, err := s.Split("", testMetadata{duration: time.Minute}); err == nil {
+		t.Errorf("Split() with too short audio expected error")
+	}
+}
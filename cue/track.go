@@ -0,0 +1,288 @@
+package cue
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slotheroo/yurit"
+)
+
+//TrackMetadata is a yurit.Metadata for a single track of an audio file that is
+//described by a cue sheet. Values from the cue sheet take precedence, and the
+//metadata of the whole audio file is used for anything that the cue sheet
+//doesn't give.
+type TrackMetadata struct {
+	sheet       *Sheet
+	track       Track
+	totalTracks int
+	start       time.Duration
+	end         time.Duration
+	audio       yurit.Metadata
+}
+
+//Split returns a TrackMetadata for each audio track of the named file of the
+//cue sheet, where m is the metadata of that file. Each track ends where the
+//next track starts, so any gap before a track is included in the previous track,
+//and the last track ends at the end of the audio. If the cue sheet only has one
+//file then name may be empty.
+func (s *Sheet) Split(name string, m yurit.Metadata) ([]*TrackMetadata, error) {
+	f, err := s.file(name)
+	if err != nil {
+		return nil, err
+	}
+	var tracks []Track
+	for _, t := range f.Tracks {
+		if t.DataType == "" || t.DataType == "AUDIO" {
+			tracks = append(tracks, t)
+		}
+	}
+	var metadata []*TrackMetadata
+	for i, t := range tracks {
+		tm := &TrackMetadata{
+			sheet:       s,
+			track:       t,
+			totalTracks: s.totalTracks(),
+			start:       t.Start(),
+			audio:       m,
+		}
+		if i+1 < len(tracks) {
+			tm.end = tracks[i+1].Start()
+		} else if m != nil {
+			tm.end = m.Duration()
+		}
+		if tm.end < tm.start {
+			return nil, fmt.Errorf("track %d starts after the end of the audio", t.Number)
+		}
+		metadata = append(metadata, tm)
+	}
+	return metadata, nil
+}
+
+//file returns the file of the cue sheet with the given name.
+func (s *Sheet) file(name string) (*File, error) {
+	if name == "" && len(s.Files) == 1 {
+		return &s.Files[0], nil
+	}
+	for i := range s.Files {
+		if s.Files[i].Name == name {
+			return &s.Files[i], nil
+		}
+	}
+	return nil, fmt.Errorf("file not in cue sheet: %q", name)
+}
+
+//totalTracks returns the number of audio tracks in all files of the cue sheet.
+func (s *Sheet) totalTracks() int {
+	n := 0
+	for _, f := range s.Files {
+		for _, t := range f.Tracks {
+			if t.DataType == "" || t.DataType == "AUDIO" {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func (m TrackMetadata) Album() string {
+	if m.sheet.Title != "" {
+		return m.sheet.Title
+	}
+	if m.audio != nil {
+		return m.audio.Album()
+	}
+	return ""
+}
+
+//AlbumArtist returns the performer of the cue sheet, which is the performer of
+//the whole disc.
+func (m TrackMetadata) AlbumArtist() string {
+	if m.sheet.Performer != "" {
+		return m.sheet.Performer
+	}
+	if m.audio != nil {
+		return m.audio.AlbumArtist()
+	}
+	return ""
+}
+
+//Artist returns the performer of the track, falling back to the performer of
+//the cue sheet.
+func (m TrackMetadata) Artist() string {
+	if m.track.Performer != "" {
+		return m.track.Performer
+	}
+	if m.sheet.Performer != "" {
+		return m.sheet.Performer
+	}
+	if m.audio != nil {
+		return m.audio.Artist()
+	}
+	return ""
+}
+
+//AverageBitrate returns the average bitrate of the whole audio file.
+func (m TrackMetadata) AverageBitrate() int {
+	if m.audio != nil {
+		return m.audio.AverageBitrate()
+	}
+	return 0
+}
+
+//AudioMetadata returns the metadata of the whole audio file.
+func (m TrackMetadata) AudioMetadata() yurit.Metadata {
+	return m.audio
+}
+
+//Chapters returns nil, as the tracks of a cue sheet are themselves the chapters
+//of the audio file.
+func (m TrackMetadata) Chapters() []yurit.Chapter {
+	return nil
+}
+
+func (m TrackMetadata) Comment() string {
+	if c := m.sheet.Comment(); c != "" {
+		return c
+	}
+	if m.audio != nil {
+		return m.audio.Comment()
+	}
+	return ""
+}
+
+func (m TrackMetadata) Composer() string {
+	if m.track.Songwriter != "" {
+		return m.track.Songwriter
+	}
+	if m.sheet.Songwriter != "" {
+		return m.sheet.Songwriter
+	}
+	if m.audio != nil {
+		return m.audio.Composer()
+	}
+	return ""
+}
+
+//Disc returns the disc number and total discs from the REM DISCNUMBER and REM
+//TOTALDISCS comments, falling back to those of the audio file.
+func (m TrackMetadata) Disc() (int, int) {
+	disc, _ := strconv.Atoi(m.sheet.Rem["DISCNUMBER"])
+	total, _ := strconv.Atoi(m.sheet.Rem["TOTALDISCS"])
+	if disc == 0 && m.audio != nil {
+		return m.audio.Disc()
+	}
+	return disc, total
+}
+
+//Duration returns the length of the track, from its INDEX 01 to the INDEX 01
+//of the next track or the end of the audio.
+func (m TrackMetadata) Duration() time.Duration {
+	return m.end - m.start
+}
+
+//End returns the end of the track from the beginning of the audio file.
+func (m TrackMetadata) End() time.Duration {
+	return m.end
+}
+
+func (m TrackMetadata) FileType() yurit.FileType {
+	if m.audio != nil {
+		return m.audio.FileType()
+	}
+	return yurit.UnknownFileType
+}
+
+func (m TrackMetadata) Format() yurit.Format {
+	return yurit.CUE
+}
+
+func (m TrackMetadata) Genre() string {
+	if g := m.sheet.Genre(); g != "" {
+		return g
+	}
+	if m.audio != nil {
+		return m.audio.Genre()
+	}
+	return ""
+}
+
+//ISRC returns the International Standard Recording Code of the track.
+func (m TrackMetadata) ISRC() string {
+	return m.track.ISRC
+}
+
+//Lyrics returns an empty string, as lyrics of the whole audio file don't belong
+//to any one track.
+func (m TrackMetadata) Lyrics() string {
+	return ""
+}
+
+//Picture returns the picture of the whole audio file.
+func (m TrackMetadata) Picture() *yurit.Picture {
+	if m.audio != nil {
+		return m.audio.Picture()
+	}
+	return nil
+}
+
+//Raw returns the commands of the cue sheet that apply to the track, with
+//lowercase names. REM comments of the track override those of the cue sheet.
+func (m TrackMetadata) Raw() map[string]interface{} {
+	raw := map[string]interface{}{}
+	add := func(k, v string) {
+		if v != "" {
+			raw[k] = v
+		}
+	}
+	add("catalog", m.sheet.Catalog)
+	add("album", m.sheet.Title)
+	add("albumartist", m.sheet.Performer)
+	for k, v := range m.sheet.Rem {
+		add(strings.ToLower(k), v)
+	}
+	for k, v := range m.track.Rem {
+		add(strings.ToLower(k), v)
+	}
+	add("title", m.track.Title)
+	add("performer", m.track.Performer)
+	add("songwriter", m.track.Songwriter)
+	add("isrc", m.track.ISRC)
+	raw["track"] = m.track.Number
+	return raw
+}
+
+//Sheet returns the cue sheet that the track is from.
+func (m TrackMetadata) Sheet() *Sheet {
+	return m.sheet
+}
+
+//Start returns the start of the track from the beginning of the audio file.
+func (m TrackMetadata) Start() time.Duration {
+	return m.start
+}
+
+func (m TrackMetadata) Title() string {
+	return m.track.Title
+}
+
+//Track returns the number of the track and the number of audio tracks in the
+//cue sheet.
+func (m TrackMetadata) Track() (int, int) {
+	return m.track.Number, m.totalTracks
+}
+
+//Year returns the year from the REM DATE comment, falling back to the year of
+//the audio file.
+func (m TrackMetadata) Year() int {
+	if d := m.sheet.Date(); len(d) >= 4 {
+		if y, err := strconv.Atoi(d[:4]); err == nil {
+			return y
+		}
+	}
+	if m.audio != nil {
+		return m.audio.Year()
+	}
+	return 0
+}
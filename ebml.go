@@ -0,0 +1,173 @@
+package yurit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+//ebmlElement is a single element read from an EBML document, such as a
+//Matroska or WebM file. Only the data of elements that we are interested in is
+//kept, and master elements are expected to be broken down further with
+//processEBMLElements.
+//https://github.com/ietf-wg-cellar/ebml-specification/blob/master/specification.markdown
+type ebmlElement struct {
+	id   uint32
+	data []byte
+}
+
+//ebmlUnknownSize is returned as the size of an element whose size field has all
+//of its value bits set, which EBML uses to mean that the size is unknown.
+const ebmlUnknownSize int64 = -1
+
+//readEBMLVint reads a variable length integer from an EBML stream. The number
+//of leading zero bits in the first byte determines the length of the integer.
+//If keepMarker is true then the length marker bit is kept in the value, which
+//is how element IDs are represented.
+func readEBMLVint(r io.Reader, keepMarker bool) (value uint64, length int, err error) {
+	b, err := readBytes(r, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	length = ebmlVintLength(b[0])
+	if length == 0 {
+		return 0, 0, errors.New("invalid EBML variable length integer")
+	}
+	rest, err := readBytes(r, uint(length-1))
+	if err != nil {
+		return 0, 0, err
+	}
+	value, _ = getEBMLVint(append(b, rest...), keepMarker)
+	return value, length, nil
+}
+
+//getEBMLVint works as readEBMLVint but on a slice of bytes, and returns the
+//decoded value along with the number of bytes it occupied. A length of 0 is
+//returned if b does not hold a valid variable length integer.
+func getEBMLVint(b []byte, keepMarker bool) (uint64, int) {
+	if len(b) == 0 {
+		return 0, 0
+	}
+	length := ebmlVintLength(b[0])
+	if length == 0 || len(b) < length {
+		return 0, 0
+	}
+	first := b[0]
+	if !keepMarker {
+		first &= 0xFF >> uint(length)
+	}
+	value := uint64(first)
+	for _, x := range b[1:length] {
+		value = value<<8 | uint64(x)
+	}
+	return value, length
+}
+
+//ebmlVintLength returns the total length in bytes of a variable length integer
+//given its first byte, or 0 if the first byte is invalid.
+func ebmlVintLength(first byte) int {
+	for i := 0; i < 8; i++ {
+		if getBit(first, uint(7-i)) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+//readEBMLElementHeader reads the ID and the size of the next element in an EBML
+//stream. The returned headerSize is the number of bytes used by the ID and size
+//fields. If the element has an unknown size then ebmlUnknownSize is returned as
+//size.
+func readEBMLElementHeader(r io.Reader) (id uint32, size int64, headerSize int, err error) {
+	idValue, idLen, err := readEBMLVint(r, true)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if idLen > 4 {
+		return 0, 0, 0, fmt.Errorf("invalid EBML element ID length: %d", idLen)
+	}
+	sizeValue, sizeLen, err := readEBMLVint(r, false)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	size = int64(sizeValue)
+	if sizeValue == (1<<uint(7*sizeLen))-1 {
+		size = ebmlUnknownSize
+	}
+	return uint32(idValue), size, idLen + sizeLen, nil
+}
+
+//readEBMLElementData reads the data of an element of the given size at the
+//current position of r. The size comes from the file, so it is checked against
+//the rest of the file before the data is read.
+func readEBMLElementData(r io.ReadSeeker, size int64) ([]byte, error) {
+	offset, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.Seek(offset, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	if size < 0 || size > end-offset {
+		return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", size, end-offset)
+	}
+	return readBytes(r, uint(size))
+}
+
+//processEBMLElements breaks the data of a master element down into its child
+//elements.
+func processEBMLElements(b []byte) ([]ebmlElement, error) {
+	var elements []ebmlElement
+	offset := 0
+	for offset < len(b) {
+		id, idLen := getEBMLVint(b[offset:], true)
+		if idLen == 0 || idLen > 4 {
+			return nil, fmt.Errorf("invalid EBML element ID at offset %d", offset)
+		}
+		offset += idLen
+		size, sizeLen := getEBMLVint(b[offset:], false)
+		if sizeLen == 0 {
+			return nil, fmt.Errorf("invalid EBML element size at offset %d", offset)
+		}
+		offset += sizeLen
+		if size == (1<<uint(7*sizeLen))-1 {
+			//An unknown size inside an element we have already read means that the
+			//child runs to the end of its parent
+			size = uint64(len(b) - offset)
+		}
+		if uint64(len(b)-offset) < size {
+			return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", size, len(b)-offset)
+		}
+		elements = append(elements, ebmlElement{id: uint32(id), data: b[offset : offset+int(size)]})
+		offset += int(size)
+	}
+	return elements, nil
+}
+
+//findEBMLElement returns the first element with the given ID, or nil if there
+//is no such element.
+func findEBMLElement(elements []ebmlElement, id uint32) *ebmlElement {
+	for i := range elements {
+		if elements[i].id == id {
+			return &elements[i]
+		}
+	}
+	return nil
+}
+
+//getEBMLFloat reads an EBML float element, which may be either 4 or 8 bytes
+//long. An empty element represents a value of 0.
+func getEBMLFloat(b []byte) float64 {
+	if len(b) == 4 {
+		return float64(math.Float32frombits(uint32(getUint(b))))
+	} else if len(b) == 8 {
+		return getFloat64(b)
+	}
+	return 0
+}
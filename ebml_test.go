@@ -0,0 +1,79 @@
+package yurit
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGetEBMLVint(t *testing.T) {
+	tests := []struct {
+		input      []byte
+		keepMarker bool
+		value      uint64
+		length     int
+	}{
+		{nil, false, 0, 0},
+		{[]byte{0x00}, false, 0, 0},
+		{[]byte{0x81}, false, 1, 1},
+		{[]byte{0x81}, true, 0x81, 1},
+		{[]byte{0x40, 0x02}, false, 2, 2},
+		{[]byte{0x42, 0x82}, true, 0x4282, 2},
+		{[]byte{0x1A, 0x45, 0xDF, 0xA3}, true, 0x1A45DFA3, 4},
+		{[]byte{0x1A, 0x45, 0xDF}, true, 0, 0},
+		{[]byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00}, false, 0x100, 8},
+	}
+
+	for ii, tt := range tests {
+		value, length := getEBMLVint(tt.input, tt.keepMarker)
+		if value != tt.value || length != tt.length {
+			t.Errorf("[%d] getEBMLVint(%v, %v) = %v, %v, expected %v, %v", ii, tt.input, tt.keepMarker, value, length, tt.value, tt.length)
+		}
+	}
+}
+
+func TestReadEBMLElementHeader(t *testing.T) {
+	tests := []struct {
+		input      []byte
+		id         uint32
+		size       int64
+		headerSize int
+	}{
+		{[]byte{0x1A, 0x45, 0xDF, 0xA3, 0x9F}, 0x1A45DFA3, 31, 5},
+		{[]byte{0x18, 0x53, 0x80, 0x67, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, 0x18538067, ebmlUnknownSize, 12},
+		{[]byte{0x1F, 0x43, 0xB6, 0x75, 0xFF}, 0x1F43B675, ebmlUnknownSize, 5},
+	}
+
+	for ii, tt := range tests {
+		id, size, headerSize, err := readEBMLElementHeader(bytes.NewReader(tt.input))
+		if err != nil {
+			t.Errorf("[%d] readEBMLElementHeader(%v) returned error: %v", ii, tt.input, err)
+			continue
+		}
+		if id != tt.id || size != tt.size || headerSize != tt.headerSize {
+			t.Errorf("[%d] readEBMLElementHeader(%v) = %x, %v, %v, expected %x, %v, %v", ii, tt.input, id, size, headerSize, tt.id, tt.size, tt.headerSize)
+		}
+	}
+}
+
+func TestProcessEBMLElements(t *testing.T) {
+	//DocType "webm" followed by DocTypeVersion 4
+	b := []byte{0x42, 0x82, 0x84, 'w', 'e', 'b', 'm', 0x42, 0x87, 0x81, 0x04}
+	elements, err := processEBMLElements(b)
+	if err != nil {
+		t.Fatalf("processEBMLElements(%v) returned error: %v", b, err)
+	}
+	if len(elements) != 2 {
+		t.Fatalf("processEBMLElements(%v) returned %d elements, expected 2", b, len(elements))
+	}
+	if elements[0].id != ebmlDocTypeID || string(elements[0].data) != "webm" {
+		t.Errorf("processEBMLElements(%v)[0] = %x %q, expected %x %q", b, elements[0].id, elements[0].data, ebmlDocTypeID, "webm")
+	}
+	if elements[1].id != 0x4287 || getUint(elements[1].data) != 4 {
+		t.Errorf("processEBMLElements(%v)[1] = %x %v, expected %x %v", b, elements[1].id, elements[1].data, 0x4287, 4)
+	}
+
+	_, err = processEBMLElements(b[:5])
+	if err == nil {
+		t.Errorf("processEBMLElements(%v) expected error for truncated element", b[:5])
+	}
+}
@@ -16,10 +16,10 @@ const (
 	streamInfoBlock blockType = 0
 	// Padding Block               1
 	// Application Block           2
-	// Seektable Block             3
+	seekTableBlock     blockType = 3
 	vorbisCommentBlock blockType = 4
-	// Cue Sheet Block             5
-	pictureBlock blockType = 6
+	cueSheetBlock      blockType = 5
+	pictureBlock       blockType = 6
 )
 
 //FLACMetadata is a collection of metadata and other useful data from a native
@@ -29,6 +29,8 @@ type FLACMetadata struct {
 	fileSize      int64
 	metadataSize  int64
 	streamInfo    flacStreamInfo
+	cueSheet      *FLACCueSheet
+	seekPoints    []FLACSeekPoint
 	pictures      []Picture
 	vorbisComment vorbisComment
 }
@@ -79,7 +81,7 @@ func (m *FLACMetadata) readFLACMetadataBlocks(r io.ReadSeeker) error {
 		if err != nil {
 			return err
 		}
-		m.metadataSize += int64(blockLen)
+		m.metadataSize += 4 + int64(blockLen) //block header and block
 
 		if blockType(blockHeader[0]) == streamInfoBlock {
 			b, err := readBytes(r, uint(blockLen))
@@ -94,6 +96,24 @@ func (m *FLACMetadata) readFLACMetadataBlocks(r io.ReadSeeker) error {
 				return err
 			}
 			err = m.loadVorbisComment(b)
+		} else if blockType(blockHeader[0]) == seekTableBlock {
+			b, err := readBytes(r, uint(blockLen))
+			if err != nil {
+				return err
+			}
+			m.seekPoints, err = processSeekTableBlock(b)
+			if err != nil {
+				return err
+			}
+		} else if blockType(blockHeader[0]) == cueSheetBlock {
+			b, err := readBytes(r, uint(blockLen))
+			if err != nil {
+				return err
+			}
+			m.cueSheet, err = processCueSheetBlock(b, m.streamInfo.SampleRate())
+			if err != nil {
+				return err
+			}
 		} else if blockType(blockHeader[0]) == pictureBlock {
 			b, err := readBytes(r, uint(blockLen))
 			if err != nil {
@@ -227,6 +247,23 @@ func (m FLACMetadata) AverageBitrate() int {
 	return int(audioDataSize / durationInSeconds)
 }
 
+//Chapters returns the chapters from the CHAPTERxxx comments of the Vorbis
+//comment. If there are none then the audio tracks of the cue sheet are used,
+//preferring the CUESHEET block over a CUESHEET comment.
+func (m FLACMetadata) Chapters() []Chapter {
+	chapters := m.vorbisComment.Chapters()
+	if len(chapters) == 0 {
+		c := m.CueSheet()
+		if c == nil {
+			c = m.CueSheetComment()
+		}
+		if c != nil {
+			chapters = c.chapters()
+		}
+	}
+	return completeChapters(chapters, m.Duration())
+}
+
 func (m FLACMetadata) Comment() string {
 	if m.vorbisComment != nil {
 		return m.vorbisComment.Comment()
@@ -241,6 +278,27 @@ func (m FLACMetadata) Composer() string {
 	return ""
 }
 
+//CueSheet returns the cue sheet from a FLAC file's CUESHEET metadata block, or
+//nil if there is none.
+//https://xiph.org/flac/format.html#metadata_block_cuesheet
+func (m FLACMetadata) CueSheet() *FLACCueSheet {
+	return m.cueSheet
+}
+
+//CueSheetComment returns the cue sheet parsed from the CUESHEET comment of the
+//Vorbis comment, or nil if there is none or it can't be parsed.
+func (m FLACMetadata) CueSheetComment() *FLACCueSheet {
+	s, ok := m.vorbisComment["cuesheet"]
+	if !ok {
+		return nil
+	}
+	c, err := parseCueSheetComment(s, m.SampleRate())
+	if err != nil {
+		return nil
+	}
+	return c
+}
+
 func (m FLACMetadata) Disc() (int, int) {
 	if m.vorbisComment != nil {
 		return m.vorbisComment.Disc()
@@ -0,0 +1,264 @@
+package yurit
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//FLACCueSheet is a cue sheet from a FLAC file, read either from a CUESHEET
+//metadata block or from a CUESHEET Vorbis comment. All offsets are in samples.
+//https://xiph.org/flac/format.html#metadata_block_cuesheet
+type FLACCueSheet struct {
+	MediaCatalogNumber string
+	LeadInSamples      int64 //Zero for cue sheets from a Vorbis comment
+	IsCD               bool
+	Tracks             []FLACCueSheetTrack
+}
+
+//FLACCueSheetTrack is a track from a cue sheet. Offset and Start are from the
+//beginning of the audio. A CUESHEET block always ends with a lead-out track.
+type FLACCueSheetTrack struct {
+	Number      int
+	Offset      int64
+	Start       time.Duration
+	ISRC        string
+	Audio       bool
+	PreEmphasis bool
+	Title       string //Only set for cue sheets from a Vorbis comment
+	Performer   string //Only set for cue sheets from a Vorbis comment
+	Indices     []FLACCueSheetIndex
+}
+
+//FLACCueSheetIndex is an index point of a track. Offset is from the beginning
+//of the track, as it is stored in the CUESHEET block, while Start is from the
+//beginning of the audio.
+type FLACCueSheetIndex struct {
+	Number int
+	Offset int64
+	Start  time.Duration
+}
+
+//IsLeadOut reports whether the track is the lead-out track, which has number
+//170 on CDs and 255 otherwise.
+func (t FLACCueSheetTrack) IsLeadOut() bool {
+	return t.Number == 170 || t.Number == 255
+}
+
+//indexStart returns the start of index point 1 of the track, which is where
+//the track itself starts after any pregap in index point 0, or the start of the
+//track if it has no index point 1.
+func (t FLACCueSheetTrack) indexStart() time.Duration {
+	for _, idx := range t.Indices {
+		if idx.Number == 1 {
+			return idx.Start
+		}
+	}
+	return t.Start
+}
+
+//chapters returns the audio tracks of the cue sheet as chapters, each starting
+//at its index point 1 and ending where the next track or the lead-out starts,
+//so that the pregap of a track is part of the previous chapter.
+func (c FLACCueSheet) chapters() []Chapter {
+	var chapters []Chapter
+	for i, t := range c.Tracks {
+		if t.IsLeadOut() || !t.Audio {
+			continue
+		}
+		ch := Chapter{Title: t.Title, Start: t.indexStart()}
+		if i+1 < len(c.Tracks) {
+			ch.End = c.Tracks[i+1].indexStart()
+		}
+		chapters = append(chapters, ch)
+	}
+	return chapters
+}
+
+//samplesToDuration converts a number of samples to a duration.
+func samplesToDuration(samples int64, sampleRate int) time.Duration {
+	if sampleRate <= 0 {
+		return time.Duration(0)
+	}
+	return time.Duration(float64(samples) / float64(sampleRate) * float64(time.Second))
+}
+
+//processCueSheetBlock reads a CUESHEET metadata block. The sample rate from
+//STREAMINFO is needed to convert the offsets to times.
+func processCueSheetBlock(b []byte, sampleRate int) (*FLACCueSheet, error) {
+	//128 catalog number, 8 lead-in samples, 1 CD flag, 258 reserved, 1 tracks
+	if err := checkLen(b, 396); err != nil {
+		return nil, err
+	}
+	c := &FLACCueSheet{}
+	c.MediaCatalogNumber = getString(b[0:128])
+	c.LeadInSamples = getInt64(b[128:136])
+	c.IsCD = getBit(b[136], 7)
+	numTracks := int(b[395])
+	offset := 396
+	for i := 0; i < numTracks; i++ {
+		//8 offset, 1 number, 12 ISRC, 1 type and pre-emphasis, 13 reserved,
+		//1 index points
+		if err := checkLen(b, uint(offset+36)); err != nil {
+			return nil, err
+		}
+		t := FLACCueSheetTrack{
+			Offset:      getInt64(b[offset : offset+8]),
+			Number:      int(b[offset+8]),
+			ISRC:        getString(b[offset+9 : offset+21]),
+			Audio:       !getBit(b[offset+21], 7),
+			PreEmphasis: getBit(b[offset+21], 6),
+		}
+		t.Start = samplesToDuration(t.Offset, sampleRate)
+		numIndices := int(b[offset+35])
+		offset += 36
+		for j := 0; j < numIndices; j++ {
+			//8 offset, 1 number, 3 reserved
+			if err := checkLen(b, uint(offset+12)); err != nil {
+				return nil, err
+			}
+			idx := FLACCueSheetIndex{
+				Offset: getInt64(b[offset : offset+8]),
+				Number: int(b[offset+8]),
+			}
+			idx.Start = samplesToDuration(t.Offset+idx.Offset, sampleRate)
+			t.Indices = append(t.Indices, idx)
+			offset += 12
+		}
+		c.Tracks = append(c.Tracks, t)
+	}
+	return c, nil
+}
+
+//cueFramesPerSecond is the number of CD frames per second, used for the
+//mm:ss:ff times of cue sheet INDEX commands.
+const cueFramesPerSecond = 75
+
+//parseCueSheetComment parses the text of a CUESHEET Vorbis comment. Only the
+//commands that are needed to fill a FLACCueSheet are used, and since the audio
+//is the FLAC file itself, FILE commands are ignored. The offset of each track is
+//its first index point.
+func parseCueSheetComment(s string, sampleRate int) (*FLACCueSheet, error) {
+	c := &FLACCueSheet{}
+	var t *FLACCueSheetTrack
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for scanner.Scan() {
+		fields := splitCueLine(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "CATALOG":
+			if len(fields) > 1 {
+				c.MediaCatalogNumber = fields[1]
+			}
+		case "TRACK":
+			if len(fields) < 3 {
+				return nil, fmt.Errorf("invalid cue sheet TRACK: %q", scanner.Text())
+			}
+			n, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid cue sheet TRACK number: %q", fields[1])
+			}
+			c.Tracks = append(c.Tracks, FLACCueSheetTrack{Number: n, Audio: strings.ToUpper(fields[2]) == "AUDIO"})
+			t = &c.Tracks[len(c.Tracks)-1]
+		case "INDEX":
+			if t == nil || len(fields) < 3 {
+				return nil, fmt.Errorf("invalid cue sheet INDEX: %q", scanner.Text())
+			}
+			n, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid cue sheet INDEX number: %q", fields[1])
+			}
+			frames, err := parseCueTime(fields[2])
+			if err != nil {
+				return nil, err
+			}
+			samples := frames * int64(sampleRate) / cueFramesPerSecond
+			if len(t.Indices) == 0 {
+				t.Offset = samples
+				t.Start = samplesToDuration(samples, sampleRate)
+			}
+			t.Indices = append(t.Indices, FLACCueSheetIndex{
+				Number: n,
+				Offset: samples - t.Offset,
+				Start:  samplesToDuration(samples, sampleRate),
+			})
+		case "ISRC":
+			if t != nil && len(fields) > 1 {
+				t.ISRC = fields[1]
+			}
+		case "FLAGS":
+			if t != nil {
+				for _, f := range fields[1:] {
+					if strings.ToUpper(f) == "PRE" {
+						t.PreEmphasis = true
+					}
+				}
+			}
+		case "TITLE":
+			if t != nil && len(fields) > 1 {
+				t.Title = fields[1]
+			}
+		case "PERFORMER":
+			if t != nil && len(fields) > 1 {
+				t.Performer = fields[1]
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+//splitCueLine splits a cue sheet line into fields, keeping quoted strings
+//together without their quotes.
+func splitCueLine(line string) []string {
+	var (
+		fields  []string
+		field   strings.Builder
+		quoted  bool
+		inField bool
+	)
+	for _, r := range strings.TrimSpace(line) {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			inField = true
+		case (r == ' ' || r == '\t') && !quoted:
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+		default:
+			field.WriteRune(r)
+			inField = true
+		}
+	}
+	if inField {
+		fields = append(fields, field.String())
+	}
+	return fields
+}
+
+//parseCueTime parses a cue sheet time in the form mm:ss:ff and returns it as a
+//number of CD frames, of which there are 75 per second.
+func parseCueTime(s string) (int64, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid cue sheet time: %q", s)
+	}
+	var n [3]int64
+	for i, p := range parts {
+		v, err := strconv.ParseInt(p, 10, 64)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("invalid cue sheet time: %q", s)
+		}
+		n[i] = v
+	}
+	return (n[0]*60+n[1])*cueFramesPerSecond + n[2], nil
+}
@@ -0,0 +1,137 @@
+package yurit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProcessCueSheetBlock(t *testing.T) {
+	b := make([]byte, 396)
+	copy(b, "1234567890123")
+	b[128+5], b[128+6], b[128+7] = 0x01, 0x58, 0x88 //lead-in of 88200 samples
+	b[136] = 0x80                                   //CD
+	b[395] = 2
+	track := make([]byte, 36)
+	copy(track[9:], "USABC1234567")
+	track[8] = 1
+	track[21] = 0x40 //audio with pre-emphasis
+	track[35] = 1
+	index := make([]byte, 12)
+	index[8] = 1
+	b = append(b, track...)
+	b = append(b, index...)
+	leadOut := make([]byte, 36)
+	leadOut[5], leadOut[6], leadOut[7] = 0x01, 0x58, 0x88 //88200 samples
+	leadOut[8] = 170
+	b = append(b, leadOut...)
+
+	c, err := processCueSheetBlock(b, 44100)
+	if err != nil {
+		t.Fatalf("processCueSheetBlock() returned error: %v", err)
+	}
+	expected := &FLACCueSheet{
+		MediaCatalogNumber: "1234567890123",
+		LeadInSamples:      88200,
+		IsCD:               true,
+		Tracks: []FLACCueSheetTrack{
+			{Number: 1, ISRC: "USABC1234567", Audio: true, PreEmphasis: true, Indices: []FLACCueSheetIndex{{Number: 1}}},
+			{Number: 170, Offset: 88200, Start: 2 * time.Second, Audio: true},
+		},
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf("processCueSheetBlock() = %+v, expected %+v", c, expected)
+	}
+	if _, err := processCueSheetBlock(b[:len(b)-1], 44100); err == nil {
+		t.Errorf("processCueSheetBlock() with truncated block expected error")
+	}
+}
+
+func TestParseCueSheetComment(t *testing.T) {
+	s := "CATALOG 1234567890123\r\n" +
+		"FILE \"album.flac\" WAVE\r\n" +
+		"  TRACK 01 AUDIO\r\n" +
+		"    TITLE \"First Song\"\r\n" +
+		"    PERFORMER \"Someone\"\r\n" +
+		"    INDEX 01 00:00:00\r\n" +
+		"  TRACK 02 AUDIO\r\n" +
+		"    ISRC USABC1234567\r\n" +
+		"    FLAGS DCP PRE\r\n" +
+		"    INDEX 00 00:02:00\r\n" +
+		"    INDEX 01 00:03:00\r\n"
+	c, err := parseCueSheetComment(s, 44100)
+	if err != nil {
+		t.Fatalf("parseCueSheetComment() returned error: %v", err)
+	}
+	expected := &FLACCueSheet{
+		MediaCatalogNumber: "1234567890123",
+		Tracks: []FLACCueSheetTrack{
+			{Number: 1, Audio: true, Title: "First Song", Performer: "Someone", Indices: []FLACCueSheetIndex{{Number: 1}}},
+			{Number: 2, Offset: 88200, Start: 2 * time.Second, ISRC: "USABC1234567", Audio: true, PreEmphasis: true, Indices: []FLACCueSheetIndex{
+				{Number: 0, Start: 2 * time.Second},
+				{Number: 1, Offset: 44100, Start: 3 * time.Second},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf("parseCueSheetComment() = %+v, expected %+v", c, expected)
+	}
+
+	chapters := completeChapters(c.chapters(), 5*time.Second)
+	expectedChapters := []Chapter{
+		{Index: 1, Title: "First Song", End: 3 * time.Second},
+		{Index: 2, Start: 3 * time.Second, End: 5 * time.Second},
+	}
+	if !reflect.DeepEqual(chapters, expectedChapters) {
+		t.Errorf("chapters() = %v, expected %v", chapters, expectedChapters)
+	}
+
+	for ii, s := range []string{"INDEX 01 00:00:00", "TRACK 01 AUDIO\nINDEX 01 00:00", "TRACK xx AUDIO"} {
+		if _, err := parseCueSheetComment(s, 44100); err == nil {
+			t.Errorf("[%d] parseCueSheetComment(%q) expected error", ii, s)
+		}
+	}
+}
+
+func TestFLACCueSheetChapters(t *testing.T) {
+	c := FLACCueSheet{Tracks: []FLACCueSheetTrack{
+		{Number: 1, Audio: true, Indices: []FLACCueSheetIndex{{Number: 1}}},
+		//A pregap of 2 seconds before the track
+		{Number: 2, Start: 60 * time.Second, Audio: true, Indices: []FLACCueSheetIndex{
+			{Number: 0, Start: 60 * time.Second},
+			{Number: 1, Start: 62 * time.Second},
+		}},
+		//No index point 1
+		{Number: 3, Start: 120 * time.Second, Audio: true, Indices: []FLACCueSheetIndex{{Number: 0, Start: 120 * time.Second}}},
+		{Number: 4, Start: 180 * time.Second, Audio: false, Indices: []FLACCueSheetIndex{{Number: 1, Start: 181 * time.Second}}},
+		{Number: 170, Start: 240 * time.Second, Audio: true},
+	}}
+	expected := []Chapter{
+		{End: 62 * time.Second},
+		{Start: 62 * time.Second, End: 120 * time.Second},
+		{Start: 120 * time.Second, End: 181 * time.Second},
+	}
+	if chapters := c.chapters(); !reflect.DeepEqual(chapters, expected) {
+		t.Errorf("chapters() = %v, expected %v", chapters, expected)
+	}
+}
+
+func TestParseCueTime(t *testing.T) {
+	tests := []struct {
+		input      string
+		frames     int64
+		makesError bool
+	}{
+		{"00:00:00", 0, false},
+		{"01:02:03", (62 * 75) + 3, false},
+		{"01:02", 0, true},
+		{"aa:00:00", 0, true},
+	}
+
+	for ii, tt := range tests {
+		frames, err := parseCueTime(tt.input)
+		if (err != nil) != tt.makesError || frames != tt.frames {
+			t.Errorf("[%d] parseCueTime(%q) = %v, %v, expected %v, error: %v", ii, tt.input, frames, err, tt.frames, tt.makesError)
+		}
+	}
+}
@@ -0,0 +1,427 @@
+package yurit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+)
+
+//FLACDecoder decodes the audio frames of a FLAC file to PCM samples.
+//https://xiph.org/flac/format.html#frame
+type FLACDecoder struct {
+	br         *flacBitReader
+	sampleSize int
+	samples    int64
+}
+
+//NewDecoder returns a FLACDecoder for the audio in r, the file that m was read
+//from.
+func (m FLACMetadata) NewDecoder(r io.ReadSeeker) (*FLACDecoder, error) {
+	_, err := r.Seek(m.metadataSize, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	sampleSize, _ := m.streamInfo[SampleSizeKey].(byte)
+	return &FLACDecoder{
+		br:         &flacBitReader{r: bufio.NewReaderSize(r, 1<<16)},
+		sampleSize: int(sampleSize),
+	}, nil
+}
+
+//Next decodes the next frame and returns its samples, with a slice of samples
+//for each channel. After the last frame io.EOF is returned. An error is
+//returned if the CRC-16 of the frame doesn't match.
+func (d *FLACDecoder) Next() ([][]int32, error) {
+	b, err := d.br.r.Peek(flacMaxFrameHeaderSize)
+	if len(b) == 0 {
+		if err == nil {
+			err = io.EOF
+		}
+		return nil, err
+	}
+	h, err := parseFLACFrameHeader(b)
+	if err != nil {
+		return nil, fmt.Errorf("after sample %d: %v", d.samples, err)
+	}
+	if !h.crcOK {
+		return nil, fmt.Errorf("after sample %d: frame header CRC-8 mismatch", d.samples)
+	}
+	d.br.crc = updateFLACCRC16(0, b[:h.size])
+	d.br.r.Discard(h.size)
+	sampleSize := h.sampleSize
+	if sampleSize == 0 {
+		sampleSize = d.sampleSize
+	}
+	if sampleSize == 0 {
+		return nil, errors.New("unknown sample size")
+	}
+	channels := make([][]int64, h.channels())
+	for i := range channels {
+		//The side channel has one more bit
+		ss := sampleSize
+		if (h.channelAssignment == 8 && i == 1) || (h.channelAssignment == 9 && i == 0) || (h.channelAssignment == 10 && i == 1) {
+			ss++
+		}
+		channels[i], err = d.br.readSubframe(h.blockSize, ss)
+		if err != nil {
+			return nil, err
+		}
+	}
+	d.br.alignToByte()
+	crc := d.br.crc
+	footer, err := d.br.readBits(16)
+	if err != nil {
+		return nil, err
+	}
+	if uint16(footer) != crc {
+		return nil, fmt.Errorf("after sample %d: frame CRC-16 mismatch", d.samples)
+	}
+	d.samples += int64(h.blockSize)
+	return decorrelateFLACChannels(channels, h.channelAssignment), nil
+}
+
+//SampleSize returns the number of bits per sample of the decoded samples.
+func (d *FLACDecoder) SampleSize() int {
+	return d.sampleSize
+}
+
+//VerifyMD5 decodes all of the audio in r, the file that m was read from, and
+//returns whether the MD5 signature of the decoded samples matches the one in
+//STREAMINFO. An error is returned if the audio can't be decoded or STREAMINFO
+//has no signature.
+func (m FLACMetadata) VerifyMD5(r io.ReadSeeker) (bool, error) {
+	signature, _ := m.streamInfo[MD5Key].([]byte)
+	if len(signature) != md5.Size || bytes.Equal(signature, make([]byte, md5.Size)) {
+		return false, errors.New("no MD5 signature in STREAMINFO")
+	}
+	d, err := m.NewDecoder(r)
+	if err != nil {
+		return false, err
+	}
+	h := md5.New()
+	//Samples are signed little endian and interleaved, using as many whole
+	//bytes as needed for the sample size
+	bytesPerSample := (d.sampleSize + 7) / 8
+	var buf []byte
+	for {
+		samples, err := d.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return false, err
+		}
+		buf = buf[:0]
+		for i := range samples[0] {
+			for _, c := range samples {
+				s := c[i]
+				for j := 0; j < bytesPerSample; j++ {
+					buf = append(buf, byte(s>>(8*uint(j))))
+				}
+			}
+		}
+		h.Write(buf)
+	}
+	return bytes.Equal(h.Sum(nil), signature), nil
+}
+
+//decorrelateFLACChannels restores the left and right channels of stereo frames
+//that were coded as one channel and the difference between the channels.
+func decorrelateFLACChannels(channels [][]int64, channelAssignment byte) [][]int32 {
+	samples := make([][]int32, len(channels))
+	for i := range samples {
+		samples[i] = make([]int32, len(channels[i]))
+	}
+	for i := range channels[0] {
+		switch channelAssignment {
+		case 8:
+			//Left and side
+			left, side := channels[0][i], channels[1][i]
+			samples[0][i], samples[1][i] = int32(left), int32(left-side)
+		case 9:
+			//Side and right
+			side, right := channels[0][i], channels[1][i]
+			samples[0][i], samples[1][i] = int32(side+right), int32(right)
+		case 10:
+			//Mid and side, where the lowest bit of mid was lost
+			mid, side := channels[0][i], channels[1][i]
+			mid = mid<<1 | side&1
+			samples[0][i], samples[1][i] = int32((mid+side)>>1), int32((mid-side)>>1)
+		default:
+			for c := range channels {
+				samples[c][i] = int32(channels[c][i])
+			}
+		}
+	}
+	return samples
+}
+
+//flacBitReader reads the bits of a FLAC frame, keeping the CRC-16 of the bytes
+//that have been read.
+type flacBitReader struct {
+	r   *bufio.Reader
+	crc uint16
+	buf uint64
+	n   uint //The number of bits in buf
+}
+
+//fill reads another byte into buf.
+func (br *flacBitReader) fill() error {
+	b, err := br.r.ReadByte()
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	} else if err != nil {
+		return err
+	}
+	br.crc = br.crc<<8 ^ flacCRC16Table[byte(br.crc>>8)^b]
+	br.buf = br.buf<<8 | uint64(b)
+	br.n += 8
+	return nil
+}
+
+//readBits reads n bits, where n is at most 33.
+func (br *flacBitReader) readBits(n uint) (uint64, error) {
+	for br.n < n {
+		if err := br.fill(); err != nil {
+			return 0, err
+		}
+	}
+	br.n -= n
+	v := br.buf >> br.n
+	br.buf &= 1<<br.n - 1
+	return v, nil
+}
+
+//readSigned reads an n bit two's complement number.
+func (br *flacBitReader) readSigned(n uint) (int64, error) {
+	if n == 0 {
+		return 0, nil
+	}
+	v, err := br.readBits(n)
+	return int64(v<<(64-n)) >> (64 - n), err
+}
+
+//readUnary reads a number coded as that many 0 bits followed by a 1 bit.
+func (br *flacBitReader) readUnary() (uint64, error) {
+	var q uint64
+	for {
+		if br.n == 0 {
+			if err := br.fill(); err != nil {
+				return 0, err
+			}
+		}
+		if br.buf == 0 {
+			q += uint64(br.n)
+			br.n = 0
+			continue
+		}
+		zeros := uint(bits.LeadingZeros64(br.buf)) - (64 - br.n)
+		q += uint64(zeros)
+		br.n -= zeros + 1
+		br.buf &= 1<<br.n - 1
+		return q, nil
+	}
+}
+
+//alignToByte skips the bits up to the next byte boundary.
+func (br *flacBitReader) alignToByte() {
+	br.n -= br.n % 8
+	br.buf &= 1<<br.n - 1
+}
+
+//flacFixedCoefficients are the coefficients of the predictors of fixed
+//subframes of each order.
+var flacFixedCoefficients = [][]int64{
+	{},
+	{1},
+	{2, -1},
+	{3, -3, 1},
+	{4, -6, 4, -1},
+}
+
+//readSubframe decodes a subframe of blockSize samples of sampleSize bits.
+//https://xiph.org/flac/format.html#subframe
+func (br *flacBitReader) readSubframe(blockSize int, sampleSize int) ([]int64, error) {
+	header, err := br.readBits(8)
+	if err != nil {
+		return nil, err
+	}
+	if header&0x80 != 0 {
+		return nil, errors.New("invalid FLAC subframe header: padding bit set")
+	}
+	subframeType := int(header>>1) & 0x3F
+	wasted := uint(0)
+	if header&0x01 != 0 {
+		w, err := br.readUnary()
+		if err != nil {
+			return nil, err
+		}
+		wasted = uint(w) + 1
+		if int(wasted) >= sampleSize {
+			return nil, errors.New("invalid FLAC subframe header: too many wasted bits")
+		}
+	}
+	n := uint(sampleSize) - wasted
+	samples := make([]int64, blockSize)
+	switch {
+	case subframeType == 0:
+		//Constant
+		v, err := br.readSigned(n)
+		if err != nil {
+			return nil, err
+		}
+		for i := range samples {
+			samples[i] = v
+		}
+	case subframeType == 1:
+		//Verbatim
+		for i := range samples {
+			samples[i], err = br.readSigned(n)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case subframeType >= 8 && subframeType <= 12:
+		order := subframeType - 8
+		if err := br.readPredicted(samples, order, n, flacFixedCoefficients[order], 0); err != nil {
+			return nil, err
+		}
+	case subframeType >= 32:
+		order := subframeType - 31
+		if err := br.readLPCSubframe(samples, order, n); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid FLAC subframe type: %d", subframeType)
+	}
+	if wasted > 0 {
+		for i := range samples {
+			samples[i] <<= wasted
+		}
+	}
+	return samples, nil
+}
+
+//readLPCSubframe decodes the rest of an LPC subframe, after its header.
+func (br *flacBitReader) readLPCSubframe(samples []int64, order int, sampleSize uint) error {
+	if order > len(samples) {
+		return errors.New("invalid FLAC subframe: predictor order larger than block size")
+	}
+	//The warm-up samples come before the precision and coefficients
+	for i := 0; i < order; i++ {
+		v, err := br.readSigned(sampleSize)
+		if err != nil {
+			return err
+		}
+		samples[i] = v
+	}
+	p, err := br.readBits(4)
+	if err != nil {
+		return err
+	}
+	if p == 15 {
+		return errors.New("invalid FLAC subframe: invalid coefficient precision")
+	}
+	shift, err := br.readSigned(5)
+	if err != nil {
+		return err
+	}
+	if shift < 0 {
+		return errors.New("invalid FLAC subframe: negative LPC shift")
+	}
+	coefficients := make([]int64, order)
+	for i := range coefficients {
+		coefficients[i], err = br.readSigned(uint(p) + 1)
+		if err != nil {
+			return err
+		}
+	}
+	return br.readResidual(samples, order, coefficients, uint(shift))
+}
+
+//readPredicted decodes the warm-up samples and residual of a fixed subframe.
+func (br *flacBitReader) readPredicted(samples []int64, order int, sampleSize uint, coefficients []int64, shift uint) error {
+	if order > len(samples) {
+		return errors.New("invalid FLAC subframe: predictor order larger than block size")
+	}
+	for i := 0; i < order; i++ {
+		v, err := br.readSigned(sampleSize)
+		if err != nil {
+			return err
+		}
+		samples[i] = v
+	}
+	return br.readResidual(samples, order, coefficients, shift)
+}
+
+//readResidual decodes the Rice coded residual that follows the warm-up samples
+//of a predicted subframe, and adds the prediction from the previous samples to
+//each one.
+//https://xiph.org/flac/format.html#residual
+func (br *flacBitReader) readResidual(samples []int64, order int, coefficients []int64, shift uint) error {
+	method, err := br.readBits(2)
+	if err != nil {
+		return err
+	}
+	if method > 1 {
+		return fmt.Errorf("invalid FLAC residual coding method: %d", method)
+	}
+	paramBits, escape := uint(4), uint64(15)
+	if method == 1 {
+		paramBits, escape = 5, 31
+	}
+	partitionOrder, err := br.readBits(4)
+	if err != nil {
+		return err
+	}
+	partitions := 1 << partitionOrder
+	partitionSize := len(samples) >> partitionOrder
+	if partitionSize<<partitionOrder != len(samples) || partitionSize < order {
+		return errors.New("invalid FLAC residual: invalid partition order")
+	}
+	i := order
+	for p := 0; p < partitions; p++ {
+		end := (p + 1) * partitionSize
+		param, err := br.readBits(paramBits)
+		if err != nil {
+			return err
+		}
+		if param == escape {
+			//The residual is stored unencoded with this many bits per sample
+			n, err := br.readBits(5)
+			if err != nil {
+				return err
+			}
+			for ; i < end; i++ {
+				samples[i], err = br.readSigned(uint(n))
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		for ; i < end; i++ {
+			q, err := br.readUnary()
+			if err != nil {
+				return err
+			}
+			r, err := br.readBits(uint(param))
+			if err != nil {
+				return err
+			}
+			u := q<<param | r
+			samples[i] = int64(u>>1) ^ -int64(u&1)
+		}
+	}
+	for i := order; i < len(samples); i++ {
+		var prediction int64
+		for j, c := range coefficients {
+			prediction += c * samples[i-1-j]
+		}
+		samples[i] += prediction >> shift
+	}
+	return nil
+}
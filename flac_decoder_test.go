@@ -0,0 +1,192 @@
+package yurit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// flacTestBitWriter writes the bits of a FLAC frame for the decoder tests.
+type flacTestBitWriter struct {
+	b []byte
+	n uint //The number of bits used in the last byte
+}
+
+func (w *flacTestBitWriter) write(v int64, n uint) {
+	for i := int(n) - 1; i >= 0; i-- {
+		if w.n%8 == 0 {
+			w.b = append(w.b, 0)
+			w.n = 0
+		}
+		if v>>uint(i)&1 == 1 {
+			w.b[len(w.b)-1] |= 0x80 >> w.n
+		}
+		w.n++
+	}
+}
+
+func (w *flacTestBitWriter) writeRice(v int64, k uint) {
+	u := uint64(v<<1) ^ uint64(v>>63)
+	for q := u >> k; q > 0; q-- {
+		w.write(0, 1)
+	}
+	w.write(1, 1)
+	w.write(int64(u), k)
+}
+
+// flacTestFrame returns a 16 bit frame of 8 samples with the given channel
+// assignment and subframes, which are written by the functions in subframes.
+func flacTestFrame(number byte, channelAssignment byte, subframes ...func(w *flacTestBitWriter)) []byte {
+	header := []byte{0xFF, 0xF8, 0x60, channelAssignment<<4 | 4<<1, number, 7}
+	w := &flacTestBitWriter{b: append(header, flacCRC8(header))}
+	for _, s := range subframes {
+		s(w)
+	}
+	crc := updateFLACCRC16(0, w.b)
+	return append(w.b, byte(crc>>8), byte(crc))
+}
+
+func verbatimSubframe(samples []int64, n uint) func(w *flacTestBitWriter) {
+	return func(w *flacTestBitWriter) {
+		w.write(0x02, 8)
+		for _, s := range samples {
+			w.write(s, n)
+		}
+	}
+}
+
+func TestFLACDecoder(t *testing.T) {
+	left := []int64{0, 1, -1, 1000, -1000, 32767, -32768, 5}
+	right := []int64{7, -7, 100, -100, 32767, -32768, 0, 5}
+	diff := func(a, b []int64) []int64 {
+		d := make([]int64, len(a))
+		for i := range a {
+			d[i] = a[i] - b[i]
+		}
+		return d
+	}
+	mid := make([]int64, len(left))
+	for i := range left {
+		mid[i] = (left[i] + right[i]) >> 1
+	}
+	ramp := []int64{100, 103, 106, 109, 105, 101, 97, 93}
+
+	var (
+		data     []byte
+		expected [][][]int32
+	)
+	add := func(frame []byte, samples ...[]int64) {
+		data = append(data, frame...)
+		var channels [][]int32
+		for _, c := range samples {
+			s := make([]int32, len(c))
+			for i := range c {
+				s[i] = int32(c[i])
+			}
+			channels = append(channels, s)
+		}
+		expected = append(expected, channels)
+	}
+	//Independent, left/side, side/right and mid/side stereo
+	add(flacTestFrame(0, 1, verbatimSubframe(left, 16), verbatimSubframe(right, 16)), left, right)
+	add(flacTestFrame(1, 8, verbatimSubframe(left, 16), verbatimSubframe(diff(left, right), 17)), left, right)
+	add(flacTestFrame(2, 9, verbatimSubframe(diff(left, right), 17), verbatimSubframe(right, 16)), left, right)
+	add(flacTestFrame(3, 10, verbatimSubframe(mid, 16), verbatimSubframe(diff(left, right), 17)), left, right)
+	//A constant subframe and a verbatim subframe with 2 wasted bits
+	add(flacTestFrame(4, 1, func(w *flacTestBitWriter) {
+		w.write(0x00, 8)
+		w.write(-1234, 16)
+	}, func(w *flacTestBitWriter) {
+		w.write(0x03, 8)
+		w.write(1, 2)
+		for _, s := range []int64{4, -4, 8, -8, 400, -400, 0, 32764} {
+			w.write(s>>2, 14)
+		}
+	}), []int64{-1234, -1234, -1234, -1234, -1234, -1234, -1234, -1234}, []int64{4, -4, 8, -8, 400, -400, 0, 32764})
+	//A fixed subframe of order 2 with a Rice coded partition and an escaped
+	//partition, and an LPC subframe of order 1 predicting the previous sample
+	add(flacTestFrame(5, 1, func(w *flacTestBitWriter) {
+		w.write(10<<1, 8)
+		w.write(ramp[0], 16)
+		w.write(ramp[1], 16)
+		w.write(0, 2)
+		w.write(1, 4)
+		w.write(2, 4)
+		for i := 2; i < 4; i++ {
+			w.writeRice(ramp[i]-(2*ramp[i-1]-ramp[i-2]), 2)
+		}
+		w.write(15, 4)
+		w.write(5, 5)
+		for i := 4; i < 8; i++ {
+			w.write(ramp[i]-(2*ramp[i-1]-ramp[i-2]), 5)
+		}
+	}, func(w *flacTestBitWriter) {
+		w.write(32<<1, 8)
+		w.write(ramp[0], 16)
+		w.write(3, 4)
+		w.write(1, 5)
+		w.write(2, 4)
+		w.write(1, 2)
+		w.write(0, 4)
+		w.write(3, 5)
+		for i := 1; i < 8; i++ {
+			w.writeRice(ramp[i]-ramp[i-1], 3)
+		}
+	}), ramp, ramp)
+
+	d := &FLACDecoder{br: &flacBitReader{r: bufio.NewReader(bytes.NewReader(data))}, sampleSize: 16}
+	for ii, e := range expected {
+		samples, err := d.Next()
+		if err != nil {
+			t.Fatalf("[%d] Next() returned error: %v", ii, err)
+		}
+		if !reflect.DeepEqual(samples, e) {
+			t.Errorf("[%d] Next() = %v, expected %v", ii, samples, e)
+		}
+	}
+	if _, err := d.Next(); err != io.EOF {
+		t.Errorf("Next() after the last frame returned %v, expected %v", err, io.EOF)
+	}
+
+	h := md5.New()
+	for _, channels := range expected {
+		for i := range channels[0] {
+			for _, c := range channels {
+				binary.Write(h, binary.LittleEndian, int16(c[i]))
+			}
+		}
+	}
+	m := FLACMetadata{streamInfo: flacStreamInfo{SampleSizeKey: byte(16), MD5Key: h.Sum(nil)}}
+	if ok, err := m.VerifyMD5(bytes.NewReader(data)); !ok || err != nil {
+		t.Errorf("VerifyMD5() = %v, %v, expected true", ok, err)
+	}
+	corrupt := append([]byte{}, data...)
+	corrupt[20] ^= 0x01
+	if _, err := m.VerifyMD5(bytes.NewReader(corrupt)); err == nil {
+		t.Errorf("VerifyMD5() of corrupt audio expected error")
+	}
+	m.streamInfo[MD5Key] = make([]byte, md5.Size)
+	if _, err := m.VerifyMD5(bytes.NewReader(data)); err == nil {
+		t.Errorf("VerifyMD5() without a signature expected error")
+	}
+}
+
+func TestFLACVerifyMD5File(t *testing.T) {
+	f, err := os.Open("./testdata/with_tags/sample.flac")
+	if err != nil {
+		t.Fatalf("error opening file: %v", err)
+	}
+	defer f.Close()
+	m, err := ReadFLACTags(f)
+	if err != nil {
+		t.Fatalf("ReadFLACTags() returned error: %v", err)
+	}
+	if ok, err := m.VerifyMD5(f); !ok || err != nil {
+		t.Errorf("VerifyMD5() = %v, %v, expected true", ok, err)
+	}
+}
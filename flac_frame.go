@@ -0,0 +1,207 @@
+package yurit
+
+import (
+	"errors"
+	"fmt"
+)
+
+//flacFrameHeader is the header of a FLAC audio frame. Number is the frame
+//number for fixed block size streams and the number of the first sample for
+//variable block size streams. A sample rate or sample size of 0 means that the
+//value from STREAMINFO applies.
+//https://xiph.org/flac/format.html#frame_header
+type flacFrameHeader struct {
+	variableBlockSize bool
+	blockSize         int
+	sampleRate        int
+	channelAssignment byte
+	sampleSize        int
+	number            int64
+	//size is the length of the header in bytes including its CRC-8
+	size int
+	//crcOK is whether the CRC-8 of the header matches
+	crcOK bool
+}
+
+//flacMaxFrameHeaderSize is the size of the largest possible frame header: 4
+//fixed bytes, a 7 byte coded number, 2 bytes each of block size and sample rate
+//and the CRC-8.
+const flacMaxFrameHeaderSize = 16
+
+//isFLACFrameSync returns whether b starts with the 14 bit FLAC frame sync code
+//followed by the reserved 0 bit.
+func isFLACFrameSync(b []byte) bool {
+	return len(b) >= 2 && b[0] == 0xFF && b[1]&0xFE == 0xF8
+}
+
+//parseFLACFrameHeader parses the frame header at the start of b. An error is
+//returned if b doesn't hold a whole frame header or the header uses reserved
+//values, but a header with a CRC-8 that doesn't match is still returned.
+func parseFLACFrameHeader(b []byte) (*flacFrameHeader, error) {
+	if len(b) < 6 {
+		return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", 6, len(b))
+	}
+	if !isFLACFrameSync(b) {
+		return nil, errors.New("expected FLAC frame sync code")
+	}
+	h := &flacFrameHeader{variableBlockSize: b[1]&0x01 == 1}
+	blockSizeCode := b[2] >> 4
+	sampleRateCode := b[2] & 0x0F
+	h.channelAssignment = b[3] >> 4
+	sampleSizeCode := (b[3] >> 1) & 0x07
+	if blockSizeCode == 0 || sampleRateCode == 15 || h.channelAssignment > 10 || sampleSizeCode == 3 || b[3]&0x01 != 0 {
+		return nil, errors.New("invalid FLAC frame header: reserved value")
+	}
+	h.sampleSize = []int{0, 8, 12, 0, 16, 20, 24, 32}[sampleSizeCode]
+	number, n, err := decodeFLACCodedNumber(b[4:])
+	if err != nil {
+		return nil, err
+	}
+	h.number = number
+	offset := 4 + n
+	//The block size and sample rate may follow at the end of the header
+	extra := 0
+	switch blockSizeCode {
+	case 6:
+		extra++
+	case 7:
+		extra += 2
+	}
+	switch sampleRateCode {
+	case 12:
+		extra++
+	case 13, 14:
+		extra += 2
+	}
+	if len(b) < offset+extra+1 {
+		return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", offset+extra+1, len(b))
+	}
+	switch {
+	case blockSizeCode == 1:
+		h.blockSize = 192
+	case blockSizeCode <= 5:
+		h.blockSize = 576 << (blockSizeCode - 2)
+	case blockSizeCode == 6:
+		h.blockSize = int(b[offset]) + 1
+		offset++
+	case blockSizeCode == 7:
+		h.blockSize = getUint16AsInt(b[offset:offset+2]) + 1
+		offset += 2
+	default:
+		h.blockSize = 256 << (blockSizeCode - 8)
+	}
+	switch {
+	case sampleRateCode == 0:
+		h.sampleRate = 0
+	case sampleRateCode <= 11:
+		h.sampleRate = []int{0, 88200, 176400, 192000, 8000, 16000, 22050, 24000, 32000, 44100, 48000, 96000}[sampleRateCode]
+	case sampleRateCode == 12:
+		h.sampleRate = int(b[offset]) * 1000
+		offset++
+	case sampleRateCode == 13:
+		h.sampleRate = getUint16AsInt(b[offset : offset+2])
+		offset += 2
+	case sampleRateCode == 14:
+		h.sampleRate = getUint16AsInt(b[offset:offset+2]) * 10
+		offset += 2
+	}
+	h.crcOK = flacCRC8(b[:offset]) == b[offset]
+	h.size = offset + 1
+	return h, nil
+}
+
+//decodeFLACCodedNumber decodes the frame or sample number of a frame header,
+//which is coded like a UTF-8 character but may be up to 36 bits long. It
+//returns the number and its length in bytes.
+func decodeFLACCodedNumber(b []byte) (int64, int, error) {
+	if len(b) == 0 {
+		return 0, 0, errors.New("invalid encoding: expected coded number")
+	}
+	//The number of leading 1 bits of the first byte is the length
+	n := 0
+	for n < 8 && getBit(b[0], uint(7-n)) {
+		n++
+	}
+	if n == 0 {
+		return int64(b[0]), 1, nil
+	}
+	if n == 1 || n > 7 {
+		return 0, 0, errors.New("invalid FLAC frame header: invalid coded number")
+	}
+	if len(b) < n {
+		return 0, 0, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", n, len(b))
+	}
+	v := int64(b[0] & (0x7F >> uint(n)))
+	for _, c := range b[1:n] {
+		if c&0xC0 != 0x80 {
+			return 0, 0, errors.New("invalid FLAC frame header: invalid coded number")
+		}
+		v = v<<6 | int64(c&0x3F)
+	}
+	return v, n, nil
+}
+
+//channels returns the number of channels given by the channel assignment.
+func (h flacFrameHeader) channels() int {
+	if h.channelAssignment <= 7 {
+		return int(h.channelAssignment) + 1
+	}
+	//Left/side, right/side and mid/side stereo
+	return 2
+}
+
+//flacCRC8Table and flacCRC16Table are lookup tables for the CRCs of FLAC frames,
+//which use the polynomials x^8 + x^2 + x + 1 and x^16 + x^15 + x^2 + 1.
+var (
+	flacCRC8Table  = makeFLACCRC8Table()
+	flacCRC16Table = makeFLACCRC16Table()
+)
+
+func makeFLACCRC8Table() [256]byte {
+	var t [256]byte
+	for i := range t {
+		crc := byte(i)
+		for j := 0; j < 8; j++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+		t[i] = crc
+	}
+	return t
+}
+
+func makeFLACCRC16Table() [256]uint16 {
+	var t [256]uint16
+	for i := range t {
+		crc := uint16(i) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x8005
+			} else {
+				crc <<= 1
+			}
+		}
+		t[i] = crc
+	}
+	return t
+}
+
+//flacCRC8 returns the CRC-8 of a frame header.
+func flacCRC8(b []byte) byte {
+	var crc byte
+	for _, c := range b {
+		crc = flacCRC8Table[crc^c]
+	}
+	return crc
+}
+
+//updateFLACCRC16 updates the CRC-16 of a frame with the bytes of b.
+func updateFLACCRC16(crc uint16, b []byte) uint16 {
+	for _, c := range b {
+		crc = crc<<8 ^ flacCRC16Table[byte(crc>>8)^c]
+	}
+	return crc
+}
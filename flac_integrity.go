@@ -0,0 +1,254 @@
+package yurit
+
+import (
+	"io"
+)
+
+//FLACIntegrityReport is the result of checking every frame of a FLAC file. The
+//audio is free of errors when there are no issues.
+type FLACIntegrityReport struct {
+	Frames  int
+	Samples int64
+	//ExpectedSamples is the total number of samples from STREAMINFO, where 0
+	//means that it is unknown
+	ExpectedSamples int64
+	Issues          []FLACIntegrityIssue
+}
+
+//FLACIntegrityIssue is a problem found in the audio of a FLAC file. Offset is
+//from the beginning of the file, and Size is the number of bytes affected.
+type FLACIntegrityIssue struct {
+	Type   FLACIntegrityIssueType
+	Offset int64
+	Size   int64
+}
+
+//FLACIntegrityIssueType is the kind of problem found in the audio of a FLAC
+//file
+type FLACIntegrityIssueType string
+
+//All possible integrity issue types
+const (
+	//Bytes where a frame was expected that aren't the start of a frame
+	FLACIssueLostSync FLACIntegrityIssueType = "Lost sync"
+	//The CRC-8 of a frame header doesn't match the header
+	FLACIssueHeaderCRCMismatch FLACIntegrityIssueType = "Header CRC mismatch"
+	//The CRC-16 at the end of a frame doesn't match the frame
+	FLACIssueCRCMismatch FLACIntegrityIssueType = "CRC mismatch"
+	//The frame or sample number of a frame doesn't follow on from the frame
+	//before it, with a size of 0
+	FLACIssueDiscontinuity FLACIntegrityIssueType = "Discontinuity"
+	//The number of samples in the frames isn't the total number of samples
+	//from STREAMINFO, with an offset of the end of the file and a size of 0
+	FLACIssueSampleCountMismatch FLACIntegrityIssueType = "Sample count mismatch"
+)
+
+//OK returns whether no issues were found.
+func (r FLACIntegrityReport) OK() bool {
+	return len(r.Issues) == 0
+}
+
+//CheckIntegrity reads every frame of the audio in r, the file that m was read
+//from, checking the CRCs of each frame header and frame, that the frame or
+//sample numbers follow on from each other and that the frames hold the number
+//of samples given by STREAMINFO. The audio isn't decoded, so the end of each
+//frame is found by looking for the next frame header after which the CRC-16
+//matches.
+func (m FLACMetadata) CheckIntegrity(r io.ReadSeeker) (*FLACIntegrityReport, error) {
+	_, err := r.Seek(m.metadataSize, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	return checkFLACIntegrity(r, m.metadataSize, m.streamInfo)
+}
+
+//checkFLACIntegrity checks the frames of r from its current position, which is
+//at start in the file, until the end of r.
+func checkFLACIntegrity(r io.Reader, start int64, si flacStreamInfo) (*FLACIntegrityReport, error) {
+	a := &flacAudioReader{r: r, start: start}
+	report := &FLACIntegrityReport{ExpectedSamples: si.TotalSamples()}
+	maxFrameSize, _ := si[MaximumFrameSizeKey].(int)
+	addIssue := func(t FLACIntegrityIssueType, offset int64, size int64) {
+		report.Issues = append(report.Issues, FLACIntegrityIssue{Type: t, Offset: offset, Size: size})
+	}
+	var (
+		offset = start
+		//next is the expected frame or sample number of the next frame
+		next     int64
+		variable bool
+	)
+	for {
+		b, err := a.bytes(offset, flacMaxFrameHeaderSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) == 0 {
+			break
+		}
+		h, err := parseFLACFrameHeader(b)
+		if err != nil {
+			//Look for the next frame
+			headerOffset, nh, err := a.findFrameHeader(offset+1, variable, report.Frames > 0)
+			if err != nil {
+				return nil, err
+			}
+			addIssue(FLACIssueLostSync, offset, headerOffset-offset)
+			offset = headerOffset
+			if nh == nil {
+				break
+			}
+			h = nh
+		} else if !h.crcOK {
+			addIssue(FLACIssueHeaderCRCMismatch, offset, int64(h.size))
+			//The number can't be trusted, so assume that it is the expected one
+			if report.Frames > 0 {
+				h.number = next
+			}
+		}
+		if report.Frames == 0 {
+			variable = h.variableBlockSize
+			next = h.number
+		}
+		if h.variableBlockSize != variable || h.number != next {
+			addIssue(FLACIssueDiscontinuity, offset, 0)
+		}
+		if h.variableBlockSize {
+			next = h.number + int64(h.blockSize)
+		} else {
+			next = h.number + 1
+		}
+		end, crcOK, err := a.findFrameEnd(offset, h, next, maxFrameSize)
+		if err != nil {
+			return nil, err
+		}
+		if !crcOK {
+			addIssue(FLACIssueCRCMismatch, offset, end-offset)
+		}
+		report.Frames++
+		report.Samples += int64(h.blockSize)
+		a.discard(end)
+		offset = end
+	}
+	if report.ExpectedSamples > 0 && report.Samples != report.ExpectedSamples {
+		addIssue(FLACIssueSampleCountMismatch, offset, 0)
+	}
+	return report, nil
+}
+
+//flacAudioReader gives access to a window of the audio of a FLAC file, reading
+//it from r as it is needed and dropping what is no longer needed.
+type flacAudioReader struct {
+	r     io.Reader
+	buf   []byte
+	start int64 //The offset in the file of buf[0]
+	eof   bool
+}
+
+//bytes returns up to n bytes from offset, which are fewer at the end of the
+//file.
+func (a *flacAudioReader) bytes(offset int64, n int) ([]byte, error) {
+	for !a.eof && a.start+int64(len(a.buf)) < offset+int64(n) {
+		l := len(a.buf)
+		a.buf = append(a.buf, make([]byte, 1<<16)...)
+		k, err := io.ReadFull(a.r, a.buf[l:])
+		a.buf = a.buf[:l+k]
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			a.eof = true
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	i := offset - a.start
+	if i >= int64(len(a.buf)) {
+		return nil, nil
+	}
+	j := i + int64(n)
+	if j > int64(len(a.buf)) {
+		j = int64(len(a.buf))
+	}
+	return a.buf[i:j], nil
+}
+
+//discard drops the bytes before offset.
+func (a *flacAudioReader) discard(offset int64) {
+	if i := offset - a.start; i > 0 && i <= int64(len(a.buf)) {
+		a.buf = a.buf[i:]
+		a.start = offset
+	}
+}
+
+//findFrameHeader returns the offset of the first frame header at or after
+//offset with a matching CRC-8, or the offset of the end of the file and a nil
+//header if there is none. Once the blocking strategy is known, only headers
+//using it are accepted.
+func (a *flacAudioReader) findFrameHeader(offset int64, variable bool, known bool) (int64, *flacFrameHeader, error) {
+	for {
+		b, err := a.bytes(offset, flacMaxFrameHeaderSize)
+		if err != nil {
+			return 0, nil, err
+		}
+		if len(b) == 0 {
+			return offset, nil, nil
+		}
+		if isFLACFrameSync(b) {
+			h, err := parseFLACFrameHeader(b)
+			if err == nil && h.crcOK && (!known || h.variableBlockSize == variable) {
+				return offset, h, nil
+			}
+		}
+		offset++
+	}
+}
+
+//findFrameEnd returns the offset of the end of the frame at offset with header
+//h, and whether the CRC-16 of the frame matches. The frame ends at the first
+//frame sync code or the end of the file where the preceding 2 bytes match the
+//CRC-16 of the frame. If the CRC-16 never matches, the frame is taken to end at
+//the next frame header with the expected number next, or once past the maximum
+//frame size at the next frame header with a matching CRC-8. In that case, if
+//the CRC-16 matched somewhere before that header, the frame is taken to end
+//there and be followed by junk.
+func (a *flacAudioReader) findFrameEnd(offset int64, h *flacFrameHeader, next int64, maxFrameSize int) (int64, bool, error) {
+	b, err := a.bytes(offset, h.size)
+	if err != nil {
+		return 0, false, err
+	}
+	crc := updateFLACCRC16(0, b)
+	lastMatch := int64(-1)
+	//p is a possible end of the frame, and crc covers everything up to the
+	//2 bytes before it
+	for p := offset + int64(h.size) + 2; ; p++ {
+		b, err := a.bytes(p-2, 2+flacMaxFrameHeaderSize)
+		if err != nil {
+			return 0, false, err
+		}
+		if len(b) < 2 {
+			//The end of the file is in the middle of the frame
+			return p - 2 + int64(len(b)), false, nil
+		}
+		footer := uint16(getUint16AsInt(b[0:2]))
+		if len(b) == 2 {
+			if crc != footer && lastMatch > 0 {
+				return lastMatch, true, nil
+			}
+			return p, crc == footer, nil
+		}
+		if isFLACFrameSync(b[2:]) {
+			if crc == footer {
+				return p, true, nil
+			}
+			nh, err := parseFLACFrameHeader(b[2:])
+			if err == nil && nh.crcOK && nh.variableBlockSize == h.variableBlockSize {
+				if nh.number == next || (maxFrameSize > 0 && p-offset > int64(maxFrameSize)) {
+					if lastMatch > 0 {
+						return lastMatch, true, nil
+					}
+					return p, false, nil
+				}
+			}
+		} else if crc == footer {
+			lastMatch = p
+		}
+		crc = updateFLACCRC16(crc, b[0:1])
+	}
+}
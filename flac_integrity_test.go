@@ -0,0 +1,114 @@
+package yurit
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestFLACCRCs(t *testing.T) {
+	if crc := flacCRC8([]byte("123456789")); crc != 0xF4 {
+		t.Errorf("flacCRC8() = %#x, expected %#x", crc, 0xF4)
+	}
+	if crc := updateFLACCRC16(0, []byte("123456789")); crc != 0xFEE8 {
+		t.Errorf("updateFLACCRC16() = %#x, expected %#x", crc, 0xFEE8)
+	}
+}
+
+func TestDecodeFLACCodedNumber(t *testing.T) {
+	tests := []struct {
+		data       []byte
+		number     int64
+		size       int
+		makesError bool
+	}{
+		{[]byte{0x00}, 0, 1, false},
+		{[]byte{0x7F}, 127, 1, false},
+		{[]byte{0xC2, 0x80}, 128, 2, false},
+		{[]byte{0xE0, 0xA0, 0x80}, 2048, 3, false},
+		{[]byte{0xFE, 0xBF, 0xBF, 0xBF, 0xBF, 0xBF, 0xBF}, 1<<36 - 1, 7, false},
+		{[]byte{0x80}, 0, 0, true},
+		{[]byte{0xC2, 0x00}, 0, 0, true},
+		{[]byte{0xE0, 0xA0}, 0, 0, true},
+	}
+	for ii, tt := range tests {
+		number, size, err := decodeFLACCodedNumber(tt.data)
+		if (err != nil) != tt.makesError {
+			t.Errorf("[%d] decodeFLACCodedNumber(%v) expected error: %v - Error was: %v", ii, tt.data, tt.makesError, err)
+			continue
+		}
+		if number != tt.number || size != tt.size {
+			t.Errorf("[%d] decodeFLACCodedNumber(%v) = %d, %d, expected %d, %d", ii, tt.data, number, size, tt.number, tt.size)
+		}
+	}
+}
+
+func TestFLACCheckIntegrity(t *testing.T) {
+	f, err := os.Open("./testdata/without_tags/sample.flac")
+	if err != nil {
+		t.Fatalf("error opening file: %v", err)
+	}
+	defer f.Close()
+	m, err := ReadFLACTags(f)
+	if err != nil {
+		t.Fatalf("ReadFLACTags() returned error: %v", err)
+	}
+	b, err := ioutil.ReadFile("./testdata/without_tags/sample.flac")
+	if err != nil {
+		t.Fatalf("error reading file: %v", err)
+	}
+	//The frames of the file used here start at these offsets
+	const (
+		frame3 = 13188
+		frame4 = 15144
+		frame5 = 16779
+		frame6 = 18555
+	)
+	corrupt := func(offset int, mask byte) []byte {
+		c := append([]byte{}, b...)
+		c[offset] ^= mask
+		return c
+	}
+	join := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+
+	tests := []struct {
+		data    []byte
+		frames  int
+		samples int64
+		issues  []FLACIntegrityIssue
+	}{
+		{b, 33, 37478, nil},
+		{corrupt(frame3+100, 0xFF), 33, 37478, []FLACIntegrityIssue{{FLACIssueCRCMismatch, frame3, frame4 - frame3}}},
+		//The frame number is part of the header
+		{corrupt(frame3+4, 0x01), 33, 37478, []FLACIntegrityIssue{
+			{FLACIssueHeaderCRCMismatch, frame3, 8},
+			{FLACIssueCRCMismatch, frame3, frame4 - frame3},
+		}},
+		{join(b[:frame5], []byte("junk"), b[frame5:]), 33, 37478, []FLACIntegrityIssue{{FLACIssueLostSync, frame5, 4}}},
+		{join(b[:frame5], b[frame6:]), 32, 37478 - 1152, []FLACIntegrityIssue{
+			{FLACIssueDiscontinuity, frame5, 0},
+			{FLACIssueSampleCountMismatch, int64(len(b) - (frame6 - frame5)), 0},
+		}},
+		{b[:len(b)-100], 33, 37478, []FLACIntegrityIssue{{FLACIssueCRCMismatch, 67784, int64(len(b) - 100 - 67784)}}},
+	}
+	for ii, tt := range tests {
+		report, err := m.CheckIntegrity(bytes.NewReader(tt.data))
+		if err != nil {
+			t.Errorf("[%d] CheckIntegrity() returned error: %v", ii, err)
+			continue
+		}
+		if report.Frames != tt.frames || report.Samples != tt.samples || report.ExpectedSamples != 37478 {
+			t.Errorf("[%d] CheckIntegrity() found %d frames and %d of %d samples, expected %d frames and %d of %d samples", ii, report.Frames, report.Samples, report.ExpectedSamples, tt.frames, tt.samples, 37478)
+		}
+		if !reflect.DeepEqual(report.Issues, tt.issues) {
+			t.Errorf("[%d] CheckIntegrity() issues = %v, expected %v", ii, report.Issues, tt.issues)
+		}
+		if report.OK() != (tt.issues == nil) {
+			t.Errorf("[%d] OK() = %v, expected %v", ii, report.OK(), tt.issues == nil)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package yurit
+
+import (
+	"fmt"
+	"sort"
+)
+
+//flacSeekPointPlaceholder is the sample number of a placeholder seek point,
+//which is reserved space in the seek table rather than a real seek point.
+const flacSeekPointPlaceholder = 0xFFFFFFFFFFFFFFFF
+
+//FLACSeekPoint is a seek point from a FLAC file's SEEKTABLE metadata block.
+//Offset is the offset in bytes of the target frame from the first frame, and
+//Samples is the number of samples in the target frame.
+//https://xiph.org/flac/format.html#seekpoint
+type FLACSeekPoint struct {
+	SampleNumber int64
+	Offset       int64
+	Samples      int
+}
+
+//processSeekTableBlock reads the seek points of a SEEKTABLE metadata block.
+//Placeholder seek points are left out.
+//https://xiph.org/flac/format.html#metadata_block_seektable
+func processSeekTableBlock(b []byte) ([]FLACSeekPoint, error) {
+	if len(b)%18 != 0 {
+		return nil, fmt.Errorf("invalid seek table: %d bytes is not a multiple of 18", len(b))
+	}
+	var seekPoints []FLACSeekPoint
+	for offset := 0; offset < len(b); offset += 18 {
+		sampleNumber := getUint64(b[offset : offset+8])
+		if sampleNumber == flacSeekPointPlaceholder {
+			continue
+		}
+		seekPoints = append(seekPoints, FLACSeekPoint{
+			SampleNumber: int64(sampleNumber),
+			Offset:       getInt64(b[offset+8 : offset+16]),
+			Samples:      getUint16AsInt(b[offset+16 : offset+18]),
+		})
+	}
+	return seekPoints, nil
+}
+
+//OffsetForSample returns the byte offset in the file of the frame that is the
+//closest one at or before sample n, according to the seek table, along with
+//the number of the first sample in that frame. Without a seek point before n
+//the first frame, which starts right after the metadata, is returned. Decoding
+//from the returned offset and skipping n minus the returned sample number
+//samples gives sample-accurate positioning.
+func (m FLACMetadata) OffsetForSample(n int64) (int64, int64, error) {
+	if n < 0 {
+		return 0, 0, fmt.Errorf("invalid sample number: %d", n)
+	}
+	if total := m.streamInfo.TotalSamples(); total > 0 && n >= total {
+		return 0, 0, fmt.Errorf("invalid sample number: %d, expected less than %d", n, total)
+	}
+	//Seek points are sorted by sample number, so find the first one after n
+	i := sort.Search(len(m.seekPoints), func(i int) bool {
+		return m.seekPoints[i].SampleNumber > n
+	})
+	if i == 0 {
+		return m.metadataSize, 0, nil
+	}
+	sp := m.seekPoints[i-1]
+	return m.metadataSize + sp.Offset, sp.SampleNumber, nil
+}
+
+//SeekPoints returns the seek points from a FLAC file's SEEKTABLE metadata
+//block, or nil if there is none.
+func (m FLACMetadata) SeekPoints() []FLACSeekPoint {
+	return m.seekPoints
+}
@@ -0,0 +1,63 @@
+package yurit
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProcessSeekTableBlock(t *testing.T) {
+	tests := []struct {
+		data       []byte
+		seekPoints []FLACSeekPoint
+		makesError bool
+	}{
+		{nil, nil, false},
+		{[]byte{0, 0, 0, 0, 0, 0, 0x10, 0, 0, 0, 0, 0, 0, 0, 0x20, 0, 0x10, 0}, []FLACSeekPoint{{4096, 8192, 4096}}, false},
+		{[]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, nil, false},
+		{[]byte{0, 0, 0}, nil, true},
+	}
+
+	for ii, tt := range tests {
+		seekPoints, err := processSeekTableBlock(tt.data)
+		if (err != nil) != tt.makesError {
+			t.Errorf("[%d] processSeekTableBlock(%v) expected error: %v - Error was: %v", ii, tt.data, tt.makesError, err)
+			continue
+		}
+		if !reflect.DeepEqual(seekPoints, tt.seekPoints) {
+			t.Errorf("[%d] processSeekTableBlock(%v) = %v, expected %v", ii, tt.data, seekPoints, tt.seekPoints)
+		}
+	}
+}
+
+func TestFLACOffsetForSample(t *testing.T) {
+	m := FLACMetadata{
+		metadataSize: 1000,
+		streamInfo:   flacStreamInfo{TotalSamplesKey: int64(20000)},
+		seekPoints:   []FLACSeekPoint{{0, 0, 4096}, {4096, 5000, 4096}, {12288, 15000, 4096}},
+	}
+	tests := []struct {
+		n          int64
+		offset     int64
+		sample     int64
+		makesError bool
+	}{
+		{0, 1000, 0, false},
+		{4095, 1000, 0, false},
+		{4096, 6000, 4096, false},
+		{12000, 6000, 4096, false},
+		{19999, 16000, 12288, false},
+		{20000, 0, 0, true},
+		{-1, 0, 0, true},
+	}
+
+	for ii, tt := range tests {
+		offset, sample, err := m.OffsetForSample(tt.n)
+		if (err != nil) != tt.makesError {
+			t.Errorf("[%d] OffsetForSample(%d) expected error: %v - Error was: %v", ii, tt.n, tt.makesError, err)
+			continue
+		}
+		if offset != tt.offset || sample != tt.sample {
+			t.Errorf("[%d] OffsetForSample(%d) = %d, %d, expected %d, %d", ii, tt.n, offset, sample, tt.offset, tt.sample)
+		}
+	}
+}
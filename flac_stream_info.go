@@ -41,3 +41,8 @@ func (si flacStreamInfo) SampleRate() int {
 	sr, _ := si[SampleRateKey].(int)
 	return sr
 }
+
+func (si flacStreamInfo) TotalSamples() int64 {
+	ts, _ := si[TotalSamplesKey].(int64)
+	return ts
+}
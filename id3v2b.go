@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -240,3 +241,62 @@ func (m id3v2Tags) Picture() *Picture {
 	}
 	return v.(*Picture)
 }
+
+//Chapters returns the chapters from the CHAP frames in the tag. If there is a
+//top-level, ordered table of contents (CTOC) then its chapters come first in
+//its order, following any nested tables of contents. Any other chapters follow,
+//sorted by start time.
+func (m id3v2Tags) Chapters() []*Chap {
+	var (
+		chaps    = map[string]*Chap{}
+		ctocs    = map[string]*Ctoc{}
+		topLevel *Ctoc
+	)
+	for _, v := range m.frames {
+		switch f := v.(type) {
+		case *Chap:
+			chaps[f.ElementID] = f
+		case *Ctoc:
+			ctocs[f.ElementID] = f
+			if f.TopLevel {
+				topLevel = f
+			}
+		}
+	}
+	var (
+		result  []*Chap
+		visited = map[string]bool{}
+		walk    func(c *Ctoc)
+	)
+	walk = func(c *Ctoc) {
+		//Guard against tables of contents that contain each other
+		if visited[c.ElementID] {
+			return
+		}
+		visited[c.ElementID] = true
+		for _, id := range c.ChildElementIDs {
+			if chap, ok := chaps[id]; ok && !visited[id] {
+				visited[id] = true
+				result = append(result, chap)
+			} else if child, ok := ctocs[id]; ok {
+				walk(child)
+			}
+		}
+	}
+	if topLevel != nil && topLevel.Ordered {
+		walk(topLevel)
+	}
+	var rest []*Chap
+	for id, chap := range chaps {
+		if !visited[id] {
+			rest = append(rest, chap)
+		}
+	}
+	sort.Slice(rest, func(i, j int) bool {
+		if rest[i].StartTime == rest[j].StartTime {
+			return rest[i].ElementID < rest[j].ElementID
+		}
+		return rest[i].StartTime < rest[j].StartTime
+	})
+	return append(result, rest...)
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 	"unicode/utf16"
 )
 
@@ -101,8 +102,10 @@ var id3v22Frames = map[string]string{
 var id3v23Frames = map[string]string{
 	"AENC": "Audio encryption]",
 	"APIC": "Attached picture",
+	"CHAP": "Chapter (see http://id3.org/id3v2-chapters-1.0)",
 	"COMM": "Comments",
 	"COMR": "Commercial frame",
+	"CTOC": "Table of contents (see http://id3.org/id3v2-chapters-1.0)",
 	"ENCR": "Encryption method registration",
 	"EQUA": "Equalization",
 	"ETCO": "Event timing codes",
@@ -184,8 +187,10 @@ var id3v24Frames = map[string]string{
 	"APIC": "Attached picture",
 	"ASPI": "Audio seek point index",
 
+	"CHAP": "Chapter (see http://id3.org/id3v2-chapters-1.0)",
 	"COMM": "Comments",
 	"COMR": "Commercial frame",
+	"CTOC": "Table of contents (see http://id3.org/id3v2-chapters-1.0)",
 
 	"ENCR": "Encryption method registration",
 	"EQU2": "Equalisation (2)",
@@ -566,6 +571,18 @@ func processID3v2Frames(b []byte, h *id3v2Header) (map[string]interface{}, error
 				return nil, err
 			}
 			result[modName] = p
+		} else if name == "CHAP" {
+			c, err := getCHAPFrame(b, h)
+			if err != nil {
+				return nil, err
+			}
+			result[modName] = c
+		} else if name == "CTOC" {
+			c, err := getCTOCFrame(b, h)
+			if err != nil {
+				return nil, err
+			}
+			result[modName] = c
 		} else {
 			result[modName] = b
 		}
@@ -929,3 +946,128 @@ func getAPICFrame(b []byte) (*Picture, error) {
 		Data:        descDataSplit[1],
 	}, nil
 }
+
+//Chap is a chapter from a CHAP frame. Start and end offsets are byte offsets
+//from the start of the first audio frame; ChapNoOffset means that an offset is
+//not used and the times should be used instead. Frames holds the frames
+//embedded in the chapter, usually a TIT2 title, but also APIC pictures or
+//WXXX links.
+type Chap struct {
+	ElementID   string
+	StartTime   time.Duration
+	EndTime     time.Duration
+	StartOffset uint32
+	EndOffset   uint32
+	Frames      map[string]interface{}
+}
+
+//ChapNoOffset is the value of Chap.StartOffset and Chap.EndOffset when the
+//offsets are not used.
+const ChapNoOffset uint32 = 0xFFFFFFFF
+
+//String returns a string representation of the underlying Chap instance.
+func (c Chap) String() string {
+	return fmt.Sprintf("Chap{ElementID: '%v', Title: '%v', Start: %v, End: %v}",
+		c.ElementID, c.Title(), c.StartTime, c.EndTime)
+}
+
+//Title returns the title of the chapter from its embedded TIT2 frame.
+func (c Chap) Title() string {
+	s, _ := c.Frames["TIT2"].(string)
+	return s
+}
+
+//Ctoc is a table of contents from a CTOC frame. ChildElementIDs holds the
+//element IDs of the CHAP and CTOC frames it contains. Frames holds the frames
+//embedded in the table of contents, usually a TIT2 title.
+type Ctoc struct {
+	ElementID       string
+	TopLevel        bool
+	Ordered         bool
+	ChildElementIDs []string
+	Frames          map[string]interface{}
+}
+
+//String returns a string representation of the underlying Ctoc instance.
+func (c Ctoc) String() string {
+	return fmt.Sprintf("Ctoc{ElementID: '%v', TopLevel: %v, Ordered: %v, %v children}",
+		c.ElementID, c.TopLevel, c.Ordered, len(c.ChildElementIDs))
+}
+
+//processID3v2EmbeddedFrames extracts the frames embedded in a CHAP or CTOC
+//frame, which use the same frame headers as the rest of the tag. The tag
+//has already been unsynchronised as a whole if needed, so it isn't done again.
+func processID3v2EmbeddedFrames(b []byte, h *id3v2Header) (map[string]interface{}, error) {
+	eh := *h
+	eh.unsynchronization = false
+	return processID3v2Frames(b, &eh)
+}
+
+//ID3v2 Chapter Frame Addendum (see http://id3.org/id3v2-chapters-1.0)
+//-- Header
+//<ID3v2.3 or ID3v2.4 frame header, ID: "CHAP">
+//-- getCHAPFrame
+//Element ID      <text string> $00
+//Start time      $xx xx xx xx
+//End time        $xx xx xx xx
+//Start offset    $xx xx xx xx
+//End offset      $xx xx xx xx
+//<Optional embedded sub-frames>
+func getCHAPFrame(b []byte, h *id3v2Header) (*Chap, error) {
+	idDataSplit := bytes.SplitN(b, singleZero, 2)
+	if len(idDataSplit) != 2 || len(idDataSplit[1]) < 16 {
+		return nil, errors.New("error decoding CHAP frame: invalid encoding")
+	}
+	b = idDataSplit[1]
+	c := &Chap{
+		ElementID:   decodeISO8859(idDataSplit[0]),
+		StartTime:   time.Duration(binary.BigEndian.Uint32(b[0:4])) * time.Millisecond,
+		EndTime:     time.Duration(binary.BigEndian.Uint32(b[4:8])) * time.Millisecond,
+		StartOffset: binary.BigEndian.Uint32(b[8:12]),
+		EndOffset:   binary.BigEndian.Uint32(b[12:16]),
+	}
+	frames, err := processID3v2EmbeddedFrames(b[16:], h)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding CHAP sub-frames: %v", err)
+	}
+	c.Frames = frames
+	return c, nil
+}
+
+//ID3v2 Chapter Frame Addendum (see http://id3.org/id3v2-chapters-1.0)
+//-- Header
+//<ID3v2.3 or ID3v2.4 frame header, ID: "CTOC">
+//-- getCTOCFrame
+//Element ID      <text string> $00
+//Flags           %000000ab (a = top-level, b = ordered)
+//Entry count     $xx
+//Child element   <text string> $00 (repeated entry count times)
+//<Optional embedded sub-frames>
+func getCTOCFrame(b []byte, h *id3v2Header) (*Ctoc, error) {
+	idDataSplit := bytes.SplitN(b, singleZero, 2)
+	if len(idDataSplit) != 2 || len(idDataSplit[1]) < 2 {
+		return nil, errors.New("error decoding CTOC frame: invalid encoding")
+	}
+	b = idDataSplit[1]
+	c := &Ctoc{
+		ElementID: decodeISO8859(idDataSplit[0]),
+		TopLevel:  b[0]&0x02 != 0,
+		Ordered:   b[0]&0x01 != 0,
+	}
+	entryCount := int(b[1])
+	b = b[2:]
+	for i := 0; i < entryCount; i++ {
+		childDataSplit := bytes.SplitN(b, singleZero, 2)
+		if len(childDataSplit) != 2 {
+			return nil, errors.New("error decoding CTOC child element IDs: invalid encoding")
+		}
+		c.ChildElementIDs = append(c.ChildElementIDs, decodeISO8859(childDataSplit[0]))
+		b = childDataSplit[1]
+	}
+	frames, err := processID3v2EmbeddedFrames(b, h)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding CTOC sub-frames: %v", err)
+	}
+	c.Frames = frames
+	return c, nil
+}
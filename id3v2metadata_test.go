@@ -4,7 +4,12 @@
 
 package yurit
 
-import "testing"
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
 
 func TestParseXofN(t *testing.T) {
 	table := []struct {
@@ -27,3 +32,64 @@ func TestParseXofN(t *testing.T) {
 		}
 	}
 }
+
+func id3v23TestFrame(name string, data []byte) []byte {
+	b := make([]byte, 10)
+	copy(b, name)
+	binary.BigEndian.PutUint32(b[4:8], uint32(len(data)))
+	return append(b, data...)
+}
+
+func id3v23TestChap(id string, start, end uint32, title string) []byte {
+	b := append([]byte(id), 0)
+	times := make([]byte, 16)
+	binary.BigEndian.PutUint32(times[0:4], start)
+	binary.BigEndian.PutUint32(times[4:8], end)
+	binary.BigEndian.PutUint32(times[8:12], ChapNoOffset)
+	binary.BigEndian.PutUint32(times[12:16], ChapNoOffset)
+	b = append(b, times...)
+	b = append(b, id3v23TestFrame("TIT2", append([]byte{0}, title...))...)
+	return id3v23TestFrame("CHAP", b)
+}
+
+func TestID3v2Chapters(t *testing.T) {
+	ctoc := append([]byte("toc\x00"), 0x03, 2)
+	ctoc = append(ctoc, "ch2\x00ch1\x00"...)
+	frames := id3v23TestFrame("CTOC", ctoc)
+	frames = append(frames, id3v23TestChap("ch1", 0, 1000, "One")...)
+	frames = append(frames, id3v23TestChap("ch2", 1000, 2500, "Two")...)
+	frames = append(frames, id3v23TestChap("ch3", 500, 900, "Three")...)
+	frames = append(frames, id3v23TestFrame("TIT2", []byte("\x00Title"))...)
+	//Tag size is a 28 bit sync safe integer
+	size := len(frames)
+	tag := []byte{'I', 'D', '3', 3, 0, 0, byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)}
+	tag = append(tag, frames...)
+
+	m, err := ReadID3v2Tags(bytes.NewReader(tag))
+	if err != nil {
+		t.Fatalf("ReadID3v2Tags() returned unexpected error: %v", err)
+	}
+	if m.Title() != "Title" {
+		t.Errorf("Title() = %q, expected %q", m.Title(), "Title")
+	}
+	expected := []struct {
+		id    string
+		title string
+		start time.Duration
+		end   time.Duration
+	}{
+		{"ch2", "Two", 1000 * time.Millisecond, 2500 * time.Millisecond},
+		{"ch1", "One", 0, 1000 * time.Millisecond},
+		{"ch3", "Three", 500 * time.Millisecond, 900 * time.Millisecond},
+	}
+	chapters := m.Chapters()
+	if len(chapters) != len(expected) {
+		t.Fatalf("Chapters() returned %d chapters, expected %d", len(chapters), len(expected))
+	}
+	for ii, tt := range expected {
+		c := chapters[ii]
+		if c.ElementID != tt.id || c.Title() != tt.title || c.StartTime != tt.start || c.EndTime != tt.end {
+			t.Errorf("[%d] Chapters() = %v, expected %v", ii, c, tt)
+		}
+	}
+}
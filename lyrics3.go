@@ -0,0 +1,187 @@
+package yurit
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+)
+
+//lyrics3Tags holds the fields from a Lyrics3 tag, which is sometimes found at
+//the end of an mp3 file between the audio and the ID3v1 tag. Fields are keyed
+//by their three character IDs, e.g. LYR for the lyrics. Lyrics3v1 tags only
+//hold lyrics, which are stored under LYR as well.
+//https://id3.org/Lyrics3
+//https://id3.org/Lyrics3v2
+type lyrics3Tags map[string]interface{}
+
+const (
+	lyrics3BeginID  = "LYRICSBEGIN"
+	lyrics3v1EndID  = "LYRICSEND"
+	lyrics3v2EndID  = "LYRICS200"
+	lyrics3v1MaxLen = 5100
+)
+
+//ReadLyrics3Tags reads a Lyrics3v2 or Lyrics3v1 tag from the end of the
+//io.ReadSeeker, skipping over an ID3v1 tag if there is one. If there is no
+//Lyrics3 tag, or the tag is malformed, returns nil. As the tag is optional and
+//only sits at the end of the file, a broken one is not worth failing over.
+func ReadLyrics3Tags(r io.ReadSeeker) (lyrics3Tags, error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	//The Lyrics3 tag is located right before the ID3v1 tag if there is one
+	if end >= 128 {
+		_, err = r.Seek(-128, io.SeekEnd)
+		if err != nil {
+			return nil, err
+		}
+		b, err := readBytes(r, 3)
+		if err != nil {
+			return nil, err
+		}
+		if string(b) == "TAG" {
+			end -= 128
+		}
+	}
+	//The shortest possible tag is an empty Lyrics3v1 tag, which is also long
+	//enough to hold the 6 digit size and LYRICS200 at the end of Lyrics3v2
+	if end < int64(len(lyrics3BeginID)+len(lyrics3v1EndID)) {
+		return nil, nil
+	}
+	_, err = r.Seek(end-15, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	b, err := readBytes(r, 15)
+	if err != nil {
+		return nil, err
+	}
+	if string(b[6:]) == lyrics3v2EndID {
+		return readLyrics3v2Tags(r, end, getString(b[0:6]))
+	}
+	if string(b[15-len(lyrics3v1EndID):]) == lyrics3v1EndID {
+		return readLyrics3v1Tags(r, end)
+	}
+	return nil, nil
+}
+
+//readLyrics3v2Tags reads the fields of a Lyrics3v2 tag that ends (including
+//its size and LYRICS200) at end. The size string holds the length of the tag
+//up to, but not including, the size itself. If the size or any of the fields
+//are invalid, returns nil.
+func readLyrics3v2Tags(r io.ReadSeeker, end int64, size string) (lyrics3Tags, error) {
+	n, err := strconv.ParseInt(size, 10, 64)
+	start := end - 15 - n
+	if err != nil || n < int64(len(lyrics3BeginID)) || start < 0 {
+		return nil, nil
+	}
+	_, err = r.Seek(start, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	b, err := readBytes(r, uint(n))
+	if err != nil {
+		return nil, err
+	}
+	if string(b[0:len(lyrics3BeginID)]) != lyrics3BeginID {
+		return nil, nil
+	}
+	l := lyrics3Tags{}
+	l[VersionKey] = "2.00"
+	l[TotalBytesKey] = end - start
+	//Each field is a 3 character ID and 5 digit length followed by the data
+	offset := len(lyrics3BeginID)
+	for offset+8 <= len(b) {
+		id := string(b[offset : offset+3])
+		fieldLen, err := strconv.Atoi(string(b[offset+3 : offset+8]))
+		if err != nil || fieldLen < 0 || offset+8+fieldLen > len(b) {
+			return nil, nil
+		}
+		l[id] = string(b[offset+8 : offset+8+fieldLen])
+		offset += 8 + fieldLen
+	}
+	return l, nil
+}
+
+//readLyrics3v1Tags reads the lyrics from a Lyrics3v1 tag that ends (including
+//LYRICSEND) at end. Lyrics3v1 doesn't store its size, so the start of the tag is
+//found by searching backwards for LYRICSBEGIN.
+func readLyrics3v1Tags(r io.ReadSeeker, end int64) (lyrics3Tags, error) {
+	searchLen := int64(len(lyrics3BeginID) + lyrics3v1MaxLen + len(lyrics3v1EndID))
+	if searchLen > end {
+		searchLen = end
+	}
+	_, err := r.Seek(end-searchLen, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	b, err := readBytes(r, uint(searchLen))
+	if err != nil {
+		return nil, err
+	}
+	i := bytes.LastIndex(b, []byte(lyrics3BeginID))
+	if i < 0 {
+		return nil, nil
+	}
+	l := lyrics3Tags{}
+	l[VersionKey] = "1.00"
+	l[TotalBytesKey] = searchLen - int64(i)
+	l["LYR"] = string(b[i+len(lyrics3BeginID) : len(b)-len(lyrics3v1EndID)])
+	return l, nil
+}
+
+//Album returns the extended album (EAL), which may be longer than the one in
+//the ID3v1 tag.
+func (l lyrics3Tags) Album() string {
+	s, _ := l["EAL"].(string)
+	return s
+}
+
+//Artist returns the extended artist (EAR), which may be longer than the one in
+//the ID3v1 tag.
+func (l lyrics3Tags) Artist() string {
+	s, _ := l["EAR"].(string)
+	return s
+}
+
+//Author returns the author of the lyrics (AUT).
+func (l lyrics3Tags) Author() string {
+	s, _ := l["AUT"].(string)
+	return s
+}
+
+//Indications returns the indications field (IND), where the first character is
+//1 if the lyrics are present and the second is 1 if the lyrics hold timestamps.
+func (l lyrics3Tags) Indications() string {
+	s, _ := l["IND"].(string)
+	return s
+}
+
+//Information returns the additional information field (INF).
+func (l lyrics3Tags) Information() string {
+	s, _ := l["INF"].(string)
+	return s
+}
+
+func (l lyrics3Tags) Lyrics() string {
+	s, _ := l["LYR"].(string)
+	return s
+}
+
+func (l lyrics3Tags) Raw() map[string]interface{} {
+	return l
+}
+
+//Size returns the number of bytes that the tag takes up in the file.
+func (l lyrics3Tags) Size() int64 {
+	s, _ := l[TotalBytesKey].(int64)
+	return s
+}
+
+//Title returns the extended title (ETT), which may be longer than the one in
+//the ID3v1 tag.
+func (l lyrics3Tags) Title() string {
+	s, _ := l["ETT"].(string)
+	return s
+}
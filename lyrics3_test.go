@@ -0,0 +1,55 @@
+package yurit
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestReadLyrics3Tags(t *testing.T) {
+	audio := bytes.Repeat([]byte{0xFF}, 200)
+	id3v1 := append([]byte("TAG"), make([]byte, 125)...)
+	v2Body := "LYRICSBEGIN" + "IND00003110" + "LYR00012[00:01]Hello" + "ETT00010Long Title"
+	v2 := v2Body + fmt.Sprintf("%06d", len(v2Body)) + "LYRICS200"
+	v1 := "LYRICSBEGIN" + "Hello\r\nWorld" + "LYRICSEND"
+
+	tests := []struct {
+		input   []byte
+		version string
+		size    int64
+		lyrics  string
+		title   string
+	}{
+		{audio, "", 0, "", ""},
+		{append(audio, id3v1...), "", 0, "", ""},
+		{append(append([]byte{}, audio...), v2...), "2.00", int64(len(v2)), "[00:01]Hello", "Long Title"},
+		{append(append(append([]byte{}, audio...), v2...), id3v1...), "2.00", int64(len(v2)), "[00:01]Hello", "Long Title"},
+		{append(append(append([]byte{}, audio...), v1...), id3v1...), "1.00", int64(len(v1)), "Hello\r\nWorld", ""},
+		//Malformed tags are treated as absent
+		{append(append([]byte{}, audio...), v2Body+"00x000LYRICS200"...), "", 0, "", ""},
+		{append(append([]byte{}, audio...), v2Body+"999999LYRICS200"...), "", 0, "", ""},
+		{append(append([]byte{}, audio...), v2Body[1:]+fmt.Sprintf("%06d", len(v2Body)-1)+"LYRICS200"...), "", 0, "", ""},
+		{append(append([]byte{}, audio...), "LYRICSBEGINLYR00099Hello"+"000024LYRICS200"...), "", 0, "", ""},
+	}
+
+	for ii, tt := range tests {
+		l, err := ReadLyrics3Tags(bytes.NewReader(tt.input))
+		if err != nil {
+			t.Errorf("[%d] ReadLyrics3Tags() returned unexpected error: %v", ii, err)
+			continue
+		}
+		version, _ := l[VersionKey].(string)
+		if version != tt.version {
+			t.Errorf("[%d] ReadLyrics3Tags() version = %q, expected %q", ii, version, tt.version)
+		}
+		if l.Size() != tt.size {
+			t.Errorf("[%d] ReadLyrics3Tags() size = %d, expected %d", ii, l.Size(), tt.size)
+		}
+		if l.Lyrics() != tt.lyrics {
+			t.Errorf("[%d] ReadLyrics3Tags() lyrics = %q, expected %q", ii, l.Lyrics(), tt.lyrics)
+		}
+		if l.Title() != tt.title {
+			t.Errorf("[%d] ReadLyrics3Tags() title = %q, expected %q", ii, l.Title(), tt.title)
+		}
+	}
+}
@@ -0,0 +1,792 @@
+package yurit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Matroska element IDs that are used by this package. IDs include their length
+//marker bits, as they appear in the file.
+//https://www.matroska.org/technical/elements.html
+const (
+	ebmlHeaderID           uint32 = 0x1A45DFA3
+	ebmlDocTypeID          uint32 = 0x4282
+	mkvSegmentID           uint32 = 0x18538067
+	mkvSeekHeadID          uint32 = 0x114D9B74
+	mkvSeekID              uint32 = 0x4DBB
+	mkvSeekIDID            uint32 = 0x53AB
+	mkvSeekPositionID      uint32 = 0x53AC
+	mkvInfoID              uint32 = 0x1549A966
+	mkvTimecodeScaleID     uint32 = 0x2AD7B1
+	mkvDurationID          uint32 = 0x4489
+	mkvTitleID             uint32 = 0x7BA9
+	mkvMuxingAppID         uint32 = 0x4D80
+	mkvWritingAppID        uint32 = 0x5741
+	mkvTracksID            uint32 = 0x1654AE6B
+	mkvTrackEntryID        uint32 = 0xAE
+	mkvTrackNumberID       uint32 = 0xD7
+	mkvTrackTypeID         uint32 = 0x83
+	mkvCodecIDID           uint32 = 0x86
+	mkvCodecPrivateID      uint32 = 0x63A2
+	mkvCodecDelayID        uint32 = 0x56AA
+	mkvSeekPreRollID       uint32 = 0x56BB
+	mkvAudioID             uint32 = 0xE1
+	mkvSamplingFreqID      uint32 = 0xB5
+	mkvOutputSamplingID    uint32 = 0x78B5
+	mkvChannelsID          uint32 = 0x9F
+	mkvBitDepthID          uint32 = 0x6264
+	mkvTagsID              uint32 = 0x1254C367
+	mkvTagID               uint32 = 0x7373
+	mkvTargetsID           uint32 = 0x63C0
+	mkvTargetTypeValueID   uint32 = 0x68CA
+	mkvSimpleTagID         uint32 = 0x67C8
+	mkvTagNameID           uint32 = 0x45A3
+	mkvTagStringID         uint32 = 0x4487
+	mkvAttachmentsID       uint32 = 0x1941A469
+	mkvAttachedFileID      uint32 = 0x61A7
+	mkvFileDescriptionID   uint32 = 0x467E
+	mkvFileNameID          uint32 = 0x466E
+	mkvFileMimeTypeID      uint32 = 0x4660
+	mkvFileDataID          uint32 = 0x465C
+	mkvChaptersID          uint32 = 0x1043A770
+	mkvEditionEntryID      uint32 = 0x45B9
+	mkvChapterAtomID       uint32 = 0xB6
+	mkvChapterUIDID        uint32 = 0x73C4
+	mkvChapterTimeStartID  uint32 = 0x91
+	mkvChapterTimeEndID    uint32 = 0x92
+	mkvChapterFlagHiddenID uint32 = 0x98
+	mkvChapterDisplayID    uint32 = 0x80
+	mkvChapStringID        uint32 = 0x85
+	mkvChapLanguageID      uint32 = 0x437C
+)
+
+//Matroska track types, only audio tracks are of interest here.
+const (
+	mkvTrackTypeAudio = 2
+)
+
+//Matroska tag target type values. The specification makes 50 the default for
+//tags without a target type value, but in audio files such tags describe the
+//track, so they are treated as track level tags here.
+//https://www.matroska.org/technical/tagging.html
+const (
+	mkvTargetEdition = 60
+	mkvTargetAlbum   = 50
+	mkvTargetTrack   = 30
+)
+
+//MatroskaMetadata is a collection of metadata and other useful data from a
+//Matroska (.mka/.mkv) or WebM (.webm) container.
+type MatroskaMetadata struct {
+	fileType    FileType
+	segmentSize int64
+	info        matroskaInfo
+	track       matroskaTrack
+	tags        matroskaTags
+	pictures    []Picture
+	chapters    []MatroskaChapter
+}
+
+//MatroskaChapter is a single chapter atom from the Chapters element of a
+//Matroska file. Chapters may contain nested chapters.
+type MatroskaChapter struct {
+	UID      uint64
+	Start    time.Duration
+	End      time.Duration //Zero if not set
+	Hidden   bool
+	Titles   map[string]string //Chapter titles keyed by language
+	Children []MatroskaChapter
+}
+
+//Title returns the English chapter title if there is one, else any title.
+func (c MatroskaChapter) Title() string {
+	if t, ok := c.Titles["eng"]; ok {
+		return t
+	}
+	for _, t := range c.Titles {
+		return t
+	}
+	return ""
+}
+
+// ReadMatroska reads Matroska or WebM metadata from the io.ReadSeeker, returning
+// the resulting metadata in a Metadata implementation, or non-nil error if there
+// was a problem.
+func ReadMatroska(r io.ReadSeeker) (*MatroskaMetadata, error) {
+	id, size, _, err := readEBMLElementHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if id != ebmlHeaderID || size == ebmlUnknownSize {
+		return nil, errors.New("expected EBML header")
+	}
+	b, err := readEBMLElementData(r, size)
+	if err != nil {
+		return nil, err
+	}
+	header, err := processEBMLElements(b)
+	if err != nil {
+		return nil, err
+	}
+	m := &MatroskaMetadata{fileType: MKA}
+	if docType := findEBMLElement(header, ebmlDocTypeID); docType != nil {
+		switch getString(docType.data) {
+		case "webm":
+			m.fileType = WEBM
+		case "matroska":
+		default:
+			return nil, fmt.Errorf("unknown EBML doc type: %q", getString(docType.data))
+		}
+	}
+
+	//Skip anything else until we get to the segment
+	for {
+		id, size, _, err = readEBMLElementHeader(r)
+		if err != nil {
+			return nil, err
+		}
+		if id == mkvSegmentID {
+			break
+		}
+		if size == ebmlUnknownSize {
+			return nil, errors.New("expected Matroska segment")
+		}
+		_, err = r.Seek(size, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+	}
+	m.segmentSize = size
+	segmentStart, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	err = m.readSegment(r, segmentStart, size)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+//readSegment walks the top level elements of a Matroska segment and reads the
+//ones that we are interested in. Elements that the walk cannot reach, because
+//they come after an element of unknown size, are found using the seek head.
+func (m *MatroskaMetadata) readSegment(r io.ReadSeeker, segmentStart int64, segmentSize int64) error {
+	var (
+		seen      = map[uint32]bool{}
+		positions = map[uint32]int64{}
+		offset    int64
+	)
+	for segmentSize == ebmlUnknownSize || offset < segmentSize {
+		id, size, headerSize, err := readEBMLElementHeader(r)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if size == ebmlUnknownSize {
+			//Most likely a live stream cluster, there is no way to skip it
+			break
+		}
+		if id == mkvSeekHeadID {
+			b, err := readEBMLElementData(r, size)
+			if err != nil {
+				return err
+			}
+			err = processMatroskaSeekHead(b, positions)
+			if err != nil {
+				return err
+			}
+		} else if m.isWantedElement(id) && !seen[id] {
+			b, err := readEBMLElementData(r, size)
+			if err != nil {
+				return err
+			}
+			err = m.processTopLevelElement(id, b)
+			if err != nil {
+				return err
+			}
+			seen[id] = true
+		} else {
+			_, err = r.Seek(size, io.SeekCurrent)
+			if err != nil {
+				return err
+			}
+		}
+		offset += int64(headerSize) + size
+	}
+	//Pick up anything the seek head points to that we haven't read yet
+	for id, position := range positions {
+		if seen[id] || !m.isWantedElement(id) {
+			continue
+		}
+		_, err := r.Seek(segmentStart+position, io.SeekStart)
+		if err != nil {
+			return err
+		}
+		foundID, size, _, err := readEBMLElementHeader(r)
+		if err != nil {
+			return err
+		}
+		if foundID != id || size == ebmlUnknownSize {
+			continue
+		}
+		b, err := readEBMLElementData(r, size)
+		if err != nil {
+			return err
+		}
+		err = m.processTopLevelElement(id, b)
+		if err != nil {
+			return err
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+func (m *MatroskaMetadata) isWantedElement(id uint32) bool {
+	return id == mkvInfoID || id == mkvTracksID || id == mkvTagsID ||
+		id == mkvAttachmentsID || id == mkvChaptersID
+}
+
+func (m *MatroskaMetadata) processTopLevelElement(id uint32, b []byte) error {
+	var err error
+	switch id {
+	case mkvInfoID:
+		m.info, err = processMatroskaInfo(b)
+	case mkvTracksID:
+		m.track, err = processMatroskaTracks(b)
+	case mkvTagsID:
+		m.tags, err = processMatroskaTags(b)
+	case mkvAttachmentsID:
+		m.pictures, err = processMatroskaAttachments(b)
+	case mkvChaptersID:
+		m.chapters, err = processMatroskaChapters(b)
+	}
+	return err
+}
+
+//processMatroskaSeekHead reads the seek entries in a seek head and adds the
+//positions of the elements they point to, relative to the start of the segment
+//data, to positions.
+func processMatroskaSeekHead(b []byte, positions map[uint32]int64) error {
+	seeks, err := processEBMLElements(b)
+	if err != nil {
+		return err
+	}
+	for _, seek := range seeks {
+		if seek.id != mkvSeekID {
+			continue
+		}
+		children, err := processEBMLElements(seek.data)
+		if err != nil {
+			return err
+		}
+		seekID := findEBMLElement(children, mkvSeekIDID)
+		seekPosition := findEBMLElement(children, mkvSeekPositionID)
+		if seekID == nil || seekPosition == nil {
+			continue
+		}
+		id := uint32(getUint(seekID.data))
+		if _, ok := positions[id]; !ok {
+			positions[id] = int64(getUint(seekPosition.data))
+		}
+	}
+	return nil
+}
+
+//matroskaInfo holds general information about a Matroska segment.
+type matroskaInfo map[string]interface{}
+
+func processMatroskaInfo(b []byte) (matroskaInfo, error) {
+	elements, err := processEBMLElements(b)
+	if err != nil {
+		return nil, err
+	}
+	info := matroskaInfo{}
+	//Timecode scale defaults to 1ms
+	info[TimeScaleKey] = int64(1000000)
+	for _, e := range elements {
+		switch e.id {
+		case mkvTimecodeScaleID:
+			info[TimeScaleKey] = int64(getUint(e.data))
+		case mkvDurationID:
+			info[DurationKey] = getEBMLFloat(e.data)
+		case mkvTitleID:
+			info["title"] = getString(e.data)
+		case mkvMuxingAppID:
+			info["muxingApp"] = getString(e.data)
+		case mkvWritingAppID:
+			info["writingApp"] = getString(e.data)
+		}
+	}
+	return info, nil
+}
+
+func (info matroskaInfo) Duration() time.Duration {
+	d, okd := info[DurationKey].(float64)
+	s, oks := info[TimeScaleKey].(int64)
+	if okd && oks {
+		//Duration is given in units of the timecode scale, which is in nanoseconds
+		return time.Duration(d * float64(s))
+	}
+	return time.Duration(0)
+}
+
+//matroskaTrack holds information about the first audio track found in a
+//Matroska file.
+type matroskaTrack map[string]interface{}
+
+//processMatroskaTracks reads the track entries of a Matroska file and returns
+//the first audio track.
+func processMatroskaTracks(b []byte) (matroskaTrack, error) {
+	entries, err := processEBMLElements(b)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.id != mkvTrackEntryID {
+			continue
+		}
+		elements, err := processEBMLElements(entry.data)
+		if err != nil {
+			return nil, err
+		}
+		trackType := findEBMLElement(elements, mkvTrackTypeID)
+		if trackType == nil || getUint(trackType.data) != mkvTrackTypeAudio {
+			continue
+		}
+		t := matroskaTrack{}
+		//Defaults from the specification
+		t[SampleRateKey] = float64(8000)
+		t[ChannelsKey] = 1
+		for _, e := range elements {
+			switch e.id {
+			case mkvTrackNumberID:
+				t["trackNumber"] = int(getUint(e.data))
+			case mkvCodecIDID:
+				t["codecID"] = getString(e.data)
+			case mkvCodecPrivateID:
+				t["codecPrivate"] = e.data
+			case mkvCodecDelayID:
+				t["codecDelay"] = int64(getUint(e.data))
+			case mkvSeekPreRollID:
+				t["seekPreRoll"] = int64(getUint(e.data))
+			case mkvAudioID:
+				audio, err := processEBMLElements(e.data)
+				if err != nil {
+					return nil, err
+				}
+				for _, a := range audio {
+					switch a.id {
+					case mkvSamplingFreqID:
+						t[SampleRateKey] = getEBMLFloat(a.data)
+					case mkvOutputSamplingID:
+						t["outputSampleRate"] = getEBMLFloat(a.data)
+					case mkvChannelsID:
+						t[ChannelsKey] = int(getUint(a.data))
+					case mkvBitDepthID:
+						t[SampleSizeKey] = int(getUint(a.data))
+					}
+				}
+			}
+		}
+		return t, nil
+	}
+	return nil, nil
+}
+
+func (t matroskaTrack) CodecID() string {
+	c, _ := t["codecID"].(string)
+	return c
+}
+
+func (t matroskaTrack) SampleRate() int {
+	//The output sample rate is the real one when SBR is used
+	if sr, ok := t["outputSampleRate"].(float64); ok {
+		return int(sr)
+	}
+	sr, _ := t[SampleRateKey].(float64)
+	return int(sr)
+}
+
+//matroskaTags holds SimpleTag values from a Matroska file, grouped by the
+//target type value of the Tag they belong to. Tag names are upper case.
+type matroskaTags map[int]map[string]string
+
+func processMatroskaTags(b []byte) (matroskaTags, error) {
+	tagElements, err := processEBMLElements(b)
+	if err != nil {
+		return nil, err
+	}
+	tags := matroskaTags{}
+	for _, tagElement := range tagElements {
+		if tagElement.id != mkvTagID {
+			continue
+		}
+		elements, err := processEBMLElements(tagElement.data)
+		if err != nil {
+			return nil, err
+		}
+		level := mkvTargetTrack
+		if targets := findEBMLElement(elements, mkvTargetsID); targets != nil {
+			targetElements, err := processEBMLElements(targets.data)
+			if err != nil {
+				return nil, err
+			}
+			if ttv := findEBMLElement(targetElements, mkvTargetTypeValueID); ttv != nil {
+				level = int(getUint(ttv.data))
+			}
+		}
+		if tags[level] == nil {
+			tags[level] = map[string]string{}
+		}
+		for _, e := range elements {
+			if e.id == mkvSimpleTagID {
+				err = processMatroskaSimpleTag(e.data, "", tags[level])
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return tags, nil
+}
+
+//processMatroskaSimpleTag reads a SimpleTag and any SimpleTags nested in it.
+//Nested tags are stored with their parent's name as a prefix, e.g. ARTIST/URL.
+func processMatroskaSimpleTag(b []byte, prefix string, values map[string]string) error {
+	elements, err := processEBMLElements(b)
+	if err != nil {
+		return err
+	}
+	nameElement := findEBMLElement(elements, mkvTagNameID)
+	if nameElement == nil {
+		return nil
+	}
+	name := prefix + strings.ToUpper(getString(nameElement.data))
+	if value := findEBMLElement(elements, mkvTagStringID); value != nil {
+		//Keep the first value found for a name
+		if _, ok := values[name]; !ok {
+			values[name] = getString(value.data)
+		}
+	}
+	for _, e := range elements {
+		if e.id == mkvSimpleTagID {
+			err = processMatroskaSimpleTag(e.data, name+"/", values)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (t matroskaTags) get(level int, name string) string {
+	return t[level][name]
+}
+
+//processMatroskaAttachments reads the image attachments of a Matroska file,
+//which is where cover art is stored.
+//https://www.matroska.org/technical/attachments.html
+func processMatroskaAttachments(b []byte) ([]Picture, error) {
+	files, err := processEBMLElements(b)
+	if err != nil {
+		return nil, err
+	}
+	var pictures []Picture
+	for _, file := range files {
+		if file.id != mkvAttachedFileID {
+			continue
+		}
+		elements, err := processEBMLElements(file.data)
+		if err != nil {
+			return nil, err
+		}
+		var name, desc, mime string
+		var data []byte
+		for _, e := range elements {
+			switch e.id {
+			case mkvFileNameID:
+				name = getString(e.data)
+			case mkvFileDescriptionID:
+				desc = getString(e.data)
+			case mkvFileMimeTypeID:
+				mime = getString(e.data)
+			case mkvFileDataID:
+				data = e.data
+			}
+		}
+		if !strings.HasPrefix(mime, "image/") {
+			continue
+		}
+		ext := ""
+		switch mime {
+		case "image/jpeg":
+			ext = "jpg"
+		case "image/png":
+			ext = "png"
+		case "image/gif":
+			ext = "gif"
+		}
+		//By convention the front cover is named cover.jpg or cover.png
+		pictureType := pictureTypes[0x00]
+		if strings.HasPrefix(strings.ToLower(name), "cover.") {
+			pictureType = pictureTypes[0x03]
+		}
+		pictures = append(pictures, Picture{
+			Ext:         ext,
+			MIMEType:    mime,
+			Type:        pictureType,
+			Description: desc,
+			Data:        data,
+		})
+	}
+	return pictures, nil
+}
+
+//processMatroskaChapters reads the chapter atoms of the default (first) edition
+//in a Matroska file. Chapter times are given in nanoseconds, unscaled.
+func processMatroskaChapters(b []byte) ([]MatroskaChapter, error) {
+	editions, err := processEBMLElements(b)
+	if err != nil {
+		return nil, err
+	}
+	edition := findEBMLElement(editions, mkvEditionEntryID)
+	if edition == nil {
+		return nil, nil
+	}
+	return processMatroskaChapterAtoms(edition.data)
+}
+
+func processMatroskaChapterAtoms(b []byte) ([]MatroskaChapter, error) {
+	atoms, err := processEBMLElements(b)
+	if err != nil {
+		return nil, err
+	}
+	var chapters []MatroskaChapter
+	for _, atom := range atoms {
+		if atom.id != mkvChapterAtomID {
+			continue
+		}
+		elements, err := processEBMLElements(atom.data)
+		if err != nil {
+			return nil, err
+		}
+		c := MatroskaChapter{Titles: map[string]string{}}
+		for _, e := range elements {
+			switch e.id {
+			case mkvChapterUIDID:
+				c.UID = uint64(getUint(e.data))
+			case mkvChapterTimeStartID:
+				c.Start = time.Duration(getUint(e.data))
+			case mkvChapterTimeEndID:
+				c.End = time.Duration(getUint(e.data))
+			case mkvChapterFlagHiddenID:
+				c.Hidden = getUint(e.data) == 1
+			case mkvChapterDisplayID:
+				display, err := processEBMLElements(e.data)
+				if err != nil {
+					return nil, err
+				}
+				title := findEBMLElement(display, mkvChapStringID)
+				if title == nil {
+					continue
+				}
+				//Language defaults to English
+				lang := "eng"
+				if l := findEBMLElement(display, mkvChapLanguageID); l != nil {
+					lang = getString(l.data)
+				}
+				c.Titles[lang] = getString(title.data)
+			}
+		}
+		c.Children, err = processMatroskaChapterAtoms(atom.data)
+		if err != nil {
+			return nil, err
+		}
+		chapters = append(chapters, c)
+	}
+	return chapters, nil
+}
+
+func (m MatroskaMetadata) Album() string {
+	return m.tags.get(mkvTargetAlbum, "TITLE")
+}
+
+func (m MatroskaMetadata) AlbumArtist() string {
+	return m.tags.get(mkvTargetAlbum, "ARTIST")
+}
+
+func (m MatroskaMetadata) Artist() string {
+	if a := m.tags.get(mkvTargetTrack, "ARTIST"); a != "" {
+		return a
+	}
+	return m.tags.get(mkvTargetAlbum, "ARTIST")
+}
+
+// AverageBitrate returns the roughly calculated average bitrate of the file in
+// bits per second. The size of the whole segment is used, so the returned value
+// includes container overhead as well as any attachments.
+func (m MatroskaMetadata) AverageBitrate() int {
+	if b, err := strconv.Atoi(m.tags.get(mkvTargetTrack, "BPS")); err == nil {
+		return b
+	}
+	durationInSeconds := m.Duration().Seconds()
+	if durationInSeconds == 0 || m.segmentSize == ebmlUnknownSize {
+		return 0
+	}
+	return int(float64(m.segmentSize*8) / durationInSeconds)
+}
+
+//Channels returns the number of channels of the first audio track.
+func (m MatroskaMetadata) Channels() int {
+	c, _ := m.track[ChannelsKey].(int)
+	return c
+}
+
+//Chapters returns the top level chapters of the default edition that are not
+//hidden. See MatroskaChapters for all of the chapters, including nested ones.
+func (m MatroskaMetadata) Chapters() []Chapter {
+	var chapters []Chapter
+	for _, c := range m.chapters {
+		if c.Hidden {
+			continue
+		}
+		chapters = append(chapters, Chapter{Title: c.Title(), Start: c.Start, End: c.End})
+	}
+	return completeChapters(chapters, m.Duration())
+}
+
+//CodecID returns the codec ID of the first audio track, e.g. A_OPUS or A_VORBIS.
+func (m MatroskaMetadata) CodecID() string {
+	return m.track.CodecID()
+}
+
+func (m MatroskaMetadata) Comment() string {
+	if c := m.tags.get(mkvTargetTrack, "COMMENT"); c != "" {
+		return c
+	}
+	return m.tags.get(mkvTargetAlbum, "COMMENT")
+}
+
+func (m MatroskaMetadata) Composer() string {
+	if c := m.tags.get(mkvTargetTrack, "COMPOSER"); c != "" {
+		return c
+	}
+	return m.tags.get(mkvTargetAlbum, "COMPOSER")
+}
+
+func (m MatroskaMetadata) Disc() (int, int) {
+	x, _ := strconv.Atoi(m.tags.get(mkvTargetAlbum, "PART_NUMBER"))
+	n, _ := strconv.Atoi(m.tags.get(mkvTargetEdition, "TOTAL_PARTS"))
+	return x, n
+}
+
+func (m MatroskaMetadata) Duration() time.Duration {
+	return m.info.Duration()
+}
+
+func (m MatroskaMetadata) FileType() FileType {
+	return m.fileType
+}
+
+func (m MatroskaMetadata) Format() Format {
+	if len(m.tags) > 0 {
+		return MATROSKA
+	}
+	return UnknownFormat
+}
+
+func (m MatroskaMetadata) Genre() string {
+	if g := m.tags.get(mkvTargetTrack, "GENRE"); g != "" {
+		return g
+	}
+	return m.tags.get(mkvTargetAlbum, "GENRE")
+}
+
+//Info returns information extracted from the segment info element.
+func (m MatroskaMetadata) Info() map[string]interface{} {
+	return m.info
+}
+
+func (m MatroskaMetadata) Lyrics() string {
+	return m.tags.get(mkvTargetTrack, "LYRICS")
+}
+
+//MatroskaChapters returns the chapters of the first edition of the file.
+func (m MatroskaMetadata) MatroskaChapters() []MatroskaChapter {
+	return m.chapters
+}
+
+//Picture attempts to return front cover art, else it returns the first image
+//attachment found in the file.
+func (m MatroskaMetadata) Picture() *Picture {
+	if len(m.pictures) == 0 {
+		return nil
+	}
+	for _, pic := range m.pictures {
+		if pic.Type == pictureTypes[0x03] {
+			return &pic
+		}
+	}
+	return &m.pictures[0]
+}
+
+//Pictures returns ALL image attachments found in the file.
+func (m MatroskaMetadata) Pictures() []Picture {
+	return m.pictures
+}
+
+//Raw returns the tags found in the file. Keys are made up of the target type
+//value and the tag name, e.g. "50/TITLE" is the album title.
+func (m MatroskaMetadata) Raw() map[string]interface{} {
+	raw := map[string]interface{}{}
+	for level, values := range m.tags {
+		for name, value := range values {
+			raw[strconv.Itoa(level)+"/"+name] = value
+		}
+	}
+	return raw
+}
+
+//SampleRate returns the sample rate of the first audio track.
+func (m MatroskaMetadata) SampleRate() int {
+	return m.track.SampleRate()
+}
+
+func (m MatroskaMetadata) Title() string {
+	if t := m.tags.get(mkvTargetTrack, "TITLE"); t != "" {
+		return t
+	}
+	t, _ := m.info["title"].(string)
+	return t
+}
+
+//TrackEntry returns the information extracted from the first audio track entry.
+func (m MatroskaMetadata) TrackEntry() map[string]interface{} {
+	return m.track
+}
+
+func (m MatroskaMetadata) Track() (int, int) {
+	x, _ := strconv.Atoi(m.tags.get(mkvTargetTrack, "PART_NUMBER"))
+	n, _ := strconv.Atoi(m.tags.get(mkvTargetAlbum, "TOTAL_PARTS"))
+	return x, n
+}
+
+func (m MatroskaMetadata) Year() int {
+	for _, level := range []int{mkvTargetTrack, mkvTargetAlbum} {
+		for _, name := range []string{"DATE_RELEASED", "DATE_RECORDED"} {
+			if d := m.tags.get(level, name); len(d) >= 4 {
+				if year, err := strconv.Atoi(d[:4]); err == nil {
+					return year
+				}
+			}
+		}
+	}
+	return 0
+}
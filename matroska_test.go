@@ -0,0 +1,238 @@
+package yurit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+//ebmlTestElement builds an EBML element with the given ID and the concatenation
+//of data as its content. The size is always written as an 8 byte vint so that
+//elements can be measured before their content is known.
+func ebmlTestElement(id uint32, data ...[]byte) []byte {
+	var b []byte
+	for shift := 24; shift >= 0; shift -= 8 {
+		if x := byte(id >> uint(shift)); x != 0 || len(b) > 0 {
+			b = append(b, x)
+		}
+	}
+	content := bytes.Join(data, nil)
+	size := make([]byte, 8)
+	binary.BigEndian.PutUint64(size, uint64(len(content)))
+	size[0] = 0x01
+	return append(append(b, size...), content...)
+}
+
+func ebmlTestUint(id uint32, x uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, x)
+	return ebmlTestElement(id, b)
+}
+
+func ebmlTestFloat(id uint32, f float64) []byte {
+	return ebmlTestUint(id, math.Float64bits(f))
+}
+
+func ebmlTestString(id uint32, s string) []byte {
+	return ebmlTestElement(id, []byte(s))
+}
+
+func ebmlTestSimpleTag(name, value string, children ...[]byte) []byte {
+	return ebmlTestElement(mkvSimpleTagID, append([][]byte{
+		ebmlTestString(mkvTagNameID, name),
+		ebmlTestString(mkvTagStringID, value),
+	}, children...)...)
+}
+
+//matroskaTestFile builds a WebM file whose Tags element comes after a cluster of
+//unknown size, so it can only be found through the seek head.
+func matroskaTestFile() []byte {
+	header := ebmlTestElement(ebmlHeaderID, ebmlTestString(ebmlDocTypeID, "webm"))
+	info := ebmlTestElement(mkvInfoID,
+		ebmlTestUint(mkvTimecodeScaleID, 1000000),
+		ebmlTestFloat(mkvDurationID, 183000),
+		ebmlTestString(mkvTitleID, "Segment Title"),
+	)
+	tracks := ebmlTestElement(mkvTracksID,
+		ebmlTestElement(mkvTrackEntryID,
+			ebmlTestUint(mkvTrackNumberID, 1),
+			ebmlTestUint(mkvTrackTypeID, 1),
+			ebmlTestString(mkvCodecIDID, "V_VP9"),
+		),
+		ebmlTestElement(mkvTrackEntryID,
+			ebmlTestUint(mkvTrackNumberID, 2),
+			ebmlTestUint(mkvTrackTypeID, mkvTrackTypeAudio),
+			ebmlTestString(mkvCodecIDID, "A_OPUS"),
+			ebmlTestElement(mkvAudioID,
+				ebmlTestFloat(mkvSamplingFreqID, 48000),
+				ebmlTestUint(mkvChannelsID, 2),
+			),
+		),
+	)
+	chapters := ebmlTestElement(mkvChaptersID,
+		ebmlTestElement(mkvEditionEntryID,
+			ebmlTestElement(mkvChapterAtomID,
+				ebmlTestUint(mkvChapterUIDID, 1),
+				ebmlTestUint(mkvChapterTimeStartID, 0),
+				ebmlTestElement(mkvChapterDisplayID, ebmlTestString(mkvChapStringID, "Intro")),
+			),
+			ebmlTestElement(mkvChapterAtomID,
+				ebmlTestUint(mkvChapterUIDID, 2),
+				ebmlTestUint(mkvChapterTimeStartID, uint64(10*time.Second)),
+				ebmlTestUint(mkvChapterFlagHiddenID, 1),
+			),
+			ebmlTestElement(mkvChapterAtomID,
+				ebmlTestUint(mkvChapterUIDID, 3),
+				ebmlTestUint(mkvChapterTimeStartID, uint64(time.Minute)),
+				ebmlTestElement(mkvChapterDisplayID,
+					ebmlTestString(mkvChapStringID, "Hauptteil"),
+					ebmlTestString(mkvChapLanguageID, "ger"),
+				),
+			),
+		),
+	)
+	attachments := ebmlTestElement(mkvAttachmentsID,
+		ebmlTestElement(mkvAttachedFileID,
+			ebmlTestString(mkvFileNameID, "notes.txt"),
+			ebmlTestString(mkvFileMimeTypeID, "text/plain"),
+			ebmlTestString(mkvFileDataID, "notes"),
+		),
+		ebmlTestElement(mkvAttachedFileID,
+			ebmlTestString(mkvFileNameID, "cover.png"),
+			ebmlTestString(mkvFileMimeTypeID, "image/png"),
+			ebmlTestString(mkvFileDataID, "png"),
+		),
+	)
+	//A cluster of unknown size stops the walk of the segment
+	cluster := []byte{0x1F, 0x43, 0xB6, 0x75, 0xFF, 0xE7, 0x81, 0x00}
+	tags := ebmlTestElement(mkvTagsID,
+		ebmlTestElement(mkvTagID,
+			ebmlTestElement(mkvTargetsID, ebmlTestUint(mkvTargetTypeValueID, mkvTargetAlbum)),
+			ebmlTestSimpleTag("TITLE", "Album"),
+			ebmlTestSimpleTag("ARTIST", "Album Artist"),
+			ebmlTestSimpleTag("TOTAL_PARTS", "12"),
+			ebmlTestSimpleTag("DATE_RELEASED", "2001-02-03"),
+		),
+		//No TargetTypeValue, so this is read as a track level tag
+		ebmlTestElement(mkvTagID,
+			ebmlTestSimpleTag("title", "Track"),
+			ebmlTestSimpleTag("ARTIST", "Track Artist", ebmlTestSimpleTag("URL", "https://example.com")),
+			ebmlTestSimpleTag("PART_NUMBER", "3"),
+		),
+	)
+
+	seekHeadSize := len(ebmlTestElement(mkvSeekHeadID, ebmlTestElement(mkvSeekID,
+		ebmlTestUint(mkvSeekIDID, 0), ebmlTestUint(mkvSeekPositionID, 0))))
+	body := bytes.Join([][]byte{info, tracks, chapters, attachments, cluster}, nil)
+	seekHead := ebmlTestElement(mkvSeekHeadID, ebmlTestElement(mkvSeekID,
+		ebmlTestUint(mkvSeekIDID, uint64(mkvTagsID)),
+		ebmlTestUint(mkvSeekPositionID, uint64(seekHeadSize+len(body))),
+	))
+	segment := []byte{0x18, 0x53, 0x80, 0x67, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+	return bytes.Join([][]byte{header, segment, seekHead, body, tags}, nil)
+}
+
+func TestReadMatroska(t *testing.T) {
+	m, err := ReadMatroska(bytes.NewReader(matroskaTestFile()))
+	if err != nil {
+		t.Fatalf("ReadMatroska() returned error: %v", err)
+	}
+
+	if m.FileType() != WEBM {
+		t.Errorf("FileType() = %v, expected %v", m.FileType(), WEBM)
+	}
+	if m.Format() != MATROSKA {
+		t.Errorf("Format() = %v, expected %v", m.Format(), MATROSKA)
+	}
+	if d, expected := m.Duration(), 183*time.Second; d != expected {
+		t.Errorf("Duration() = %v, expected %v", d, expected)
+	}
+	if m.CodecID() != "A_OPUS" {
+		t.Errorf("CodecID() = %q, expected %q", m.CodecID(), "A_OPUS")
+	}
+	if m.SampleRate() != 48000 || m.Channels() != 2 {
+		t.Errorf("SampleRate(), Channels() = %v, %v, expected %v, %v", m.SampleRate(), m.Channels(), 48000, 2)
+	}
+
+	strs := []struct {
+		name   string
+		value  string
+		expect string
+	}{
+		{"Title", m.Title(), "Track"},
+		{"Album", m.Album(), "Album"},
+		{"Artist", m.Artist(), "Track Artist"},
+		{"AlbumArtist", m.AlbumArtist(), "Album Artist"},
+	}
+	for _, s := range strs {
+		if s.value != s.expect {
+			t.Errorf("%s() = %q, expected %q", s.name, s.value, s.expect)
+		}
+	}
+	if x, n := m.Track(); x != 3 || n != 12 {
+		t.Errorf("Track() = %v, %v, expected %v, %v", x, n, 3, 12)
+	}
+	if m.Year() != 2001 {
+		t.Errorf("Year() = %v, expected %v", m.Year(), 2001)
+	}
+	if url := m.Raw()["30/ARTIST/URL"]; url != "https://example.com" {
+		t.Errorf("Raw()[%q] = %v, expected %q", "30/ARTIST/URL", url, "https://example.com")
+	}
+
+	if len(m.Pictures()) != 1 {
+		t.Fatalf("Pictures() returned %d pictures, expected 1", len(m.Pictures()))
+	}
+	if p := m.Picture(); p.MIMEType != "image/png" || p.Type != pictureTypes[0x03] || string(p.Data) != "png" {
+		t.Errorf("Picture() = %v %v %q, expected %v %v %q", p.MIMEType, p.Type, p.Data, "image/png", pictureTypes[0x03], "png")
+	}
+
+	if n := len(m.MatroskaChapters()); n != 3 {
+		t.Errorf("MatroskaChapters() returned %d chapters, expected 3", n)
+	}
+	expected := []Chapter{
+		{Index: 1, Title: "Intro", Start: 0, End: time.Minute},
+		{Index: 2, Title: "Hauptteil", Start: time.Minute, End: 183 * time.Second},
+	}
+	if chapters := m.Chapters(); !reflect.DeepEqual(chapters, expected) {
+		t.Errorf("Chapters() = %v, expected %v", chapters, expected)
+	}
+}
+
+func TestReadMatroskaDocType(t *testing.T) {
+	b := matroskaTestFile()
+	mka := append(ebmlTestElement(ebmlHeaderID, ebmlTestString(ebmlDocTypeID, "matroska")), b[len(ebmlTestElement(ebmlHeaderID, ebmlTestString(ebmlDocTypeID, "webm"))):]...)
+	m, err := ReadMatroska(bytes.NewReader(mka))
+	if err != nil {
+		t.Fatalf("ReadMatroska() returned error: %v", err)
+	}
+	if m.FileType() != MKA {
+		t.Errorf("FileType() = %v, expected %v", m.FileType(), MKA)
+	}
+
+	other := ebmlTestElement(ebmlHeaderID, ebmlTestString(ebmlDocTypeID, "other"))
+	_, err = ReadMatroska(bytes.NewReader(other))
+	if err == nil {
+		t.Errorf("ReadMatroska() expected error for doc type %q", "other")
+	}
+}
+
+func TestReadMatroskaElementSize(t *testing.T) {
+	header := ebmlTestElement(ebmlHeaderID, ebmlTestString(ebmlDocTypeID, "webm"))
+	segment := []byte{0x18, 0x53, 0x80, 0x67, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+	//A size of 2^46 bytes, far more than the file holds
+	hugeSize := []byte{0x01, 0x00, 0x40, 0x00, 0x00, 0x00, 0x00, 0x00}
+	tests := [][]byte{
+		append([]byte{0x1A, 0x45, 0xDF, 0xA3}, hugeSize...),
+		bytes.Join([][]byte{header, segment, {0x15, 0x49, 0xA9, 0x66}, hugeSize, {0x2A, 0xD7, 0xB1, 0x83}}, nil),
+		bytes.Join([][]byte{header, segment, {0x11, 0x4D, 0x9B, 0x74}, hugeSize, {0x4D, 0xBB, 0x80}}, nil),
+	}
+	for ii, tt := range tests {
+		_, err := ReadMatroska(bytes.NewReader(tt))
+		if err == nil {
+			t.Errorf("[%d] ReadMatroska() with element size larger than the file expected error", ii)
+		}
+	}
+}
@@ -7,13 +7,18 @@ type Format string
 
 // Supported tag formats.
 const (
-	UnknownFormat Format = ""        // Unknown Format.
-	ID3v1         Format = "ID3v1"   // ID3v1 tag format.
-	ID3v2_2       Format = "ID3v2.2" // ID3v2.2 tag format.
-	ID3v2_3       Format = "ID3v2.3" // ID3v2.3 tag format (most common).
-	ID3v2_4       Format = "ID3v2.4" // ID3v2.4 tag format.
-	MP4           Format = "MP4"     // MP4 tag (atom) format (see http://www.ftyps.com/ for a full file type list)
-	VORBIS        Format = "VORBIS"  // Vorbis Comment tag format.
+	UnknownFormat Format = ""         // Unknown Format.
+	ID3v1         Format = "ID3v1"    // ID3v1 tag format.
+	ID3v2_2       Format = "ID3v2.2"  // ID3v2.2 tag format.
+	ID3v2_3       Format = "ID3v2.3"  // ID3v2.3 tag format (most common).
+	ID3v2_4       Format = "ID3v2.4"  // ID3v2.4 tag format.
+	MP4           Format = "MP4"      // MP4 tag (atom) format (see http://www.ftyps.com/ for a full file type list)
+	MATROSKA      Format = "MATROSKA" // Matroska tag (SimpleTag) format
+	ASF           Format = "ASF"      // ASF content description and attribute format
+	APEv2         Format = "APEv2"    // APEv2 tag format
+	CAFInfo       Format = "CAFInfo"  // CAF information chunk format
+	CUE           Format = "CUE"      // Cue sheet format
+	VORBIS        Format = "VORBIS"   // Vorbis Comment tag format.
 )
 
 // FileType is an enumeration of the audio file types supported by this package, in particular
@@ -30,12 +35,35 @@ const (
 	M4A             FileType = "M4A"  // M4A file Apple iTunes (ACC) Audio
 	M4B             FileType = "M4B"  // M4A file Apple iTunes (ACC) Audio Book
 	M4P             FileType = "M4P"  // M4A file Apple iTunes (ACC) AES Protected Audio
-	ALAC            FileType = "ALAC" // Apple Lossless file FIXME: actually detect this
+	ALAC            FileType = "ALAC" // Apple Lossless file
 	FLAC            FileType = "FLAC" // FLAC file
 	OGG             FileType = "OGG"  // OGG file
 	DSF             FileType = "DSF"  // DSF file DSD Sony format see https://dsd-guide.com/sites/default/files/white-papers/DSFFileFormatSpec_E.pdf
+	MKA             FileType = "MKA"  // Matroska audio file
+	WEBM            FileType = "WEBM" // WebM file
+	WMA             FileType = "WMA"  // Windows Media Audio file
+	AAC             FileType = "AAC"  // Raw AAC file made up of ADTS frames
+	MPC             FileType = "MPC"  // Musepack file
+	TTA             FileType = "TTA"  // True Audio file
+	CAF             FileType = "CAF"  // Apple Core Audio Format file
 )
 
+// GaplessInfo describes the samples that an encoder added to the audio, which a
+// player needs to remove for gapless playback. All values are samples per channel.
+type GaplessInfo struct {
+	EncoderDelay int   // Samples added at the start of the audio.
+	Padding      int   // Samples added at the end of the audio.
+	ValidSamples int64 // Samples of the original audio, or zero if unavailable.
+}
+
+// Chapter is a chapter (or other marked section) of an audio file.
+type Chapter struct {
+	Index int           // Position of the chapter, starting from 1.
+	Title string        // Title of the chapter, or an empty string if unavailable.
+	Start time.Duration // Start of the chapter from the beginning of the audio.
+	End   time.Duration // End of the chapter, or zero if unavailable.
+}
+
 // Metadata is an interface which is used to describe metadata retrieved by this package.
 type Metadata interface {
 	// Album returns the album name of the track.
@@ -46,6 +74,8 @@ type Metadata interface {
 	Artist() string
 	// AverageBitrate returns the average bitrate of the file in bits per second
 	AverageBitrate() int
+	// Chapters returns the chapters of the track in order, or nil if there are none.
+	Chapters() []Chapter
 	// Comment returns the comment, or an empty string if unavailable.
 	Comment() string
 	// Composer returns the composer of the track.
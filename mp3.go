@@ -12,7 +12,12 @@ type MP3Metadata struct {
 	id3v2Tags   *id3v2Tags
 	fileSize    int64
 	frameHeader mpegFrameHeader
+	//frameOffset is the offset in the file of the first frame header
+	frameOffset int64
+	frameScan   mp3FrameScan
 	id3v1tags   id3v1tags
+	lyrics3Tags lyrics3Tags
+	vbriHeader  mp3VBRIHeader
 	xingHeader  mp3XingHeader
 }
 
@@ -56,15 +61,47 @@ func ReadFromMP3(file *os.File) (*MP3Metadata, error) {
 		return nil, err
 	}
 	m.id3v1tags = id3v1
+	//Look for a Lyrics3 tag before the ID3v1 tag
+	lyrics3, err := ReadLyrics3Tags(file)
+	if err != nil {
+		return nil, err
+	}
+	m.lyrics3Tags = lyrics3
 	return &m, nil
 }
 
+//ReadFromMP3Accurate reads the metadata of an mp3 file like ReadFromMP3, and
+//also reads every frame header in the file. This is much slower, but gives the
+//exact frame count, sample count, duration and average bitrate even for
+//variable bitrate files without a Xing header and for files with garbage
+//between frames.
+func ReadFromMP3Accurate(file *os.File) (*MP3Metadata, error) {
+	m, err := ReadFromMP3(file)
+	if err != nil {
+		return nil, err
+	}
+	_, err = file.Seek(m.audioStart(), io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	m.frameScan, err = scanMPEGFrames(file, m.approximateAudioSize())
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (m *MP3Metadata) readFrame(r io.ReadSeeker) error {
 	frameHeader, err := readMPEGFrameHeader(r)
 	if err != nil {
 		return err
 	}
 	m.frameHeader = frameHeader
+	pos, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	m.frameOffset = pos - 4
 	_, err = r.Seek(int64(m.frameHeader.sideInfoLength()), io.SeekCurrent)
 	if err != nil {
 		return err
@@ -93,6 +130,25 @@ func (m *MP3Metadata) readFrame(r io.ReadSeeker) error {
 		}
 
 		m.xingHeader = xingHeader
+	} else {
+		//Else look for a VBRI header, which is 32 bytes after the frame header
+		_, err = r.Seek(int64(32-m.frameHeader.sideInfoLength()-6), io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		b, err := readBytes(r, 4)
+		if err != nil {
+			//A short file with no VBRI header
+			return nil
+		}
+		if string(b) == "VBRI" {
+			r.Seek(-4, io.SeekCurrent)
+			vbriHeader, err := readMP3VBRIHeader(r)
+			if err != nil {
+				return err
+			}
+			m.vbriHeader = vbriHeader
+		}
 	}
 	return err
 }
@@ -100,14 +156,19 @@ func (m *MP3Metadata) readFrame(r io.ReadSeeker) error {
 // return the approximate size of audio data in bytes
 func (m MP3Metadata) approximateAudioSize() int64 {
 	var v1TagSize int64 = 0
-	var v2TagSize int64 = 0
 	if m.id3v1tags != nil {
 		v1TagSize = 128
 	}
+	return m.fileSize - v1TagSize - m.audioStart() - m.lyrics3Tags.Size()
+}
+
+//audioStart returns the offset of the data after the ID3v2 tag, where the
+//audio frames begin.
+func (m MP3Metadata) audioStart() int64 {
 	if m.id3v2Tags != nil {
-		v2TagSize = 10 + int64(m.id3v2Tags.header.size)
+		return 10 + int64(m.id3v2Tags.header.size)
 	}
-	return m.fileSize - v1TagSize - v2TagSize
+	return 0
 }
 
 func (m MP3Metadata) Album() string {
@@ -137,29 +198,45 @@ func (m MP3Metadata) Artist() string {
 }
 
 func (m MP3Metadata) AverageBitrate() int {
-	// If we have a XingHeader with the Xing ID then assume VBR and calculate the
-	// average bitrate
-	if m.xingHeader != nil {
-		if m.xingHeader.ID() == "Xing" {
-			durationInSeconds := m.Duration().Seconds()
-			if durationInSeconds == 0 {
-				return 0
-			}
-			//audioDataSize in bits, check to see if we have value from Xing, if not
-			//then use the approximate value
-			var audioDataSize float64
-			if m.xingHeader.TotalBytes() != nil {
-				audioDataSize = float64(*m.xingHeader.TotalBytes() * 8)
-			} else {
-				audioDataSize = float64(m.approximateAudioSize() * 8)
-			}
-			return int(audioDataSize / durationInSeconds)
+	//If every frame was read then the exact value is known
+	if m.frameScan != nil {
+		return m.frameScan.AverageBitrate()
+	}
+	// If we have a XingHeader with the Xing ID, or a VBRI header, then assume VBR
+	// and calculate the average bitrate
+	var (
+		vbr        bool
+		totalBytes *int
+	)
+	if m.xingHeader != nil && m.xingHeader.ID() == "Xing" {
+		vbr, totalBytes = true, m.xingHeader.TotalBytes()
+	} else if m.vbriHeader != nil {
+		vbr, totalBytes = true, m.vbriHeader.TotalBytes()
+	}
+	if vbr {
+		durationInSeconds := m.Duration().Seconds()
+		if durationInSeconds == 0 {
+			return 0
 		}
+		//audioDataSize in bits, check to see if we have value from Xing or VBRI, if
+		//not then use the approximate value
+		var audioDataSize float64
+		if totalBytes != nil {
+			audioDataSize = float64(*totalBytes * 8)
+		} else {
+			audioDataSize = float64(m.approximateAudioSize() * 8)
+		}
+		return int(audioDataSize / durationInSeconds)
 	}
 	//Else we assume constant bitrate
 	return m.frameHeader.Bitrate() * 1000
 }
 
+//Chapters returns the chapters from the CHAP frames of the ID3v2 tag.
+func (m MP3Metadata) Chapters() []Chapter {
+	return chaptersFromID3v2(m.id3v2Tags, m.Duration())
+}
+
 func (m MP3Metadata) Comment() string {
 	if m.id3v2Tags != nil {
 		return m.id3v2Tags.Comment()
@@ -213,6 +290,9 @@ var samplesPerFrameMap = map[MPEGVersion]map[MPEGLayer]int{
 }
 
 func (m MP3Metadata) Duration() time.Duration {
+	if m.frameScan != nil {
+		return m.frameScan.Duration()
+	}
 	if m.frameHeader.SampleRate() <= 0 {
 		return time.Duration(0)
 	}
@@ -221,6 +301,15 @@ func (m MP3Metadata) Duration() time.Duration {
 	//numFrames, framesOk := m.xingHeader["numberOfFrames"].(int)
 	numBytes := m.xingHeader.TotalBytes()
 	//numBytes, bytesOk := m.xingHeader["numberOfBytes"].(int)
+	//Fraunhofer encoded files have a VBRI header instead of a Xing header
+	if m.vbriHeader != nil {
+		if numFrames == nil {
+			numFrames = m.vbriHeader.TotalFrames()
+		}
+		if numBytes == nil {
+			numBytes = m.vbriHeader.TotalBytes()
+		}
+	}
 	if numFrames != nil && m.frameHeader.SampleRate() > 0 {
 		seconds = float64(*numFrames*m.frameHeader.SamplesPerFrame()) / float64(m.frameHeader.SampleRate())
 	} else if m.frameHeader.Bitrate() <= 0 {
@@ -233,6 +322,30 @@ func (m MP3Metadata) Duration() time.Duration {
 	return time.Duration(seconds * float64(time.Second))
 }
 
+//FrameScan returns the results of reading every frame header when the file was
+//read with ReadFromMP3Accurate, or nil otherwise.
+func (m MP3Metadata) FrameScan() map[string]interface{} {
+	return m.frameScan
+}
+
+//GaplessInfo returns the encoder delay and padding from the LAME extension of
+//the Xing or Info header, or nil if there is none. The delay of the decoder,
+//which is 529 samples for most decoders, is not included.
+func (m MP3Metadata) GaplessInfo() *GaplessInfo {
+	l := m.xingHeader.LAMEHeader()
+	if l == nil {
+		return nil
+	}
+	g := &GaplessInfo{
+		EncoderDelay: l.EncoderDelay(),
+		Padding:      l.Padding(),
+	}
+	if total := m.TotalSamples(); total > int64(g.EncoderDelay+g.Padding) {
+		g.ValidSamples = total - int64(g.EncoderDelay+g.Padding)
+	}
+	return g
+}
+
 func (m MP3Metadata) Genre() string {
 	if m.id3v2Tags != nil {
 		return m.id3v2Tags.Genre()
@@ -262,6 +375,16 @@ func (m MP3Metadata) Format() Format {
 	return UnknownFormat
 }
 
+//ID3v2Chapters returns the chapters from the CHAP frames of the ID3v2 tag.
+//Chapters listed in a top-level, ordered table of contents come first in its
+//order, followed by any others sorted by start time.
+func (m MP3Metadata) ID3v2Chapters() []*Chap {
+	if m.id3v2Tags != nil {
+		return m.id3v2Tags.Chapters()
+	}
+	return nil
+}
+
 func (m MP3Metadata) ID3v2Frames() map[string]interface{} {
 	if m.id3v2Tags != nil {
 		return m.id3v2Tags.frames
@@ -269,12 +392,25 @@ func (m MP3Metadata) ID3v2Frames() map[string]interface{} {
 	return nil
 }
 
+//LAMEHeader returns the LAME extension of the Xing or Info header, which has
+//the encoder version, encoder delay and padding, preset and other encoding
+//details, or nil if there is none.
+func (m MP3Metadata) LAMEHeader() MP3LAMEHeader {
+	return m.xingHeader.LAMEHeader()
+}
+
 func (m MP3Metadata) Lyrics() string {
-	if m.id3v2Tags != nil {
+	if m.id3v2Tags != nil && m.id3v2Tags.Lyrics() != "" {
 		return m.id3v2Tags.Lyrics()
 	}
-	//No equivalent value for ID3v1
-	return ""
+	//No equivalent value for ID3v1, but there may be a Lyrics3 tag
+	return m.lyrics3Tags.Lyrics()
+}
+
+//Lyrics3Fields returns the fields from the Lyrics3 tag found at the end of the
+//file, keyed by field ID, e.g. LYR, ETT, EAR, etc.
+func (m MP3Metadata) Lyrics3Fields() map[string]interface{} {
+	return m.lyrics3Tags
 }
 
 func (m MP3Metadata) Picture() *Picture {
@@ -294,6 +430,31 @@ func (m MP3Metadata) Title() string {
 	return ""
 }
 
+//TotalFrames returns the number of audio frames, which is exact when the file
+//was read with ReadFromMP3Accurate and otherwise comes from the Xing header, if
+//there is one, or the VBRI header.
+func (m MP3Metadata) TotalFrames() int {
+	if m.frameScan != nil {
+		return m.frameScan.TotalFrames()
+	}
+	if tf := m.xingHeader.TotalFrames(); tf != nil {
+		return *tf
+	}
+	if tf := m.vbriHeader.TotalFrames(); tf != nil {
+		return *tf
+	}
+	return 0
+}
+
+//TotalSamples returns the number of samples per channel in the audio frames,
+//calculated as for TotalFrames.
+func (m MP3Metadata) TotalSamples() int64 {
+	if m.frameScan != nil {
+		return m.frameScan.TotalSamples()
+	}
+	return int64(m.TotalFrames()) * int64(m.frameHeader.SamplesPerFrame())
+}
+
 func (m MP3Metadata) Track() (int, int) {
 	if m.id3v2Tags != nil {
 		return m.id3v2Tags.Track()
@@ -303,6 +464,12 @@ func (m MP3Metadata) Track() (int, int) {
 	return 0, 0
 }
 
+//VBRIHeader returns the information in the VBRI header of the first frame that
+//Fraunhofer encoders write for variable bitrate files, or nil if there is none.
+func (m MP3Metadata) VBRIHeader() map[string]interface{} {
+	return m.vbriHeader
+}
+
 func (m MP3Metadata) Year() int {
 	if m.id3v2Tags != nil {
 		return m.id3v2Tags.Year()
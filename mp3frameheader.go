@@ -22,13 +22,12 @@ func readMPEGFrameHeader(r io.Reader) (mpegFrameHeader, error) {
 	var (
 		numBytesToRead uint = 4
 		buff           []byte
-		fh             = mpegFrameHeader{}
 	)
 	//Read bytes until we find a frame sync match
 	for {
 		b, err := readBytes(r, numBytesToRead)
 		if err != nil {
-			return fh, err
+			return mpegFrameHeader{}, err
 		}
 		//This is always expected to fill buff to exactly 4 bytes
 		buff = append(buff, b...)
@@ -50,6 +49,13 @@ func readMPEGFrameHeader(r io.Reader) (mpegFrameHeader, error) {
 			buff = []byte{}
 		}
 	}
+	return parseMPEGFrameHeader(buff), nil
+}
+
+//parseMPEGFrameHeader reads the fields of a 4 byte frame header, without
+//checking the frame sync or whether the values are valid.
+func parseMPEGFrameHeader(buff []byte) mpegFrameHeader {
+	fh := mpegFrameHeader{}
 	fh[VersionKey] = (buff[1] >> 3) & 0x03      //AAABB>>> & 00000011
 	fh["layer"] = (buff[1] >> 1) & 0x03         //AAABBCC> & 00000011
 	fh["protected"] = buff[1] & 0x01            //AAABBCCD & 00000001
@@ -62,7 +68,7 @@ func readMPEGFrameHeader(r io.Reader) (mpegFrameHeader, error) {
 	fh["copyright"] = (buff[3] >> 3) & 0x01     //IIJJK>>> & 00000001
 	fh["original"] = (buff[3] >> 2) & 0x01      //IIJJKL>> & 00000001
 	fh["emphasis"] = buff[3] & 0x03             //IIJJKLMM & 00000011
-	return fh, nil
+	return fh
 }
 
 func (fh mpegFrameHeader) Bitrate() int {
@@ -146,6 +152,26 @@ func (fh mpegFrameHeader) Emphasis() MPEGEmphasis {
 	return ""
 }
 
+//FrameSize returns the length of the frame in bytes, including the header, as
+//given by the bitrate, sample rate and padding. Zero is returned if the size
+//can't be calculated, such as for free format frames.
+func (fh mpegFrameHeader) FrameSize() int {
+	bitrate := fh.Bitrate() * 1000
+	sampleRate := fh.SampleRate()
+	if bitrate <= 0 || sampleRate <= 0 {
+		return 0
+	}
+	padding := 0
+	if fh.Padded() {
+		padding = 1
+	}
+	if fh.Layer() == MPEGLayer1 {
+		//Layer I frames are made up of 4 byte slots
+		return (fh.SamplesPerFrame()/32*bitrate/sampleRate + padding) * 4
+	}
+	return fh.SamplesPerFrame()/8*bitrate/sampleRate + padding
+}
+
 func (fh mpegFrameHeader) Layer() MPEGLayer {
 	l, ok := fh["layer"].(byte)
 	if !ok {
@@ -263,6 +289,18 @@ func (fh mpegFrameHeader) SamplesPerFrame() int {
 	return spf
 }
 
+//isValid returns whether the header has a known version, layer, bitrate and
+//sample rate, which is needed to find the size of the frame.
+func (fh mpegFrameHeader) isValid() bool {
+	return fh.Version() != MPEGVersionReserved && fh.Layer() != MPEGLayerReserved && fh.FrameSize() > 0
+}
+
+//matches returns whether the version, layer and sample rate of two headers are
+//the same, which is the case for all frames of a stream.
+func (fh mpegFrameHeader) matches(other mpegFrameHeader) bool {
+	return fh.Version() == other.Version() && fh.Layer() == other.Layer() && fh.SampleRate() == other.SampleRate()
+}
+
 //Returns the length of the side information based on version and channel mode.
 func (fh mpegFrameHeader) sideInfoLength() int {
 	if fh.Layer() != MPEGLayer3 {
@@ -0,0 +1,271 @@
+package yurit
+
+import (
+	"bufio"
+	"io"
+)
+
+//MPEGIntegrityReport is the result of checking every frame of an MPEG audio
+//stream. The stream is free of errors when there are no issues.
+type MPEGIntegrityReport struct {
+	//Frames is the number of audio frames found, not counting a Xing, Info or
+	//VBRI information frame
+	Frames int
+	//CheckedFrames is the number of frames with a CRC that was verified
+	CheckedFrames int
+	Issues        []MPEGIntegrityIssue
+}
+
+//MPEGIntegrityIssue is a problem found in an MPEG audio stream. Offset is from
+//the beginning of the file, and Size is the number of bytes affected.
+type MPEGIntegrityIssue struct {
+	Type   MPEGIntegrityIssueType
+	Offset int64
+	Size   int64
+}
+
+//MPEGIntegrityIssueType is the kind of problem found in an MPEG audio stream
+type MPEGIntegrityIssueType string
+
+//All possible integrity issue types
+const (
+	//The CRC of a frame doesn't match its data
+	MPEGIssueCRCMismatch MPEGIntegrityIssueType = "CRC mismatch"
+	//A frame isn't followed by another frame header, with a size of 0
+	MPEGIssueLostSync MPEGIntegrityIssueType = "Lost sync"
+	//Bytes before, between or after the frames that don't belong to any frame
+	MPEGIssueJunk MPEGIntegrityIssueType = "Junk"
+	//The last frame is cut short by the end of the audio
+	MPEGIssueTruncatedFrame MPEGIntegrityIssueType = "Truncated frame"
+	//A frame has a different version, layer or sample rate than the frames
+	//before it
+	MPEGIssueStreamChange MPEGIntegrityIssueType = "Stream change"
+)
+
+//OK returns whether no issues were found.
+func (r MPEGIntegrityReport) OK() bool {
+	return len(r.Issues) == 0
+}
+
+//CheckIntegrity reads every frame of the audio in r, the file that m was read
+//from, verifying the CRC of each frame that has one and reporting where the
+//stream loses sync, holds junk, changes its version, layer or sample rate, or
+//ends with a truncated frame. Tags at the beginning and end of the file are not
+//part of the audio and aren't reported.
+func (m MP3Metadata) CheckIntegrity(r io.ReadSeeker) (*MPEGIntegrityReport, error) {
+	_, err := r.Seek(m.audioStart(), io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	return checkMPEGIntegrity(r, m.audioStart(), m.approximateAudioSize())
+}
+
+//checkMPEGIntegrity checks the n bytes of r from its current position, which is
+//at start in the file. Frames are found with peekMPEGFrame like in
+//scanMPEGFrames, so after sync is lost the next frame must be followed by
+//another frame header before it is accepted.
+func checkMPEGIntegrity(r io.Reader, start int64, n int64) (*MPEGIntegrityReport, error) {
+	br := bufio.NewReaderSize(io.LimitReader(r, n), mpegScanBufferSize)
+	report := &MPEGIntegrityReport{}
+	var (
+		current   mpegFrameHeader
+		offset    = start
+		inSync    bool
+		junkStart int64 = -1
+	)
+	addIssue := func(t MPEGIntegrityIssueType, offset int64, size int64) {
+		report.Issues = append(report.Issues, MPEGIntegrityIssue{Type: t, Offset: offset, Size: size})
+	}
+	endJunk := func() {
+		if junkStart >= 0 {
+			addIssue(MPEGIssueJunk, junkStart, offset-junkStart)
+			junkStart = -1
+		}
+	}
+	skipByte := func() {
+		if junkStart < 0 {
+			if inSync {
+				addIssue(MPEGIssueLostSync, offset, 0)
+			}
+			junkStart = offset
+		}
+		inSync = false
+		br.Discard(1)
+		offset++
+	}
+	for {
+		fh, frame, err := peekMPEGFrame(br, inSync)
+		if err == io.EOF {
+			if len(frame) > 0 {
+				endJunk()
+				//Not even a whole header is left
+				addIssue(MPEGIssueTruncatedFrame, offset, int64(len(frame)))
+			}
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if fh == nil {
+			skipByte()
+			continue
+		}
+		size := fh.FrameSize()
+		if len(frame) < size {
+			if !inSync && (current == nil || !fh.matches(current)) {
+				//Most likely a false frame sync in junk at the end of the audio
+				skipByte()
+				continue
+			}
+			endJunk()
+			addIssue(MPEGIssueTruncatedFrame, offset, int64(len(frame)))
+			offset += int64(len(frame))
+			break
+		}
+		endJunk()
+		inSync = true
+		infoFrame := false
+		if current == nil {
+			infoFrame = isMPEGInfoFrame(frame[:size], fh)
+		} else if !fh.matches(current) {
+			addIssue(MPEGIssueStreamChange, offset, int64(size))
+		}
+		current = fh
+		if !infoFrame {
+			report.Frames++
+			if fh.Protected() {
+				if ok, checked := checkMPEGFrameCRC(frame[:size], fh); checked {
+					report.CheckedFrames++
+					if !ok {
+						addIssue(MPEGIssueCRCMismatch, offset, int64(size))
+					}
+				}
+			}
+		}
+		br.Discard(size)
+		offset += int64(size)
+	}
+	endJunk()
+	return report, nil
+}
+
+//checkMPEGFrameCRC returns whether the CRC that follows the header of a frame
+//matches the frame, and whether it could be checked at all. The CRC covers the
+//last 2 bytes of the header and the fields that follow the CRC, which are the
+//side information for Layer III, the bit allocation for Layer I and the bit
+//allocation and scale factor selection information for Layer II.
+func checkMPEGFrameCRC(frame []byte, fh mpegFrameHeader) (bool, bool) {
+	bits := 0
+	switch fh.Layer() {
+	case MPEGLayer1:
+		//4 bits of bit allocation for each of the 32 subbands and channels, where
+		//subbands from the bound up are shared by both channels in joint stereo
+		bound := 32
+		if fh.ChannelMode() == MPEGChannelJointStereo {
+			me, _ := fh["modeExtension"].(byte)
+			bound = (int(me) + 1) * 4
+		}
+		if fh.ChannelMode() == MPEGChannelSingle {
+			bits = 4 * 32
+		} else {
+			bits = 4 * (2*bound + 32 - bound)
+		}
+	case MPEGLayer2:
+		bits = mpegLayer2CRCBits(frame, fh)
+	case MPEGLayer3:
+		bits = fh.sideInfoLength() * 8
+	}
+	if bits == 0 || len(frame) < 6+(bits+7)/8 {
+		return false, false
+	}
+	crc := mpegCRC16(0xFFFF, frame[2:4], 16)
+	crc = mpegCRC16(crc, frame[6:], bits)
+	return crc == uint16(getUint16AsInt(frame[4:6])), true
+}
+
+//mpegLayer2AllocationBits holds the number of bits of the bit allocation of
+//each subband in the Layer II bit allocation tables, which are tables B.2a to
+//B.2d of ISO/IEC 11172-3 and table B.1 of ISO/IEC 13818-3 for the lower sample
+//rates of MPEG-2 and 2.5.
+var mpegLayer2AllocationBits = [][]int{
+	{4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 2, 2, 2, 2},
+	{4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 2, 2, 2, 2, 2, 2, 2},
+	{4, 4, 3, 3, 3, 3, 3, 3},
+	{4, 4, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3},
+	{4, 4, 4, 4, 3, 3, 3, 3, 3, 3, 3, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2},
+}
+
+//mpegLayer2CRCBits returns the number of bits after the CRC of a Layer II frame
+//that the CRC covers. These are the bit allocation of each subband, whose size
+//depends on the allocation table picked by the bitrate per channel and the
+//sample rate, and 2 bits of scale factor selection information for each
+//subband and channel with bits allocated. Zero is returned if the frame is too
+//short to hold them.
+func mpegLayer2CRCBits(frame []byte, fh mpegFrameHeader) int {
+	channels := 2
+	if fh.ChannelMode() == MPEGChannelSingle {
+		channels = 1
+	}
+	table := mpegLayer2AllocationBits[4]
+	if fh.Version() == MPEGVersion_1 {
+		bitrate, sampleRate := fh.Bitrate()/channels, fh.SampleRate()
+		switch {
+		case bitrate >= 56 && (bitrate <= 80 || sampleRate == 48000):
+			table = mpegLayer2AllocationBits[0]
+		case bitrate >= 96:
+			table = mpegLayer2AllocationBits[1]
+		case bitrate <= 48 && sampleRate != 32000:
+			table = mpegLayer2AllocationBits[2]
+		default:
+			table = mpegLayer2AllocationBits[3]
+		}
+	}
+	//Subbands from the bound up share their bit allocation in joint stereo
+	bound := len(table)
+	if fh.ChannelMode() == MPEGChannelJointStereo {
+		me, _ := fh["modeExtension"].(byte)
+		if b := (int(me) + 1) * 4; b < bound {
+			bound = b
+		}
+	}
+	pos := 6 * 8
+	scfsiBits := 0
+	for sb, n := range table {
+		allocations := channels
+		if sb >= bound {
+			allocations = 1
+		}
+		for ch := 0; ch < allocations; ch++ {
+			if pos+n > len(frame)*8 {
+				return 0
+			}
+			allocated := false
+			for i := pos; i < pos+n; i++ {
+				allocated = allocated || frame[i/8]>>(7-uint(i%8))&1 != 0
+			}
+			pos += n
+			//A shared bit allocation still has the information for each channel
+			if allocated {
+				scfsiBits += 2 * channels / allocations
+			}
+		}
+	}
+	bits := pos - 6*8 + scfsiBits
+	if len(frame) < 6+(bits+7)/8 {
+		return 0
+	}
+	return bits
+}
+
+//mpegCRC16 updates crc with the first n bits of b, using the CRC-16 polynomial
+//of MPEG audio (x^16 + x^15 + x^2 + 1).
+func mpegCRC16(crc uint16, b []byte, n int) uint16 {
+	for i := 0; i < n; i++ {
+		bit := uint16(b[i/8]>>(7-uint(i%8))) & 1
+		if bit^(crc>>15) != 0 {
+			crc = crc<<1 ^ 0x8005
+		} else {
+			crc <<= 1
+		}
+	}
+	return crc
+}
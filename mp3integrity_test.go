@@ -0,0 +1,155 @@
+package yurit
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestMPEGCRC16(t *testing.T) {
+	if crc := mpegCRC16(0xFFFF, []byte("123456789"), 72); crc != 0xAEE7 {
+		t.Errorf("mpegCRC16() = %#x, expected %#x", crc, 0xAEE7)
+	}
+}
+
+// mpegProtectedTestFrame returns a protected MPEG-1 Layer III frame with the
+// given header bytes and a CRC that is only correct if valid is true.
+func mpegProtectedTestFrame(b2 byte, valid bool) []byte {
+	fh := parseMPEGFrameHeader([]byte{0xFF, 0xFA, b2, 0x64})
+	frame := make([]byte, fh.FrameSize())
+	copy(frame, []byte{0xFF, 0xFA, b2, 0x64})
+	for i := 6; i < len(frame); i++ {
+		frame[i] = byte(i)
+	}
+	crc := mpegCRC16(0xFFFF, frame[2:4], 16)
+	crc = mpegCRC16(crc, frame[6:], fh.sideInfoLength()*8)
+	if !valid {
+		crc++
+	}
+	frame[4], frame[5] = byte(crc>>8), byte(crc)
+	return frame
+}
+
+func TestCheckMPEGIntegrity(t *testing.T) {
+	var b []byte
+	//44.1 kHz frames of 417 bytes, the third with a bad CRC
+	b = append(b, mpegProtectedTestFrame(0x90, true)...)
+	b = append(b, mpegProtectedTestFrame(0x90, true)...)
+	b = append(b, mpegProtectedTestFrame(0x90, false)...)
+	b = append(b, []byte("garbage")...)
+	b = append(b, mpegProtectedTestFrame(0x90, true)...)
+	b = append(b, mpegProtectedTestFrame(0x90, true)...)
+	//48 kHz frames of 384 bytes
+	b = append(b, mpegProtectedTestFrame(0x94, true)...)
+	b = append(b, mpegProtectedTestFrame(0x94, true)...)
+	b = append(b, mpegProtectedTestFrame(0x94, true)[:100]...)
+
+	report, err := checkMPEGIntegrity(bytes.NewReader(b), 10, int64(len(b)))
+	if err != nil {
+		t.Fatalf("checkMPEGIntegrity() returned error: %v", err)
+	}
+	if report.Frames != 7 || report.CheckedFrames != 7 {
+		t.Errorf("checkMPEGIntegrity() found %d frames and checked %d, expected %d and %d", report.Frames, report.CheckedFrames, 7, 7)
+	}
+	expected := []MPEGIntegrityIssue{
+		{MPEGIssueCRCMismatch, 10 + 834, 417},
+		{MPEGIssueLostSync, 10 + 1251, 0},
+		{MPEGIssueJunk, 10 + 1251, 7},
+		{MPEGIssueStreamChange, 10 + 2092, 384},
+		{MPEGIssueTruncatedFrame, 10 + 2860, 100},
+	}
+	if !reflect.DeepEqual(report.Issues, expected) {
+		t.Errorf("checkMPEGIntegrity() issues = %v, expected %v", report.Issues, expected)
+	}
+	if report.OK() {
+		t.Errorf("OK() = true, expected false")
+	}
+}
+
+func TestMPEGLayer2CRCBits(t *testing.T) {
+	tests := []struct {
+		header []byte
+		fill   byte
+		bits   int
+	}{
+		//192 kbit/s stereo at 44.1 kHz uses table B.2b with 30 subbands
+		{[]byte{0xFF, 0xFC, 0xA0, 0x00}, 0x00, 2 * 94},
+		//Single channel, with bits allocated to every subband
+		{[]byte{0xFF, 0xFC, 0xA0, 0xC0}, 0x00, 94},
+		{[]byte{0xFF, 0xFC, 0xA0, 0xC0}, 0xFF, 94 + 30*2},
+		//Joint stereo with a bound of 4 subbands
+		{[]byte{0xFF, 0xFC, 0xA0, 0x40}, 0x00, 4*2*4 + 78},
+		{[]byte{0xFF, 0xFC, 0xA0, 0x40}, 0xFF, 4*2*4 + 78 + 30*2*2},
+		//192 kbit/s stereo at 48 kHz uses table B.2a with 27 subbands
+		{[]byte{0xFF, 0xFC, 0xA4, 0x00}, 0x00, 2 * 88},
+		//64 kbit/s stereo at 44.1 kHz uses table B.2c with 8 subbands
+		{[]byte{0xFF, 0xFC, 0x40, 0x00}, 0x00, 2 * 26},
+		//64 kbit/s stereo at 32 kHz uses table B.2d with 12 subbands
+		{[]byte{0xFF, 0xFC, 0x48, 0x00}, 0x00, 2 * 38},
+		//MPEG-2 at 22.05 kHz uses the table of ISO/IEC 13818-3
+		{[]byte{0xFF, 0xF4, 0x80, 0x00}, 0x00, 2 * 75},
+	}
+
+	for ii, tt := range tests {
+		fh := parseMPEGFrameHeader(tt.header)
+		frame := append(append([]byte{}, tt.header...), bytes.Repeat([]byte{tt.fill}, fh.FrameSize()-4)...)
+		if bits := mpegLayer2CRCBits(frame, fh); bits != tt.bits {
+			t.Errorf("[%d] mpegLayer2CRCBits() = %d, expected %d", ii, bits, tt.bits)
+		}
+		if bits := mpegLayer2CRCBits(frame[:8], fh); bits != 0 {
+			t.Errorf("[%d] mpegLayer2CRCBits() of a short frame = %d, expected 0", ii, bits)
+		}
+	}
+}
+
+func TestCheckMPEGLayer2FrameCRC(t *testing.T) {
+	//192 kbit/s stereo at 44.1 kHz with no bits allocated, so the CRC covers 188
+	//bits of bit allocation
+	fh := parseMPEGFrameHeader([]byte{0xFF, 0xFC, 0xA0, 0x00})
+	frame := make([]byte, fh.FrameSize())
+	copy(frame, []byte{0xFF, 0xFC, 0xA0, 0x00})
+	crc := mpegCRC16(0xFFFF, frame[2:4], 16)
+	crc = mpegCRC16(crc, frame[6:], 188)
+	frame[4], frame[5] = byte(crc>>8), byte(crc)
+
+	tests := []struct {
+		corrupt int
+		ok      bool
+	}{
+		{-1, true},
+		//A bit of the bit allocation
+		{28, false},
+		//Audio data isn't covered by the CRC
+		{30, true},
+	}
+	for ii, tt := range tests {
+		f := append([]byte{}, frame...)
+		if tt.corrupt >= 0 {
+			f[tt.corrupt] |= 0x01
+		}
+		ok, checked := checkMPEGFrameCRC(f, fh)
+		if ok != tt.ok || !checked {
+			t.Errorf("[%d] checkMPEGFrameCRC() = %v, %v, expected %v, %v", ii, ok, checked, tt.ok, true)
+		}
+	}
+}
+
+func TestMP3CheckIntegrity(t *testing.T) {
+	f, err := os.Open("./testdata/with_tags/sample.id3v24.mp3")
+	if err != nil {
+		t.Fatalf("error opening file: %v", err)
+	}
+	defer f.Close()
+	m, err := ReadFromMP3(f)
+	if err != nil {
+		t.Fatalf("ReadFromMP3() returned error: %v", err)
+	}
+	report, err := m.CheckIntegrity(f)
+	if err != nil {
+		t.Fatalf("CheckIntegrity() returned error: %v", err)
+	}
+	if !report.OK() || report.Frames != 132 {
+		t.Errorf("CheckIntegrity() = %v, expected 132 frames and no issues", report)
+	}
+}
@@ -0,0 +1,206 @@
+package yurit
+
+import (
+	"strconv"
+	"strings"
+)
+
+//MP3LAMEHeader is the extension that LAME, and encoders based on it, write after
+//the fields of a Xing or Info header. It holds details of how the file was
+//encoded, including the encoder delay and padding needed for gapless playback.
+//The raw values are kept in the map, and the methods decode them.
+//http://gabriel.mp3-tech.org/mp3infotag.html
+type MP3LAMEHeader map[string]interface{}
+
+//mp3LAMEHeaderSize is the length in bytes of the LAME extension.
+const mp3LAMEHeaderSize = 36
+
+//isMP3LAMEHeader returns whether the bytes after the Xing fields look like a
+//LAME extension, by checking for the encoder strings of LAME and of the FFmpeg
+//libraries which write the same extension.
+func isMP3LAMEHeader(b []byte) bool {
+	for _, prefix := range []string{"LAME", "L3.9", "Lavf", "Lavc", "GOGO"} {
+		if strings.HasPrefix(string(b), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+//processMP3LAMEHeader reads the 36 bytes of a LAME extension.
+//Encoder 9, revision and VBR method 1, lowpass 1, peak signal amplitude 4,
+//radio replay gain 2, audiophile replay gain 2, encoding flags and ATH type 1,
+//bitrate 1, encoder delay and padding 3, misc 1, MP3 gain 1, preset and
+//surround 2, music length 4, music CRC 2, info tag CRC 2
+func processMP3LAMEHeader(b []byte) MP3LAMEHeader {
+	l := MP3LAMEHeader{}
+	l["encoder"] = getString(b[0:9])
+	l[RevisionKey] = int(b[9] >> 4)
+	l["vbrMethod"] = int(b[9] & 0x0F)
+	l["lowpass"] = int(b[10]) * 100
+	//1.0 is the largest amplitude, stored as a fixed point number with 23 bits
+	//after the point
+	l["peakSignalAmplitude"] = float64(getUint32AsInt64(b[11:15])) / (1 << 23)
+	if gain, ok := mp3ReplayGain(b[15:17]); ok {
+		l["radioReplayGain"] = gain
+	}
+	if gain, ok := mp3ReplayGain(b[17:19]); ok {
+		l["audiophileReplayGain"] = gain
+	}
+	l["encodingFlags"] = int(b[19] >> 4)
+	l["athType"] = int(b[19] & 0x0F)
+	l["bitrate"] = int(b[20])
+	l["encoderDelay"] = int(b[21])<<4 | int(b[22]>>4)
+	l["padding"] = int(b[22]&0x0F)<<8 | int(b[23])
+	l["noiseShaping"] = int(b[24] & 0x03)
+	l["stereoMode"] = int((b[24] >> 2) & 0x07)
+	l["unwiseSettings"] = getBit(b[24], 5)
+	l["sourceSampleRate"] = int(b[24] >> 6)
+	//The MP3 gain is a signed number of 1.5 dB steps
+	l["mp3Gain"] = float64(int8(b[25])) * 1.5
+	l["surround"] = int((b[26] >> 3) & 0x07)
+	l["preset"] = getUint16AsInt(b[26:28]) & 0x07FF
+	l["musicLength"] = getUint32AsInt64(b[28:32])
+	l["musicCRC"] = getUint16AsInt(b[32:34])
+	l["infoTagCRC"] = getUint16AsInt(b[34:36])
+	return l
+}
+
+//mp3ReplayGain reads a ReplayGain field of a LAME extension, returning the gain
+//in dB and false if the field is not set.
+//Name 3 bits, originator 3 bits, sign 1 bit, gain 9 bits in 0.1 dB
+func mp3ReplayGain(b []byte) (float64, bool) {
+	name := b[0] >> 5
+	if name == 0 {
+		return 0, false
+	}
+	gain := float64(int(b[0]&0x01)<<8|int(b[1])) / 10
+	if getBit(b[0], 1) {
+		gain = -gain
+	}
+	return gain, true
+}
+
+//lameVBRMethods maps the VBR method numbers of a LAME extension to names.
+var lameVBRMethods = map[int]string{
+	0: "unknown",
+	1: "CBR",
+	2: "ABR",
+	3: "VBR method 1 (old/rh)",
+	4: "VBR method 2 (mtrh)",
+	5: "VBR method 3 (mt)",
+	6: "VBR method 4",
+	8: "CBR (2 pass)",
+	9: "ABR (2 pass)",
+}
+
+//lamePresets maps the preset numbers of a LAME extension to names. Numbers from
+//8 to 320 are ABR presets for that bitrate.
+var lamePresets = map[int]string{
+	410:  "V9",
+	420:  "V8",
+	430:  "V7",
+	440:  "V6",
+	450:  "V5",
+	460:  "V4",
+	470:  "V3",
+	480:  "V2",
+	490:  "V1",
+	500:  "V0",
+	1000: "r3mix",
+	1001: "standard",
+	1002: "extreme",
+	1003: "insane",
+	1004: "standard/fast",
+	1005: "extreme/fast",
+	1006: "medium",
+	1007: "medium/fast",
+}
+
+//lameStereoModes maps the stereo mode numbers of a LAME extension to names.
+var lameStereoModes = []string{"mono", "stereo", "dual", "joint", "force", "auto", "intensity", "undefined"}
+
+//lameSourceSampleRates maps the source sample rate numbers of a LAME extension
+//to descriptions.
+var lameSourceSampleRates = []string{"32 kHz or less", "44.1 kHz", "48 kHz", "over 48 kHz"}
+
+//Bitrate returns the bitrate in kbps that was given to the encoder, which is
+//the target for ABR and the minimum for VBR. 255 means 255 kbps or more.
+func (l MP3LAMEHeader) Bitrate() int {
+	b, _ := l["bitrate"].(int)
+	return b
+}
+
+//Encoder returns the short version string of the encoder, e.g. LAME3.99r.
+func (l MP3LAMEHeader) Encoder() string {
+	e, _ := l["encoder"].(string)
+	return e
+}
+
+//EncoderDelay returns the number of samples added by the encoder at the start
+//of the audio.
+func (l MP3LAMEHeader) EncoderDelay() int {
+	d, _ := l["encoderDelay"].(int)
+	return d
+}
+
+//Lowpass returns the lowpass filter frequency in Hz, or zero if unknown.
+func (l MP3LAMEHeader) Lowpass() int {
+	lp, _ := l["lowpass"].(int)
+	return lp
+}
+
+//MusicLength returns the length in bytes of the audio, from the first byte of
+//the info frame to the last byte of the last audio frame.
+func (l MP3LAMEHeader) MusicLength() int64 {
+	ml, _ := l["musicLength"].(int64)
+	return ml
+}
+
+//Padding returns the number of samples added by the encoder at the end of the
+//audio to fill the last frame.
+func (l MP3LAMEHeader) Padding() int {
+	p, _ := l["padding"].(int)
+	return p
+}
+
+//Preset returns the name of the preset used by the encoder, or an empty string
+//if no preset was used.
+func (l MP3LAMEHeader) Preset() string {
+	p, _ := l["preset"].(int)
+	if name, ok := lamePresets[p]; ok {
+		return name
+	}
+	if p >= 8 && p <= 320 {
+		return "ABR " + strconv.Itoa(p)
+	}
+	return ""
+}
+
+//SourceSampleRate returns a description of the sample rate of the audio before
+//it was encoded.
+func (l MP3LAMEHeader) SourceSampleRate() string {
+	s, ok := l["sourceSampleRate"].(int)
+	if !ok || s >= len(lameSourceSampleRates) {
+		return ""
+	}
+	return lameSourceSampleRates[s]
+}
+
+//StereoMode returns the stereo mode that was given to the encoder.
+func (l MP3LAMEHeader) StereoMode() string {
+	s, ok := l["stereoMode"].(int)
+	if !ok || s >= len(lameStereoModes) {
+		return ""
+	}
+	return lameStereoModes[s]
+}
+
+//VBRMethod returns the name of the bitrate mode used by the encoder.
+func (l MP3LAMEHeader) VBRMethod() string {
+	v, _ := l["vbrMethod"].(int)
+	if name, ok := lameVBRMethods[v]; ok {
+		return name
+	}
+	return "reserved"
+}
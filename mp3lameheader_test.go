@@ -0,0 +1,69 @@
+package yurit
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestProcessMP3LAMEHeader(t *testing.T) {
+	b := []byte("LAME3.99r")
+	b = append(b, 0x14, 195)              //revision 1, VBR method 4, 19500 Hz lowpass
+	b = append(b, 0x00, 0x80, 0x00, 0x00) //peak signal amplitude 1.0
+	b = append(b, 0x2E, 0x0F, 0x00, 0x00) //radio replay gain -1.5 dB, no audiophile gain
+	b = append(b, 0x0F, 0x20)             //ATH type 15, 32 kbps
+	b = append(b, 0x24, 0x07, 0x10)       //delay 576, padding 1808
+	b = append(b, 0x4C, 0xFE)             //44.1 kHz, joint stereo, MP3 gain -3 dB
+	b = append(b, 0x01, 0xE0)             //preset V2
+	b = append(b, 0x00, 0x01, 0x00, 0x00) //music length 65536
+	b = append(b, 0x12, 0x34, 0x56, 0x78) //CRCs
+	if !isMP3LAMEHeader(b) {
+		t.Fatalf("isMP3LAMEHeader() = false, expected true")
+	}
+	l := processMP3LAMEHeader(b)
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected interface{}
+	}{
+		{"Encoder", l.Encoder(), "LAME3.99r"},
+		{"VBRMethod", l.VBRMethod(), "VBR method 2 (mtrh)"},
+		{"Lowpass", l.Lowpass(), 19500},
+		{"peakSignalAmplitude", l["peakSignalAmplitude"], 1.0},
+		{"radioReplayGain", l["radioReplayGain"], -1.5},
+		{"audiophileReplayGain", l["audiophileReplayGain"], nil},
+		{"Bitrate", l.Bitrate(), 32},
+		{"EncoderDelay", l.EncoderDelay(), 576},
+		{"Padding", l.Padding(), 1808},
+		{"SourceSampleRate", l.SourceSampleRate(), "44.1 kHz"},
+		{"StereoMode", l.StereoMode(), "joint"},
+		{"mp3Gain", l["mp3Gain"], -3.0},
+		{"Preset", l.Preset(), "V2"},
+		{"MusicLength", l.MusicLength(), int64(65536)},
+		{"musicCRC", l["musicCRC"], 0x1234},
+	}
+	for ii, tt := range tests {
+		if tt.value != tt.expected {
+			t.Errorf("[%d] %s = %v, expected %v", ii, tt.name, tt.value, tt.expected)
+		}
+	}
+}
+
+func TestReadMP3XingHeaderLAME(t *testing.T) {
+	xing := []byte{'I', 'n', 'f', 'o', 0, 0, 0, 0x01, 0, 0, 0, 10}
+	lame := append([]byte("LAME3.100"), make([]byte, 27)...)
+	x, err := readMP3XingHeader(bytes.NewReader(append(xing, lame...)))
+	if err != nil {
+		t.Fatalf("readMP3XingHeader() returned error: %v", err)
+	}
+	if x.LAMEHeader().Encoder() != "LAME3.100" {
+		t.Errorf("LAMEHeader().Encoder() = %q, expected %q", x.LAMEHeader().Encoder(), "LAME3.100")
+	}
+	m := MP3Metadata{xingHeader: x}
+	if m.LAMEHeader().Encoder() != "LAME3.100" || m.LAMEHeader().Preset() != "" {
+		t.Errorf("MP3Metadata.LAMEHeader() encoder and preset = %q, %q, expected %q, %q", m.LAMEHeader().Encoder(), m.LAMEHeader().Preset(), "LAME3.100", "")
+	}
+	x, err = readMP3XingHeader(bytes.NewReader(xing))
+	if err != nil || x.LAMEHeader() != nil {
+		t.Errorf("readMP3XingHeader() without LAME extension = %v, %v, expected no LAME header", x, err)
+	}
+}
@@ -0,0 +1,171 @@
+package yurit
+
+import (
+	"bufio"
+	"io"
+	"time"
+)
+
+//mp3FrameScan holds the results of reading every frame header of an MPEG audio
+//stream, which unlike the first frame header and Xing header gives an exact
+//frame count and duration for all files.
+type mp3FrameScan map[string]interface{}
+
+//mpegScanBufferSize is large enough to hold the largest possible MPEG frame
+//plus the header of the following frame.
+const mpegScanBufferSize = 1 << 16
+
+//scanMPEGFrames reads every frame header in the n bytes of r from its current
+//position. Frames are read by their size, and whenever the data isn't a valid
+//frame header the scan moves on byte by byte until it finds a header that is
+//followed by another header of the same stream, skipping any garbage. An
+//information frame (Xing, Info or VBRI) at the start of the stream holds no
+//audio and is not counted.
+func scanMPEGFrames(r io.Reader, n int64) (mp3FrameScan, error) {
+	br := bufio.NewReaderSize(io.LimitReader(r, n), mpegScanBufferSize)
+	var (
+		first        mpegFrameHeader
+		frames       int
+		samples      int64
+		audioBytes   int64
+		skippedBytes int64
+		inSync       bool
+	)
+	for {
+		fh, frame, err := peekMPEGFrame(br, inSync)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if fh == nil || (first != nil && !fh.matches(first)) {
+			inSync = false
+			br.Discard(1)
+			skippedBytes++
+			continue
+		}
+		size := fh.FrameSize()
+		if len(frame) < size {
+			//The last frame is cut short, so it is treated as garbage
+			skippedBytes += int64(len(frame))
+			break
+		}
+		inSync = true
+		if first == nil {
+			first = fh
+			if isMPEGInfoFrame(frame[:size], fh) {
+				br.Discard(size)
+				continue
+			}
+		}
+		frames++
+		samples += int64(fh.SamplesPerFrame())
+		audioBytes += int64(size)
+		br.Discard(size)
+	}
+	if first == nil {
+		return nil, nil
+	}
+	scan := mp3FrameScan{
+		TotalFramesKey:  frames,
+		TotalSamplesKey: samples,
+		TotalBytesKey:   audioBytes,
+		SampleRateKey:   first.SampleRate(),
+		"skippedBytes":  skippedBytes,
+	}
+	return scan, nil
+}
+
+//peekMPEGFrame peeks at the frame at the current position of br, returning its
+//header and its bytes followed by up to 4 bytes of the next frame header. The
+//bytes may be fewer than the frame size if the data ends within the frame. A
+//nil header is returned if there is no valid frame header, or if inSync is
+//false and the frame isn't followed by another header of the same stream or
+//the end of the data, which makes sure that it is a real frame. At the end of
+//the data io.EOF is returned along with the last bytes, if there are fewer than
+//4 of them.
+func peekMPEGFrame(br *bufio.Reader, inSync bool) (mpegFrameHeader, []byte, error) {
+	b, err := br.Peek(4)
+	if len(b) < 4 {
+		return nil, b, err
+	}
+	if b[0] != 0xFF || b[1]&0xE0 != 0xE0 {
+		return nil, b, nil
+	}
+	fh := parseMPEGFrameHeader(b)
+	if !fh.isValid() {
+		return nil, b, nil
+	}
+	size := fh.FrameSize()
+	frame, err := br.Peek(size + 4)
+	if err != nil && err != io.EOF {
+		return nil, nil, err
+	}
+	if !inSync && len(frame) == size+4 {
+		next := frame[size:]
+		if next[0] != 0xFF || next[1]&0xE0 != 0xE0 {
+			return nil, b, nil
+		}
+		if nfh := parseMPEGFrameHeader(next); !nfh.isValid() || !nfh.matches(fh) {
+			return nil, b, nil
+		}
+	}
+	return fh, frame, nil
+}
+
+//isMPEGInfoFrame returns whether a frame is a Xing, Info or VBRI information
+//frame rather than audio.
+func isMPEGInfoFrame(frame []byte, fh mpegFrameHeader) bool {
+	xingOffset := 4 + fh.sideInfoLength()
+	if fh.Protected() {
+		xingOffset += 2
+	}
+	if len(frame) >= xingOffset+4 {
+		id := string(frame[xingOffset : xingOffset+4])
+		if id == "Xing" || id == "Info" {
+			return true
+		}
+	}
+	//VBRI headers are always 32 bytes after the frame header
+	return len(frame) >= 40 && string(frame[36:40]) == "VBRI"
+}
+
+//AverageBitrate returns the average bitrate of the audio frames in bits per
+//second.
+func (s mp3FrameScan) AverageBitrate() int {
+	seconds := s.Duration().Seconds()
+	if seconds == 0 {
+		return 0
+	}
+	return int(float64(s.TotalBytes()*8) / seconds)
+}
+
+func (s mp3FrameScan) Duration() time.Duration {
+	sr, _ := s[SampleRateKey].(int)
+	if sr <= 0 {
+		return time.Duration(0)
+	}
+	return time.Duration(float64(s.TotalSamples()) / float64(sr) * float64(time.Second))
+}
+
+//SkippedBytes returns the number of bytes between the audio frames that were
+//not part of any frame.
+func (s mp3FrameScan) SkippedBytes() int64 {
+	sb, _ := s["skippedBytes"].(int64)
+	return sb
+}
+
+func (s mp3FrameScan) TotalBytes() int64 {
+	tb, _ := s[TotalBytesKey].(int64)
+	return tb
+}
+
+func (s mp3FrameScan) TotalFrames() int {
+	tf, _ := s[TotalFramesKey].(int)
+	return tf
+}
+
+func (s mp3FrameScan) TotalSamples() int64 {
+	ts, _ := s[TotalSamplesKey].(int64)
+	return ts
+}
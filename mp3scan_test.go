@@ -0,0 +1,77 @@
+package yurit
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMPEGFrameHeaderFrameSize(t *testing.T) {
+	tests := []struct {
+		header []byte
+		size   int
+	}{
+		{[]byte{0xFF, 0xFB, 0x90, 0x64}, 417}, //MPEG 1 Layer III, 128 kbps, 44100 Hz
+		{[]byte{0xFF, 0xFB, 0x92, 0x64}, 418}, //Padded
+		{[]byte{0xFF, 0xF3, 0xE0, 0x64}, 522}, //MPEG 2 Layer III, 160 kbps, 22050 Hz
+		{[]byte{0xFF, 0xFD, 0x80, 0x64}, 417}, //MPEG 1 Layer II, 128 kbps, 44100 Hz
+		{[]byte{0xFF, 0xFF, 0x40, 0x64}, 136}, //MPEG 1 Layer I, 128 kbps, 44100 Hz
+		{[]byte{0xFF, 0xFB, 0x00, 0x64}, 0},   //Free format
+		{[]byte{0xFF, 0xFB, 0x9C, 0x64}, 0},   //Reserved sample rate
+	}
+
+	for ii, tt := range tests {
+		size := parseMPEGFrameHeader(tt.header).FrameSize()
+		if size != tt.size {
+			t.Errorf("[%d] FrameSize() for %x = %d, expected %d", ii, tt.header, size, tt.size)
+		}
+	}
+}
+
+// mpegTestFrame returns a 417 byte MPEG 1 Layer III frame with the given data
+// after the header.
+func mpegTestFrame(data string) []byte {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x64})
+	copy(frame[4:], data)
+	return frame
+}
+
+func TestScanMPEGFrames(t *testing.T) {
+	var b []byte
+	b = append(b, mpegTestFrame("\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00Xing")...)
+	for i := 0; i < 3; i++ {
+		b = append(b, mpegTestFrame("")...)
+	}
+	b = append(b, []byte("garbage\xFF\xFB")...)
+	for i := 0; i < 2; i++ {
+		b = append(b, mpegTestFrame("")...)
+	}
+	//Truncated last frame
+	b = append(b, mpegTestFrame("")[:100]...)
+
+	scan, err := scanMPEGFrames(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatalf("scanMPEGFrames() returned error: %v", err)
+	}
+	if scan.TotalFrames() != 5 {
+		t.Errorf("TotalFrames() = %d, expected %d", scan.TotalFrames(), 5)
+	}
+	if scan.TotalSamples() != 5*1152 {
+		t.Errorf("TotalSamples() = %d, expected %d", scan.TotalSamples(), 5*1152)
+	}
+	if scan.TotalBytes() != 5*417 {
+		t.Errorf("TotalBytes() = %d, expected %d", scan.TotalBytes(), 5*417)
+	}
+	if scan.SkippedBytes() != 109 {
+		t.Errorf("SkippedBytes() = %d, expected %d", scan.SkippedBytes(), 109)
+	}
+	//Unpadded 128 kbps frames at 44100 Hz are just under 128 kbps
+	if scan.AverageBitrate() != 127706 {
+		t.Errorf("AverageBitrate() = %d, expected %d", scan.AverageBitrate(), 127706)
+	}
+
+	scan, err = scanMPEGFrames(bytes.NewReader([]byte("no frames here")), 14)
+	if err != nil || scan != nil {
+		t.Errorf("scanMPEGFrames() without frames = %v, %v, expected nil, nil", scan, err)
+	}
+}
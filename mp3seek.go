@@ -0,0 +1,115 @@
+package yurit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+//ByteOffsetAt returns the offset in r, the file that m was read from, of the
+//frame to start playing from to seek to time t. For VBR files with a Xing
+//header the position is interpolated from its table of contents, and for other
+//files the bitrate of the first frame is used. The estimated position is then
+//moved forward to the next frame header that is followed by another frame
+//header of the same stream, so the returned offset is always on a frame
+//boundary.
+func (m MP3Metadata) ByteOffsetAt(r io.ReadSeeker, t time.Duration) (int64, error) {
+	duration := m.Duration()
+	if t < 0 || t > duration {
+		return 0, fmt.Errorf("invalid time: %v, expected between 0 and %v", t, duration)
+	}
+	estimate, err := m.estimateByteOffset(t, duration)
+	if err != nil {
+		return 0, err
+	}
+	//Seeking to the very end gives the last frame, which may be up to 4 bytes
+	//longer than the first frame because of padding
+	if last := m.audioStart() + m.approximateAudioSize() - int64(m.frameHeader.FrameSize()+4); estimate > last {
+		estimate = last
+	}
+	return m.syncToFrame(r, estimate)
+}
+
+//estimateByteOffset returns the approximate offset of time t in the file.
+func (m MP3Metadata) estimateByteOffset(t time.Duration, duration time.Duration) (int64, error) {
+	toc := m.xingHeader.TOC()
+	if len(toc) == 100 && duration > 0 {
+		//The TOC is relative to the first frame, which holds the Xing header, and
+		//entry i is the position at i percent of the duration in 1/256ths of the
+		//total bytes
+		var totalBytes float64
+		if tb := m.xingHeader.TotalBytes(); tb != nil {
+			totalBytes = float64(*tb)
+		} else {
+			totalBytes = float64(m.approximateAudioSize())
+		}
+		percent := float64(t) / float64(duration) * 100
+		i := int(percent)
+		if i > 99 {
+			i = 99
+		}
+		a := float64(toc[i])
+		b := 256.0
+		if i < 99 {
+			b = float64(toc[i+1])
+		}
+		x := a + (b-a)*(percent-float64(i))
+		return m.frameOffset + int64(x/256*totalBytes), nil
+	}
+	bitrate := m.frameHeader.Bitrate() * 1000
+	if bitrate <= 0 {
+		return 0, errors.New("unable to seek without a bitrate or Xing table of contents")
+	}
+	start := m.frameOffset
+	if m.xingHeader != nil {
+		//Skip the Info frame, which holds no audio
+		start += int64(m.frameHeader.FrameSize())
+	}
+	return start + int64(t.Seconds()*float64(bitrate)/8), nil
+}
+
+//syncToFrame returns the offset of the first frame header at or after offset
+//that is followed by another frame header of the same stream.
+func (m MP3Metadata) syncToFrame(r io.ReadSeeker, offset int64) (int64, error) {
+	audioEnd := m.audioStart() + m.approximateAudioSize()
+	for {
+		_, err := r.Seek(offset, io.SeekStart)
+		if err != nil {
+			return 0, err
+		}
+		fh, err := readMPEGFrameHeader(r)
+		if err != nil {
+			return 0, err
+		}
+		pos, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0, err
+		}
+		headerOffset := pos - 4
+		if fh.isValid() && fh.matches(m.frameHeader) {
+			if headerOffset+int64(fh.FrameSize()) >= audioEnd {
+				//The last frame, which is followed by tags or the end of the file
+				return headerOffset, nil
+			}
+			_, err = r.Seek(headerOffset+int64(fh.FrameSize()), io.SeekStart)
+			if err != nil {
+				return 0, err
+			}
+			b, err := readBytes(r, 4)
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				//The last frame in the file
+				return headerOffset, nil
+			} else if err != nil {
+				return 0, err
+			}
+			if b[0] == 0xFF && b[1]&0xE0 == 0xE0 {
+				next := parseMPEGFrameHeader(b)
+				if next.isValid() && next.matches(fh) {
+					return headerOffset, nil
+				}
+			}
+		}
+		offset = headerOffset + 1
+	}
+}
@@ -0,0 +1,70 @@
+package yurit
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMP3ByteOffsetAt(t *testing.T) {
+	f, err := os.Open("./testdata/with_tags/sample.id3v24.mp3")
+	if err != nil {
+		t.Fatalf("error opening file: %v", err)
+	}
+	defer f.Close()
+	m, err := ReadFromMP3(f)
+	if err != nil {
+		t.Fatalf("ReadFromMP3() returned error: %v", err)
+	}
+	previous := int64(-1)
+	for _, d := range []time.Duration{0, time.Second, 2 * time.Second, m.Duration()} {
+		offset, err := m.ByteOffsetAt(f, d)
+		if err != nil {
+			t.Errorf("ByteOffsetAt(%v) returned error: %v", d, err)
+			continue
+		}
+		b := make([]byte, 2)
+		if _, err := f.ReadAt(b, offset); err != nil || b[0] != 0xFF || b[1]&0xE0 != 0xE0 {
+			t.Errorf("ByteOffsetAt(%v) = %d, which is not a frame header: %x", d, offset, b)
+		}
+		if offset <= previous {
+			t.Errorf("ByteOffsetAt(%v) = %d, expected more than %d", d, offset, previous)
+		}
+		previous = offset
+	}
+	if _, err := m.ByteOffsetAt(f, m.Duration()+time.Second); err == nil {
+		t.Errorf("ByteOffsetAt() after the end expected error")
+	}
+}
+
+func TestMP3ByteOffsetAtXingTOC(t *testing.T) {
+	//100 frames of 417 bytes, with the TOC saying that the second half of the
+	//audio starts at three quarters of the bytes
+	var b []byte
+	for i := 0; i < 100; i++ {
+		b = append(b, mpegTestFrame("")...)
+	}
+	toc := make([]byte, 100)
+	for i := range toc {
+		if i < 50 {
+			toc[i] = byte(i * 192 / 50)
+		} else {
+			toc[i] = byte(192 + (i-50)*64/50)
+		}
+	}
+	totalBytes := len(b)
+	m := MP3Metadata{
+		fileSize:    int64(len(b)),
+		frameHeader: parseMPEGFrameHeader(b[0:4]),
+		xingHeader:  mp3XingHeader{"id": "Xing", TotalFramesKey: 100, TotalBytesKey: totalBytes, "toc": toc},
+	}
+	offset, err := m.ByteOffsetAt(bytes.NewReader(b), m.Duration()/2)
+	if err != nil {
+		t.Fatalf("ByteOffsetAt() returned error: %v", err)
+	}
+	//Three quarters of the bytes is 31275, the start of frame 75
+	if offset != 31275 {
+		t.Errorf("ByteOffsetAt() = %d, expected %d", offset, 31275)
+	}
+}
@@ -0,0 +1,103 @@
+package yurit
+
+import (
+	"errors"
+	"io"
+)
+
+//mp3VBRIHeader is the header that the Fraunhofer encoder puts in the first frame
+//of a variable bitrate file in place of a Xing header. It is always 32 bytes
+//after the frame header.
+type mp3VBRIHeader map[string]interface{}
+
+//readMP3VBRIHeader reads the information in a VBRI header. The method requires
+//that the reader has been positioned at the VBRI ID BEFORE the method is called.
+//ID 4, version 2, delay 2, quality 2, bytes 4, frames 4, TOC entries 2,
+//TOC scale 2, TOC entry size 2, frames per TOC entry 2, TOC
+func readMP3VBRIHeader(r io.Reader) (mp3VBRIHeader, error) {
+	b, err := readBytes(r, 26)
+	if err != nil {
+		return nil, err
+	}
+	if string(b[0:4]) != "VBRI" {
+		return nil, errors.New("expected 'VBRI'")
+	}
+	v := mp3VBRIHeader{}
+	v[VersionKey] = getUint16AsInt(b[4:6])
+	v["delay"] = getUint16AsInt(b[6:8])
+	v["qualityIndicator"] = getUint16AsInt(b[8:10])
+	v[TotalBytesKey] = getInt32AsInt(b[10:14])
+	v[TotalFramesKey] = getInt32AsInt(b[14:18])
+	numEntries := getUint16AsInt(b[18:20])
+	v["tocScale"] = getUint16AsInt(b[20:22])
+	entrySize := getUint16AsInt(b[22:24])
+	v["framesPerTOCEntry"] = getUint16AsInt(b[24:26])
+	if entrySize < 1 || entrySize > 4 {
+		//Entries are big-endian integers of 1 to 4 bytes
+		return v, nil
+	}
+	tocBytes, err := readBytes(r, uint(numEntries*entrySize))
+	if err != nil {
+		return nil, err
+	}
+	toc := make([]int, numEntries)
+	for i := range toc {
+		for _, c := range tocBytes[i*entrySize : (i+1)*entrySize] {
+			toc[i] = toc[i]<<8 | int(c)
+		}
+	}
+	v["toc"] = toc
+	return v, nil
+}
+
+//Delay returns the encoder delay in samples.
+func (v mp3VBRIHeader) Delay() int {
+	d, _ := v["delay"].(int)
+	return d
+}
+
+//FramesPerTOCEntry returns the number of frames covered by each TOC entry.
+func (v mp3VBRIHeader) FramesPerTOCEntry() int {
+	f, _ := v["framesPerTOCEntry"].(int)
+	return f
+}
+
+func (v mp3VBRIHeader) Quality() *int {
+	if q, ok := v["qualityIndicator"].(int); ok {
+		return &q
+	}
+	return nil
+}
+
+//TOC returns the table of contents, where each entry is the size in bytes of
+//the next FramesPerTOCEntry frames, divided by the TOC scale.
+func (v mp3VBRIHeader) TOC() []int {
+	if t, ok := v["toc"].([]int); ok {
+		return t
+	}
+	return nil
+}
+
+func (v mp3VBRIHeader) TOCScale() int {
+	s, _ := v["tocScale"].(int)
+	return s
+}
+
+func (v mp3VBRIHeader) TotalBytes() *int {
+	if t, ok := v[TotalBytesKey].(int); ok {
+		return &t
+	}
+	return nil
+}
+
+func (v mp3VBRIHeader) TotalFrames() *int {
+	if t, ok := v[TotalFramesKey].(int); ok {
+		return &t
+	}
+	return nil
+}
+
+func (v mp3VBRIHeader) Version() int {
+	ver, _ := v[VersionKey].(int)
+	return ver
+}
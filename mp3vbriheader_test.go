@@ -0,0 +1,31 @@
+package yurit
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestReadMP3VBRIHeader(t *testing.T) {
+	b := []byte{'V', 'B', 'R', 'I', 0, 1, 0x02, 0x41, 0, 75, 0, 0, 0x10, 0, 0, 0, 0, 100, 0, 2, 0, 1, 0, 2, 0, 50, 0x08, 0, 0x07, 0xFF}
+	v, err := readMP3VBRIHeader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("readMP3VBRIHeader() returned error: %v", err)
+	}
+	if v.Version() != 1 || v.Delay() != 577 || *v.Quality() != 75 || v.TOCScale() != 1 || v.FramesPerTOCEntry() != 50 {
+		t.Errorf("readMP3VBRIHeader() = %v, unexpected header values", v)
+	}
+	if *v.TotalBytes() != 4096 || *v.TotalFrames() != 100 {
+		t.Errorf("TotalBytes(), TotalFrames() = %d, %d, expected %d, %d", *v.TotalBytes(), *v.TotalFrames(), 4096, 100)
+	}
+	if toc, expected := v.TOC(), []int{2048, 2047}; !reflect.DeepEqual(toc, expected) {
+		t.Errorf("TOC() = %v, expected %v", toc, expected)
+	}
+
+	if _, err := readMP3VBRIHeader(bytes.NewReader(b[:28])); err == nil {
+		t.Errorf("readMP3VBRIHeader() with truncated TOC expected error")
+	}
+	if _, err := readMP3VBRIHeader(bytes.NewReader(append([]byte("Xing"), b[4:]...))); err == nil {
+		t.Errorf("readMP3VBRIHeader() without VBRI ID expected error")
+	}
+}
@@ -58,9 +58,21 @@ func readMP3XingHeader(r io.Reader) (mp3XingHeader, error) {
 		x["qualityIndicator"] = getInt32AsInt(xingData[offset : offset+4])
 		offset += 4
 	}
+	//Encoders like LAME write an extension after the Xing fields, but since it is
+	//optional a short read just means there is none
+	b := make([]byte, mp3LAMEHeaderSize)
+	if _, err := io.ReadFull(r, b); err == nil && isMP3LAMEHeader(b) {
+		x["lame"] = processMP3LAMEHeader(b)
+	}
 	return x, nil
 }
 
+//LAMEHeader returns the LAME extension of the header, or nil if there is none.
+func (x mp3XingHeader) LAMEHeader() MP3LAMEHeader {
+	l, _ := x["lame"].(MP3LAMEHeader)
+	return l
+}
+
 func (x mp3XingHeader) ID() string {
 	i, _ := x["id"].(string)
 	return i
@@ -14,6 +14,7 @@ type Mp4Atom struct {
 }
 
 var parentAtomsList = map[string]int64{
+	"edts": 0,
 	"ilst": 0,
 	"mdia": 0,
 	"meta": 4, //1 byte version, 3 bytes flags
@@ -22,6 +23,7 @@ var parentAtomsList = map[string]int64{
 	"stbl": 0,
 	"stsd": 8, //1 byte version, 3 bytes flags, 4 bytes number of entries
 	"trak": 0,
+	"tref": 0,
 	"udta": 0,
 }
 
@@ -30,13 +32,46 @@ var childrenAreParentsAtomsList = []string{
 }
 
 var dataAtomsList = []string{
+	".mp3",
+	"ac-3",
+	"alac",
+	"chap",
+	"chpl",
+	"co64",
+	"dac3",
 	"data",
+	"dec3",
+	"dfLa",
+	"dOps",
+	"ec-3",
+	"elst",
 	"esds",
+	"fLaC",
 	"ftyp",
+	"mdhd",
 	"mean",
 	"mvhd",
 	"mp4a",
 	"name",
+	"Opus",
+	"sgpd",
+	"stco",
+	"stsc",
+	"stsz",
+	"stts",
+	"tkhd",
+}
+
+//audioSampleEntriesList holds the names of the sound sample descriptions that
+//we know how to read. Only the first one found in the file is used.
+var audioSampleEntriesList = []string{
+	"mp4a",
+	".mp3",
+	"alac",
+	"fLaC",
+	"Opus",
+	"ac-3",
+	"ec-3",
 }
 
 // MP4Metadata is the implementation of Metadata for MP4 tag (atom) data.
@@ -46,6 +81,17 @@ type MP4Metadata struct {
 	metadata mp4metadata
 	mp4a     mp4mp4a
 	mvhd     mp4mvhd
+	chapters []Chapter
+	//trackGapless is the gapless information from the edit list or the roll
+	//distance of the audio track, which is used when there is no iTunSMPB tag
+	trackGapless *GaplessInfo
+	//sampleTable is the sample table of the audio track
+	sampleTable *MP4SampleTable
+	//sampleEntry is the name of the sound sample description found in the file,
+	//e.g. mp4a or alac, and codecConfig holds the information from its
+	//configuration atom when the sample description isn't mp4a or .mp3
+	sampleEntry string
+	codecConfig mp4CodecConfig
 }
 
 // ReadMP4 reads MP4 metadata atoms from the io.ReadSeeker into a Metadata, returning
@@ -70,9 +116,20 @@ func ReadMP4(r io.ReadSeeker) (*MP4Metadata, error) {
 			return nil, err
 		}
 	}
-	mp4aAtom := findAtom(a, "mp4a")
-	if mp4aAtom != nil {
-		m.mp4a, m.esds, err = processMP4AAtom(*mp4aAtom)
+	sampleEntryAtom := findSampleEntryAtom(a)
+	if sampleEntryAtom != nil {
+		m.sampleEntry = sampleEntryAtom.Name
+		switch sampleEntryAtom.Name {
+		case "mp4a", ".mp3":
+			//Both of these describe their codec with an esds atom
+			m.mp4a, m.esds, err = processMP4AAtom(*sampleEntryAtom)
+		default:
+			var children []Mp4Atom
+			m.mp4a, children, err = processSoundSampleDescription(*sampleEntryAtom)
+			if err == nil {
+				m.codecConfig, err = processMP4CodecConfig(sampleEntryAtom.Name, children)
+			}
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -84,6 +141,31 @@ func ReadMP4(r io.ReadSeeker) (*MP4Metadata, error) {
 			return nil, err
 		}
 	}
+	//Prefer QuickTime chapters, which are what iTunes writes, over Nero chapters
+	moovAtom := findAtom(a, "moov")
+	if moovAtom != nil {
+		m.chapters, err = readQuickTimeChapters(r, *moovAtom)
+		if err != nil {
+			return nil, err
+		}
+		timeScale, _ := m.mvhd[TimeScaleKey].(int64)
+		m.trackGapless = readEditListGaplessInfo(*moovAtom, timeScale, m.SampleRate())
+		if m.trackGapless == nil {
+			m.trackGapless = readRollGaplessInfo(*moovAtom, m.SampleRate())
+		}
+		m.sampleTable, err = readAudioSampleTable(*moovAtom)
+		if err != nil {
+			return nil, err
+		}
+	}
+	chplAtom := findAtom(a, "chpl")
+	if m.chapters == nil && chplAtom != nil {
+		m.chapters, err = processCHPLAtom(*chplAtom)
+		if err != nil {
+			return nil, err
+		}
+	}
+	m.chapters = completeChapters(m.chapters, m.mvhd.Duration())
 	return &m, nil
 }
 
@@ -173,6 +255,27 @@ func findAtom(atoms []Mp4Atom, name string) *Mp4Atom {
 	return nil
 }
 
+//findSampleEntryAtom returns the first sound sample description atom found in
+//the stsd atoms of the file, or nil if there is none.
+func findSampleEntryAtom(atoms []Mp4Atom) *Mp4Atom {
+	for _, atom := range atoms {
+		if atom.Name == "stsd" {
+			for _, child := range atom.Children {
+				if containsString(audioSampleEntriesList, child.Name) {
+					return &child
+				}
+			}
+		}
+		if len(atom.Children) > 0 {
+			entry := findSampleEntryAtom(atom.Children)
+			if entry != nil {
+				return entry
+			}
+		}
+	}
+	return nil
+}
+
 func (m MP4Metadata) Album() string {
 	return m.metadata.Album()
 }
@@ -186,9 +289,40 @@ func (m MP4Metadata) Artist() string {
 }
 
 func (m MP4Metadata) AverageBitrate() int {
+	if m.codecConfig != nil {
+		return m.codecConfig.AverageBitrate()
+	}
 	return m.esds.AverageBitrate()
 }
 
+//Channels returns the number of audio channels, preferring the value from the
+//codec configuration over the one in the sample description.
+func (m MP4Metadata) Channels() int {
+	if c := m.codecConfig.Channels(); c > 0 {
+		return c
+	}
+	return m.mp4a.Channels()
+}
+
+//Chapters returns the chapters from a QuickTime chapter track, or from a Nero
+//chapter list (chpl) if there is no chapter track.
+func (m MP4Metadata) Chapters() []Chapter {
+	return m.chapters
+}
+
+//Codec returns the name of the sound sample description found in the file,
+//which identifies the codec, e.g. mp4a for AAC or alac for Apple Lossless.
+func (m MP4Metadata) Codec() string {
+	return m.sampleEntry
+}
+
+//CodecConfig returns information extracted from the codec configuration atom
+//of sample descriptions other than mp4a, such as the ALAC magic cookie or the
+//FLAC STREAMINFO block.
+func (m MP4Metadata) CodecConfig() map[string]interface{} {
+	return m.codecConfig
+}
+
 func (m MP4Metadata) Comment() string {
 	return m.metadata.Comment()
 }
@@ -201,8 +335,13 @@ func (m MP4Metadata) Disc() (int, int) {
 	return m.metadata.Disc()
 }
 
+//Duration returns the duration from the movie header atom (mvhd), or from the
+//sample table of the audio track if the movie header doesn't give one.
 func (m MP4Metadata) Duration() time.Duration {
-	return m.mvhd.Duration()
+	if d := m.mvhd.Duration(); d > 0 || m.sampleTable == nil {
+		return d
+	}
+	return m.sampleTable.Duration()
 }
 
 //Returns information extracted from the elementary stream descriptor atom
@@ -212,6 +351,9 @@ func (m MP4Metadata) ESDS() map[string]interface{} {
 }
 
 func (m MP4Metadata) FileType() FileType {
+	if m.sampleEntry == "alac" {
+		return ALAC
+	}
 	return m.ftyp.FileType()
 }
 
@@ -226,6 +368,24 @@ func (m MP4Metadata) FTYP() map[string]interface{} {
 	return m.ftyp
 }
 
+//GaplessInfo returns the encoder delay and padding from the iTunSMPB tag that
+//iTunes writes, or else from the edit list of the audio track, or else the
+//encoder delay from the roll distance of the audio track. Nil is returned if
+//none of them are found.
+func (m MP4Metadata) GaplessInfo() *GaplessInfo {
+	switch smpb := m.metadata["iTunSMPB"].(type) {
+	case string:
+		if g := processITunSMPB(smpb); g != nil {
+			return g
+		}
+	case []byte:
+		if g := processITunSMPB(string(smpb)); g != nil {
+			return g
+		}
+	}
+	return m.trackGapless
+}
+
 func (m MP4Metadata) Genre() string {
 	return m.metadata.Genre()
 }
@@ -234,8 +394,8 @@ func (m MP4Metadata) Lyrics() string {
 	return m.metadata.Lyrics()
 }
 
-//Returns information extracted from the MP4A sound sample description atom
-//('mp4a') found in the file.
+//Returns information extracted from the sound sample description atom ('mp4a',
+//'alac', etc.) found in the file.
 func (m MP4Metadata) MP4A() map[string]interface{} {
 	return m.mp4a
 }
@@ -254,6 +414,22 @@ func (m MP4Metadata) Raw() map[string]interface{} {
 	return m.metadata
 }
 
+//SampleRate returns the sample rate of the audio, preferring the value from the
+//codec configuration over the one in the sample description.
+func (m MP4Metadata) SampleRate() int {
+	if sr := m.codecConfig.SampleRate(); sr > 0 {
+		return sr
+	}
+	return m.mp4a.SampleRate()
+}
+
+//SampleTable returns the sample table of the audio track, which can be used to
+//find where in the file to start reading from to seek to a given time. Nil is
+//returned if the file has no audio track.
+func (m MP4Metadata) SampleTable() *MP4SampleTable {
+	return m.sampleTable
+}
+
 func (m MP4Metadata) Title() string {
 	return m.metadata.Title()
 }
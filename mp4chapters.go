@@ -0,0 +1,172 @@
+package yurit
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf8"
+)
+
+//processCHPLAtom reads the chapters from a Nero style chapter list atom (chpl)
+//found in the udta atom of the movie. Start times are in units of 100
+//nanoseconds.
+//Bytes: 1 version, 3 flags, 4 reserved (version 1 only), 1 chapter count, then
+//for each chapter 8 start time, 1 title length and the UTF-8 title
+func processCHPLAtom(chplAtom Mp4Atom) ([]Chapter, error) {
+	b := chplAtom.Data
+	offset := 4
+	if len(b) > 0 && b[0] == 1 {
+		offset += 4
+	}
+	if len(b) < offset+1 {
+		return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", offset+1, len(b))
+	}
+	count := int(b[offset])
+	offset++
+	var chapters []Chapter
+	for i := 0; i < count; i++ {
+		if len(b) < offset+9 {
+			return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", offset+9, len(b))
+		}
+		start := time.Duration(getUint64(b[offset:offset+8])) * 100 * time.Nanosecond
+		titleLen := int(b[offset+8])
+		offset += 9
+		if len(b) < offset+titleLen {
+			return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", offset+titleLen, len(b))
+		}
+		title := string(b[offset : offset+titleLen])
+		offset += titleLen
+		chapters = append(chapters, Chapter{Title: title, Start: start})
+	}
+	return chapters, nil
+}
+
+//readQuickTimeChapters reads the chapters from the text track that a track
+//refers to with a chap reference (tref/chap). The title of each chapter is a
+//sample in the text track, so the samples are read from r using the sample
+//table of the text track. If no track refers to a chapter track then nil is
+//returned.
+func readQuickTimeChapters(r io.ReadSeeker, moovAtom Mp4Atom) ([]Chapter, error) {
+	var traks []Mp4Atom
+	for _, a := range moovAtom.Children {
+		if a.Name == "trak" {
+			traks = append(traks, a)
+		}
+	}
+	//Find the ID of the chapter track
+	var chapterTrackID uint32
+	for _, trak := range traks {
+		chapAtom := findAtom(trak.Children, "chap")
+		if chapAtom != nil && len(chapAtom.Data) >= 4 {
+			chapterTrackID = uint32(getUint32AsInt64(chapAtom.Data[0:4]))
+			break
+		}
+	}
+	if chapterTrackID == 0 {
+		return nil, nil
+	}
+	for _, trak := range traks {
+		tkhdAtom := findAtom(trak.Children, "tkhd")
+		if tkhdAtom == nil || getTKHDTrackID(*tkhdAtom) != chapterTrackID {
+			continue
+		}
+		return readChapterTrack(r, trak)
+	}
+	return nil, nil
+}
+
+//getTKHDTrackID returns the track ID from a track header atom (tkhd), which
+//follows the creation and modification times, or 0 if it can't be read.
+func getTKHDTrackID(tkhdAtom Mp4Atom) uint32 {
+	offset := 12
+	if len(tkhdAtom.Data) > 0 && tkhdAtom.Data[0] == 1 {
+		offset = 20
+	}
+	if len(tkhdAtom.Data) < offset+4 {
+		return 0
+	}
+	return uint32(getUint32AsInt64(tkhdAtom.Data[offset : offset+4]))
+}
+
+//getMDHDTimeScale returns the time scale from a media header atom (mdhd), which
+//follows the creation and modification times, or 0 if it can't be read.
+func getMDHDTimeScale(mdhdAtom Mp4Atom) int64 {
+	offset := 12
+	if len(mdhdAtom.Data) > 0 && mdhdAtom.Data[0] == 1 {
+		offset = 20
+	}
+	if len(mdhdAtom.Data) < offset+4 {
+		return 0
+	}
+	return getUint32AsInt64(mdhdAtom.Data[offset : offset+4])
+}
+
+//readChapterTrack reads the chapter titles and start times from the samples of
+//a text track. Each sample is a 2 byte text length followed by the text, which
+//is UTF-8 unless it starts with a UTF-16 byte order mark.
+func readChapterTrack(r io.ReadSeeker, trak Mp4Atom) ([]Chapter, error) {
+	mdhdAtom := findAtom(trak.Children, "mdhd")
+	stblAtom := findAtom(trak.Children, "stbl")
+	if mdhdAtom == nil || stblAtom == nil {
+		return nil, nil
+	}
+	timeScale := getMDHDTimeScale(*mdhdAtom)
+	if timeScale == 0 {
+		return nil, nil
+	}
+	t, err := processSampleTable(*stblAtom)
+	if err != nil {
+		return nil, err
+	}
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	var chapters []Chapter
+	err = t.eachSample(func(start int64, offset int64, size uint32) error {
+		if offset < 0 || offset+int64(size) > end {
+			return fmt.Errorf("invalid chapter sample: %d bytes at offset %d, file is %d bytes", size, offset, end)
+		}
+		_, err := r.Seek(offset, io.SeekStart)
+		if err != nil {
+			return err
+		}
+		b, err := readBytes(r, uint(size))
+		if err != nil {
+			return err
+		}
+		chapters = append(chapters, Chapter{
+			Title: getChapterSampleText(b),
+			Start: time.Duration(float64(start) / float64(timeScale) * float64(time.Second)),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return chapters, nil
+}
+
+//getChapterSampleText returns the text from a text track sample.
+func getChapterSampleText(b []byte) string {
+	if len(b) < 2 {
+		return ""
+	}
+	l := getUint16AsInt(b[0:2])
+	if len(b) < 2+l {
+		l = len(b) - 2
+	}
+	text := b[2 : 2+l]
+	if len(text) >= 2 && text[0] == 0xFE && text[1] == 0xFF {
+		s, _ := decodeUTF16(text[2:], binary.BigEndian)
+		return s
+	} else if len(text) >= 2 && text[0] == 0xFF && text[1] == 0xFE {
+		s, _ := decodeUTF16(text[2:], binary.LittleEndian)
+		return s
+	}
+	if !utf8.Valid(text) {
+		return decodeISO8859(text)
+	}
+	return string(text)
+}
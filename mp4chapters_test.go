@@ -0,0 +1,88 @@
+package yurit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProcessCHPLAtom(t *testing.T) {
+	tests := []struct {
+		data       []byte
+		chapters   []Chapter
+		makesError bool
+	}{
+		{[]byte{0, 0, 0, 0, 0}, nil, false},
+		{[]byte{0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 2, 'H', 'i'}, []Chapter{{Title: "Hi"}}, false},
+		{[]byte{1, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0x98, 0x96, 0x80, 1, 'A'}, []Chapter{{Title: "A", Start: time.Second}}, false},
+		{[]byte{0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 5, 'H', 'i'}, nil, true},
+		{[]byte{0, 0, 0}, nil, true},
+	}
+
+	for ii, tt := range tests {
+		chapters, err := processCHPLAtom(Mp4Atom{Name: "chpl", Data: tt.data})
+		if (err != nil) != tt.makesError {
+			t.Errorf("[%d] processCHPLAtom(%v) expected error: %v - Error was: %v", ii, tt.data, tt.makesError, err)
+			continue
+		}
+		if !reflect.DeepEqual(chapters, tt.chapters) {
+			t.Errorf("[%d] processCHPLAtom(%v) = %v, expected %v", ii, tt.data, chapters, tt.chapters)
+		}
+	}
+}
+
+func TestMP4SampleTableEachSample(t *testing.T) {
+	type sample struct {
+		time   int64
+		offset int64
+		size   uint32
+	}
+	tests := []struct {
+		table    mp4SampleTable
+		expected []sample
+	}{
+		{mp4SampleTable{
+			timeToSample:  []mp4TimeToSampleEntry{{1, 10}, {2, 30}},
+			sampleToChunk: []mp4SampleToChunkEntry{{1, 2}, {2, 1}},
+			sampleSizes:   []uint32{5, 6, 7},
+			sampleCount:   3,
+			chunkOffsets:  []int64{100, 200},
+		}, []sample{{0, 100, 5}, {10, 105, 6}, {40, 200, 7}}},
+		//A single sample size and huge counts from the file, which stop at the
+		//end of the time-to-sample table
+		{mp4SampleTable{
+			timeToSample:  []mp4TimeToSampleEntry{{0, 10}, {2, 20}},
+			sampleToChunk: []mp4SampleToChunkEntry{{1, 0xFFFFFFFF}},
+			sampleSize:    4,
+			sampleCount:   0xFFFFFFFF,
+			chunkOffsets:  []int64{100},
+		}, []sample{{0, 100, 4}, {20, 104, 4}}},
+	}
+
+	for ii, tt := range tests {
+		var samples []sample
+		err := tt.table.eachSample(func(time int64, offset int64, size uint32) error {
+			samples = append(samples, sample{time, offset, size})
+			return nil
+		})
+		if err != nil || !reflect.DeepEqual(samples, tt.expected) {
+			t.Errorf("[%d] eachSample() = %v, %v, expected %v", ii, samples, err, tt.expected)
+		}
+	}
+}
+
+func TestProcessSTSZAtom(t *testing.T) {
+	//A single size isn't expanded for each sample
+	size, count, sizes, err := processSTSZAtom(Mp4Atom{Name: "stsz", Data: []byte{0, 0, 0, 0, 0, 0, 0, 4, 0xFF, 0xFF, 0xFF, 0xFF}})
+	if err != nil || size != 4 || count != 0xFFFFFFFF || sizes != nil {
+		t.Errorf("processSTSZAtom() = %v, %v, %v, %v, expected %v, %v, %v", size, count, sizes, err, 4, 0xFFFFFFFF, nil)
+	}
+	size, count, sizes, err = processSTSZAtom(Mp4Atom{Name: "stsz", Data: []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 5, 0, 0, 0, 6}})
+	if err != nil || size != 0 || count != 2 || !reflect.DeepEqual(sizes, []uint32{5, 6}) {
+		t.Errorf("processSTSZAtom() = %v, %v, %v, %v, expected %v, %v, %v", size, count, sizes, err, 0, 2, []uint32{5, 6})
+	}
+	_, _, _, err = processSTSZAtom(Mp4Atom{Name: "stsz", Data: []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 5}})
+	if err == nil {
+		t.Errorf("processSTSZAtom() expected error for short table")
+	}
+}
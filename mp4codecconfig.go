@@ -0,0 +1,196 @@
+package yurit
+
+import (
+	"fmt"
+)
+
+//mp4CodecConfig holds codec specific information about the audio in an MP4
+//file that isn't AAC (mp4a). The information comes from the configuration atom
+//found inside the sample description, e.g. the ALAC magic cookie.
+type mp4CodecConfig map[string]interface{}
+
+//processMP4CodecConfig finds and processes the codec configuration atom in the
+//children of a sample description of the given type. A nil mp4CodecConfig is
+//returned if there is no configuration atom.
+func processMP4CodecConfig(sampleEntryType string, children []Mp4Atom) (mp4CodecConfig, error) {
+	var configAtomName string
+	switch sampleEntryType {
+	case "alac":
+		configAtomName = "alac"
+	case "fLaC":
+		configAtomName = "dfLa"
+	case "Opus":
+		configAtomName = "dOps"
+	case "ac-3":
+		configAtomName = "dac3"
+	case "ec-3":
+		configAtomName = "dec3"
+	default:
+		return nil, nil
+	}
+	configAtom := findAtom(children, configAtomName)
+	if configAtom == nil {
+		return nil, nil
+	}
+	switch configAtomName {
+	case "alac":
+		return processALACAtom(*configAtom)
+	case "dfLa":
+		return processDFLAAtom(*configAtom)
+	case "dOps":
+		return processDOPSAtom(*configAtom)
+	case "dac3":
+		return processDAC3Atom(*configAtom)
+	case "dec3":
+		return processDEC3Atom(*configAtom)
+	}
+	return nil, nil
+}
+
+//processALACAtom reads the ALAC magic cookie (ALACSpecificConfig) that follows
+//the version and flags of the alac atom inside an alac sample description.
+//https://github.com/macosforge/alac/blob/master/ALACMagicCookieDescription.txt
+func processALACAtom(alacAtom Mp4Atom) (mp4CodecConfig, error) {
+	if len(alacAtom.Data) < 28 {
+		return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", 28, len(alacAtom.Data))
+	}
+	b := alacAtom.Data[4:]
+	c := mp4CodecConfig{}
+	c["frameLength"] = getUint32AsInt64(b[0:4])
+	c["compatibleVersion"] = int(b[4])
+	c[SampleSizeKey] = int(b[5])
+	c["pb"] = int(b[6])
+	c["mb"] = int(b[7])
+	c["kb"] = int(b[8])
+	c[ChannelsKey] = int(b[9])
+	c["maxRun"] = getUint16AsInt(b[10:12])
+	c[MaximumFrameSizeKey] = getUint32AsInt64(b[12:16])
+	c[AverageBitrateKey] = getUint32AsInt64(b[16:20])
+	c[SampleRateKey] = getUint32AsInt64(b[20:24])
+	return c, nil
+}
+
+//processDFLAAtom reads the FLAC STREAMINFO block from a dfLa atom, which holds
+//version and flags followed by FLAC metadata blocks.
+//https://github.com/xiph/flac/blob/master/doc/isoflac.txt
+func processDFLAAtom(dflaAtom Mp4Atom) (mp4CodecConfig, error) {
+	if len(dflaAtom.Data) < 8 {
+		return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", 8, len(dflaAtom.Data))
+	}
+	//STREAMINFO is required to be the first metadata block
+	if blockType(dflaAtom.Data[4]&0x7F) != streamInfoBlock {
+		return nil, fmt.Errorf("expected FLAC STREAMINFO block in dfLa atom")
+	}
+	blockLen := getUint24AsInt(dflaAtom.Data[5:8])
+	if len(dflaAtom.Data) < 8+blockLen {
+		return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", 8+blockLen, len(dflaAtom.Data))
+	}
+	si, err := processStreamInfoBlock(dflaAtom.Data[8 : 8+blockLen])
+	if err != nil {
+		return nil, err
+	}
+	c := mp4CodecConfig{}
+	for k, v := range si {
+		c[k] = v
+	}
+	//Channels and sample size are bytes in STREAMINFO, use ints like the other
+	//codec configs
+	if ch, ok := si[ChannelsKey].(byte); ok {
+		c[ChannelsKey] = int(ch)
+	}
+	if ss, ok := si[SampleSizeKey].(byte); ok {
+		c[SampleSizeKey] = int(ss)
+	}
+	c[SampleRateKey] = int64(si.SampleRate())
+	return c, nil
+}
+
+//processDOPSAtom reads the Opus specific box. Opus always decodes at 48kHz,
+//the input sample rate is only informational.
+//https://opus-codec.org/docs/opus_in_isobmff.html
+func processDOPSAtom(dopsAtom Mp4Atom) (mp4CodecConfig, error) {
+	if len(dopsAtom.Data) < 11 {
+		return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", 11, len(dopsAtom.Data))
+	}
+	b := dopsAtom.Data
+	c := mp4CodecConfig{}
+	c[VersionKey] = int(b[0])
+	c[ChannelsKey] = int(b[1])
+	c["preSkip"] = getUint16AsInt(b[2:4])
+	c["inputSampleRate"] = getUint32AsInt64(b[4:8])
+	c["outputGain"] = getInt16AsInt(b[8:10])
+	c["channelMappingFamily"] = int(b[10])
+	c[SampleRateKey] = int64(48000)
+	return c, nil
+}
+
+//AC-3 sample rates by fscod and bitrates (kbit/s) by bit_rate_code, and number
+//of channels by acmod, not including the LFE channel.
+var (
+	ac3SampleRates = []int64{48000, 44100, 32000}
+	ac3Bitrates    = []int64{32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 448, 512, 576, 640}
+	ac3Channels    = []int{2, 1, 2, 3, 3, 4, 4, 5}
+)
+
+//processDAC3Atom reads the AC-3 specific box.
+//Bits: AABBBBBC CCDDDEFF FFFGGGGG
+//A = fscod, B = bsid, C = bsmod, D = acmod, E = lfeon, F = bit_rate_code,
+//G = reserved
+//https://www.etsi.org/deliver/etsi_ts/102300_102399/102366/01.04.01_60/ts_102366v010401p.pdf
+func processDAC3Atom(dac3Atom Mp4Atom) (mp4CodecConfig, error) {
+	if len(dac3Atom.Data) < 3 {
+		return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", 3, len(dac3Atom.Data))
+	}
+	b := dac3Atom.Data
+	c := mp4CodecConfig{}
+	fscod := b[0] >> 6
+	acmod := (b[1] >> 3) & 0x07
+	lfeon := (b[1] >> 2) & 0x01
+	bitRateCode := ((b[1] & 0x03) << 3) | (b[2] >> 5)
+	if int(fscod) < len(ac3SampleRates) {
+		c[SampleRateKey] = ac3SampleRates[fscod]
+	}
+	c[ChannelsKey] = ac3Channels[acmod] + int(lfeon)
+	if int(bitRateCode) < len(ac3Bitrates) {
+		c[AverageBitrateKey] = ac3Bitrates[bitRateCode] * 1000
+	}
+	return c, nil
+}
+
+//processDEC3Atom reads the E-AC-3 specific box. Only the first independent
+//substream is used for the sample rate and channels.
+//Bits: AAAAAAAA AAAAABBB CCDDDDDE FGGGHHHI JJJKKKKL
+//A = data_rate, B = num_ind_sub - 1, C = fscod, D = bsid, E = reserved,
+//F = asvc, G = bsmod, H = acmod, I = lfeon, J = reserved, K = num_dep_sub,
+//L = chan_loc or reserved
+func processDEC3Atom(dec3Atom Mp4Atom) (mp4CodecConfig, error) {
+	if len(dec3Atom.Data) < 5 {
+		return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", 5, len(dec3Atom.Data))
+	}
+	b := dec3Atom.Data
+	c := mp4CodecConfig{}
+	c[AverageBitrateKey] = int64(getUint16AsInt(b[0:2])>>3) * 1000
+	fscod := b[2] >> 6
+	acmod := (b[3] >> 1) & 0x07
+	lfeon := b[3] & 0x01
+	if int(fscod) < len(ac3SampleRates) {
+		c[SampleRateKey] = ac3SampleRates[fscod]
+	}
+	c[ChannelsKey] = ac3Channels[acmod] + int(lfeon)
+	return c, nil
+}
+
+func (c mp4CodecConfig) AverageBitrate() int {
+	b, _ := c[AverageBitrateKey].(int64)
+	return int(b)
+}
+
+func (c mp4CodecConfig) Channels() int {
+	ch, _ := c[ChannelsKey].(int)
+	return ch
+}
+
+func (c mp4CodecConfig) SampleRate() int {
+	sr, _ := c[SampleRateKey].(int64)
+	return int(sr)
+}
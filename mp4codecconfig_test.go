@@ -0,0 +1,177 @@
+package yurit
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProcessALACAtom(t *testing.T) {
+	b := []byte{
+		0, 0, 0, 0, //Version and flags
+		0x00, 0x00, 0x10, 0x00, //Frame length
+		0, 16, 40, 10, 14, 2, //Compatible version, bit depth, pb, mb, kb, channels
+		0x00, 0xFF, //Max run
+		0x00, 0x00, 0x20, 0x00, //Max frame bytes
+		0x00, 0x0F, 0x42, 0x40, //Average bitrate
+		0x00, 0x00, 0xAC, 0x44, //Sample rate
+	}
+	c, err := processALACAtom(Mp4Atom{Name: "alac", Data: b})
+	if err != nil {
+		t.Fatalf("processALACAtom() returned error: %v", err)
+	}
+	expected := mp4CodecConfig{
+		"frameLength":       int64(4096),
+		"compatibleVersion": 0,
+		SampleSizeKey:       16,
+		"pb":                40,
+		"mb":                10,
+		"kb":                14,
+		ChannelsKey:         2,
+		"maxRun":            255,
+		MaximumFrameSizeKey: int64(8192),
+		AverageBitrateKey:   int64(1000000),
+		SampleRateKey:       int64(44100),
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf("processALACAtom() = %v, expected %v", c, expected)
+	}
+	if c.SampleRate() != 44100 || c.Channels() != 2 || c.AverageBitrate() != 1000000 {
+		t.Errorf("SampleRate(), Channels(), AverageBitrate() = %v, %v, %v, expected %v, %v, %v", c.SampleRate(), c.Channels(), c.AverageBitrate(), 44100, 2, 1000000)
+	}
+
+	_, err = processALACAtom(Mp4Atom{Name: "alac", Data: b[:27]})
+	if err == nil {
+		t.Errorf("processALACAtom() expected error for short atom")
+	}
+}
+
+func TestProcessDFLAAtom(t *testing.T) {
+	streamInfo := []byte{
+		0x10, 0x00, 0x10, 0x00, //Minimum and maximum block size
+		0x00, 0x00, 0x0E, 0x00, 0x30, 0x00, //Minimum and maximum frame size
+		0x0A, 0xC4, 0x42, 0xF0, //44100 Hz, 2 channels, 16 bits
+		0x00, 0x06, 0xBA, 0xA8, //441000 samples
+	}
+	streamInfo = append(streamInfo, make([]byte, 16)...)
+	b := append([]byte{0, 0, 0, 0, 0x80, 0x00, 0x00, 0x22}, streamInfo...)
+
+	tests := []struct {
+		input      []byte
+		sampleRate int
+		channels   int
+		sampleSize int
+		makesError bool
+	}{
+		{b, 44100, 2, 16, false},
+		{b[:40], 0, 0, 0, true},
+		{append([]byte{0, 0, 0, 0, 0x81, 0x00, 0x00, 0x22}, streamInfo...), 0, 0, 0, true},
+		{b[:7], 0, 0, 0, true},
+	}
+
+	for ii, tt := range tests {
+		c, err := processDFLAAtom(Mp4Atom{Name: "dfLa", Data: tt.input})
+		if (err != nil) != tt.makesError {
+			t.Errorf("[%d] processDFLAAtom() expected error: %v - Error was: %v", ii, tt.makesError, err)
+			continue
+		}
+		if tt.makesError {
+			continue
+		}
+		if c.SampleRate() != tt.sampleRate || c.Channels() != tt.channels || c[SampleSizeKey] != tt.sampleSize {
+			t.Errorf("[%d] processDFLAAtom() = %v, %v, %v, expected %v, %v, %v", ii, c.SampleRate(), c.Channels(), c[SampleSizeKey], tt.sampleRate, tt.channels, tt.sampleSize)
+		}
+		if c[TotalSamplesKey] != int64(441000) {
+			t.Errorf("[%d] processDFLAAtom()[%q] = %v, expected %v", ii, TotalSamplesKey, c[TotalSamplesKey], 441000)
+		}
+	}
+}
+
+func TestProcessDOPSAtom(t *testing.T) {
+	b := []byte{0, 2, 0x01, 0x38, 0x00, 0x00, 0xAC, 0x44, 0xFF, 0x00, 0}
+	c, err := processDOPSAtom(Mp4Atom{Name: "dOps", Data: b})
+	if err != nil {
+		t.Fatalf("processDOPSAtom() returned error: %v", err)
+	}
+	expected := mp4CodecConfig{
+		VersionKey:             0,
+		ChannelsKey:            2,
+		"preSkip":              312,
+		"inputSampleRate":      int64(44100),
+		"outputGain":           -256,
+		"channelMappingFamily": 0,
+		SampleRateKey:          int64(48000),
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf("processDOPSAtom() = %v, expected %v", c, expected)
+	}
+
+	_, err = processDOPSAtom(Mp4Atom{Name: "dOps", Data: b[:10]})
+	if err == nil {
+		t.Errorf("processDOPSAtom() expected error for short atom")
+	}
+}
+
+func TestProcessAC3Atoms(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      []byte
+		sampleRate int
+		channels   int
+		bitrate    int
+		makesError bool
+	}{
+		//48 kHz, 3/2 with LFE, 448 kbit/s
+		{"dac3", []byte{0x10, 0x3D, 0xE0}, 48000, 6, 448000, false},
+		//Reserved sample rate and bitrate codes, mono
+		{"dac3", []byte{0xD0, 0x0B, 0xE0}, 0, 1, 0, false},
+		{"dac3", []byte{0x10, 0x3D}, 0, 0, 0, true},
+		//44.1 kHz stereo, 640 kbit/s
+		{"dec3", []byte{0x14, 0x00, 0x60, 0x04, 0x00}, 44100, 2, 640000, false},
+		{"dec3", []byte{0x14, 0x00, 0x60, 0x04}, 0, 0, 0, true},
+	}
+
+	for ii, tt := range tests {
+		var (
+			c   mp4CodecConfig
+			err error
+		)
+		if tt.name == "dac3" {
+			c, err = processDAC3Atom(Mp4Atom{Name: tt.name, Data: tt.input})
+		} else {
+			c, err = processDEC3Atom(Mp4Atom{Name: tt.name, Data: tt.input})
+		}
+		if (err != nil) != tt.makesError {
+			t.Errorf("[%d] process %s atom expected error: %v - Error was: %v", ii, tt.name, tt.makesError, err)
+			continue
+		}
+		if c.SampleRate() != tt.sampleRate || c.Channels() != tt.channels || c.AverageBitrate() != tt.bitrate {
+			t.Errorf("[%d] process %s atom = %v, %v, %v, expected %v, %v, %v", ii, tt.name, c.SampleRate(), c.Channels(), c.AverageBitrate(), tt.sampleRate, tt.channels, tt.bitrate)
+		}
+	}
+}
+
+func TestProcessMP4CodecConfig(t *testing.T) {
+	dac3 := Mp4Atom{Name: "dac3", Data: []byte{0x10, 0x3D, 0xE0}}
+	tests := []struct {
+		sampleEntryType string
+		children        []Mp4Atom
+		channels        int
+		isNil           bool
+	}{
+		{"ac-3", []Mp4Atom{{Name: "btrt"}, dac3}, 6, false},
+		{"ac-3", []Mp4Atom{{Name: "btrt"}}, 0, true},
+		{"ec-3", []Mp4Atom{dac3}, 0, true},
+		{"mp4a", []Mp4Atom{dac3}, 0, true},
+	}
+
+	for ii, tt := range tests {
+		c, err := processMP4CodecConfig(tt.sampleEntryType, tt.children)
+		if err != nil {
+			t.Errorf("[%d] processMP4CodecConfig(%q) returned error: %v", ii, tt.sampleEntryType, err)
+			continue
+		}
+		if (c == nil) != tt.isNil || c.Channels() != tt.channels {
+			t.Errorf("[%d] processMP4CodecConfig(%q) = %v, expected %v channels", ii, tt.sampleEntryType, c, tt.channels)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package yurit
+
+import (
+	"strconv"
+	"strings"
+)
+
+//processITunSMPB reads the gapless information that iTunes stores in the
+//iTunSMPB freeform tag as a list of hexadecimal numbers: a reserved value, the
+//encoder delay, the padding and the number of valid samples, followed by values
+//that aren't needed. Nil is returned if the value can't be read.
+func processITunSMPB(s string) *GaplessInfo {
+	fields := strings.Fields(s)
+	if len(fields) < 4 {
+		return nil
+	}
+	var values [3]int64
+	for i := range values {
+		v, err := strconv.ParseInt(fields[i+1], 16, 64)
+		if err != nil {
+			return nil
+		}
+		values[i] = v
+	}
+	return &GaplessInfo{
+		EncoderDelay: int(values[0]),
+		Padding:      int(values[1]),
+		ValidSamples: values[2],
+	}
+}
+
+//processELSTAtom returns the segment duration, in the movie time scale, and the
+//media time, in the media time scale, of the first edit of an edit list atom
+//(elst) that isn't an empty edit. False is returned if there is no such edit.
+//Version 0 entries are 12 bytes and version 1 entries are 20 bytes, each with
+//the segment duration, the media time and the media rate.
+func processELSTAtom(elstAtom Mp4Atom) (int64, int64, bool) {
+	b := elstAtom.Data
+	if len(b) < 8 {
+		return 0, 0, false
+	}
+	entrySize := 12
+	if b[0] == 1 {
+		entrySize = 20
+	}
+	numEntries := int(getUint32AsInt64(b[4:8]))
+	for i := 0; i < numEntries; i++ {
+		offset := 8 + i*entrySize
+		if len(b) < offset+entrySize {
+			return 0, 0, false
+		}
+		var segmentDuration, mediaTime int64
+		if entrySize == 20 {
+			segmentDuration = getInt64(b[offset : offset+8])
+			mediaTime = getInt64(b[offset+8 : offset+16])
+		} else {
+			segmentDuration = getUint32AsInt64(b[offset : offset+4])
+			mediaTime = int64(getInt32AsInt(b[offset+4 : offset+8]))
+		}
+		//A media time of -1 is an empty edit, which inserts time with no media
+		if mediaTime != -1 {
+			return segmentDuration, mediaTime, true
+		}
+	}
+	return 0, 0, false
+}
+
+//getMDHDDuration returns the duration in the media time scale from a media
+//header atom (mdhd), which follows the time scale, or 0 if it can't be read.
+func getMDHDDuration(mdhdAtom Mp4Atom) int64 {
+	if len(mdhdAtom.Data) > 0 && mdhdAtom.Data[0] == 1 {
+		if len(mdhdAtom.Data) < 32 {
+			return 0
+		}
+		return getInt64(mdhdAtom.Data[24:32])
+	}
+	if len(mdhdAtom.Data) < 20 {
+		return 0
+	}
+	return getUint32AsInt64(mdhdAtom.Data[16:20])
+}
+
+//readEditListGaplessInfo works out the gapless information of the audio track
+//from its edit list, which is how encoders other than iTunes mark the encoder
+//delay and padding. The media time of the edit is the encoder delay, the
+//segment duration is the length of the valid audio, and anything left at the
+//end of the media is padding.
+func readEditListGaplessInfo(moovAtom Mp4Atom, movieTimeScale int64, sampleRate int) *GaplessInfo {
+	for _, trak := range moovAtom.Children {
+		if trak.Name != "trak" || findSampleEntryAtom(trak.Children) == nil {
+			continue
+		}
+		elstAtom := findAtom(trak.Children, "elst")
+		mdhdAtom := findAtom(trak.Children, "mdhd")
+		if elstAtom == nil || mdhdAtom == nil || movieTimeScale <= 0 {
+			return nil
+		}
+		segmentDuration, mediaTime, ok := processELSTAtom(*elstAtom)
+		mediaTimeScale := getMDHDTimeScale(*mdhdAtom)
+		if !ok || mediaTimeScale <= 0 {
+			return nil
+		}
+		//Convert from the media time scale to samples, which are usually the same
+		toSamples := func(t int64) int64 {
+			if sampleRate <= 0 {
+				return t
+			}
+			return t * int64(sampleRate) / mediaTimeScale
+		}
+		validSamples := toSamples(segmentDuration * mediaTimeScale / movieTimeScale)
+		g := &GaplessInfo{
+			EncoderDelay: int(toSamples(mediaTime)),
+			ValidSamples: validSamples,
+		}
+		if padding := toSamples(getMDHDDuration(*mdhdAtom)) - int64(g.EncoderDelay) - validSamples; padding > 0 {
+			g.Padding = int(padding)
+		}
+		return g
+	}
+	return nil
+}
+
+//processSGPDRollAtom returns the roll distance of the first entry of a sample
+//group description atom (sgpd) with a grouping type of roll or prol. False is
+//returned if the atom has another grouping type or no entries. The roll
+//distance is a signed number of samples, here MP4 samples, that must be decoded
+//before the audio is valid, which is negative when they come before it.
+//Bytes: 1 version, 3 flags, 4 grouping type, 4 default length (version 1), 4
+//default sample description index (version 2 and above), 4 entry count, then
+//for each entry 4 description length (version 1 with no default length) and a
+//2 byte roll distance.
+func processSGPDRollAtom(sgpdAtom Mp4Atom) (int, bool) {
+	b := sgpdAtom.Data
+	if len(b) < 8 {
+		return 0, false
+	}
+	if groupingType := string(b[4:8]); groupingType != "roll" && groupingType != "prol" {
+		return 0, false
+	}
+	offset := 8
+	var defaultLength int64
+	if b[0] == 1 {
+		if len(b) < offset+4 {
+			return 0, false
+		}
+		defaultLength = getUint32AsInt64(b[offset : offset+4])
+		offset += 4
+	} else if b[0] >= 2 {
+		offset += 4
+	}
+	if len(b) < offset+4 || getUint32AsInt64(b[offset:offset+4]) == 0 {
+		return 0, false
+	}
+	offset += 4
+	if b[0] == 1 && defaultLength == 0 {
+		offset += 4
+	}
+	if len(b) < offset+2 {
+		return 0, false
+	}
+	return int(int16(getUint16AsInt(b[offset : offset+2]))), true
+}
+
+//readRollGaplessInfo works out the encoder delay of the audio track from the
+//roll distance in the sample group descriptions of its sample table, for files
+//with no edit list. A roll distance of -n means that the first n samples only
+//prime the decoder, so the delay is their total duration. Padding and the
+//number of valid samples can't be known from the roll distance.
+func readRollGaplessInfo(moovAtom Mp4Atom, sampleRate int) *GaplessInfo {
+	for _, trak := range moovAtom.Children {
+		if trak.Name != "trak" || findSampleEntryAtom(trak.Children) == nil {
+			continue
+		}
+		mdhdAtom := findAtom(trak.Children, "mdhd")
+		stblAtom := findAtom(trak.Children, "stbl")
+		sttsAtom := findAtom(trak.Children, "stts")
+		if mdhdAtom == nil || stblAtom == nil || sttsAtom == nil {
+			return nil
+		}
+		var (
+			roll int
+			ok   bool
+		)
+		for _, a := range stblAtom.Children {
+			if a.Name == "sgpd" {
+				if roll, ok = processSGPDRollAtom(a); ok {
+					break
+				}
+			}
+		}
+		timeToSample, err := processSTTSAtom(*sttsAtom)
+		mediaTimeScale := getMDHDTimeScale(*mdhdAtom)
+		if !ok || roll >= 0 || err != nil || len(timeToSample) == 0 || mediaTimeScale <= 0 {
+			return nil
+		}
+		delay := int64(-roll) * int64(timeToSample[0].sampleDuration)
+		if sampleRate > 0 {
+			delay = delay * int64(sampleRate) / mediaTimeScale
+		}
+		return &GaplessInfo{EncoderDelay: int(delay)}
+	}
+	return nil
+}
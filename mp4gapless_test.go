@@ -0,0 +1,107 @@
+package yurit
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProcessITunSMPB(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected *GaplessInfo
+	}{
+		{" 00000000 00000840 000001C0 0000000000A0B7F0 00000000 00000000", &GaplessInfo{EncoderDelay: 2112, Padding: 448, ValidSamples: 10532848}},
+		{"00000000 00000840 000001C0", nil},
+		{" 00000000 0000084X 000001C0 0000000000A0B7F0", nil},
+	}
+
+	for ii, tt := range tests {
+		g := processITunSMPB(tt.input)
+		if !reflect.DeepEqual(g, tt.expected) {
+			t.Errorf("[%d] processITunSMPB(%q) = %v, expected %v", ii, tt.input, g, tt.expected)
+		}
+	}
+}
+
+func TestReadEditListGaplessInfo(t *testing.T) {
+	//Version 0 edit list with an empty edit followed by an edit starting at 1024
+	elst := Mp4Atom{Name: "elst", Data: []byte{
+		0, 0, 0, 0, 0, 0, 0, 2,
+		0, 0, 0, 10, 0xFF, 0xFF, 0xFF, 0xFF, 0, 1, 0, 0,
+		0, 0, 0x03, 0xE8, 0, 0, 0x04, 0, 0, 1, 0, 0,
+	}}
+	//Version 0 media header with a time scale of 44100 and a duration of 46000
+	mdhd := Mp4Atom{Name: "mdhd", Data: []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xAC, 0x44, 0, 0, 0xB3, 0xB0}}
+	moov := Mp4Atom{Name: "moov", Children: []Mp4Atom{{Name: "trak", Children: []Mp4Atom{
+		{Name: "edts", Children: []Mp4Atom{elst}},
+		{Name: "mdia", Children: []Mp4Atom{mdhd, {Name: "stsd", Children: []Mp4Atom{{Name: "mp4a"}}}}},
+	}}}}
+	//1000 in a movie time scale of 1000 is one second, or 44100 samples
+	g := readEditListGaplessInfo(moov, 1000, 44100)
+	expected := &GaplessInfo{EncoderDelay: 1024, Padding: 876, ValidSamples: 44100}
+	if !reflect.DeepEqual(g, expected) {
+		t.Errorf("readEditListGaplessInfo() = %v, expected %v", g, expected)
+	}
+	if g := readEditListGaplessInfo(moov, 0, 44100); g != nil {
+		t.Errorf("readEditListGaplessInfo() without movie time scale = %v, expected nil", g)
+	}
+}
+
+func TestProcessSGPDRollAtom(t *testing.T) {
+	tests := []struct {
+		input []byte
+		roll  int
+		ok    bool
+	}{
+		//Version 1 with a default length of 2 and one entry of -1
+		{[]byte{1, 0, 0, 0, 'r', 'o', 'l', 'l', 0, 0, 0, 2, 0, 0, 0, 1, 0xFF, 0xFF}, -1, true},
+		//Version 1 with no default length, so the entry has its own length
+		{[]byte{1, 0, 0, 0, 'p', 'r', 'o', 'l', 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 2, 0xFF, 0xFC}, -4, true},
+		//Version 2 with a default sample description index
+		{[]byte{2, 0, 0, 0, 'r', 'o', 'l', 'l', 0, 0, 0, 1, 0, 0, 0, 1, 0xFF, 0xFE}, -2, true},
+		{[]byte{1, 0, 0, 0, 's', 'y', 'n', 'c', 0, 0, 0, 1, 0, 0, 0, 1, 0x02}, 0, false},
+		{[]byte{1, 0, 0, 0, 'r', 'o', 'l', 'l', 0, 0, 0, 2, 0, 0, 0, 0}, 0, false},
+		{[]byte{1, 0, 0, 0, 'r', 'o', 'l', 'l', 0, 0, 0, 2, 0, 0, 0, 1, 0xFF}, 0, false},
+	}
+
+	for ii, tt := range tests {
+		roll, ok := processSGPDRollAtom(Mp4Atom{Name: "sgpd", Data: tt.input})
+		if roll != tt.roll || ok != tt.ok {
+			t.Errorf("[%d] processSGPDRollAtom(%v) = %v, %v, expected %v, %v", ii, tt.input, roll, ok, tt.roll, tt.ok)
+		}
+	}
+}
+
+func TestReadRollGaplessInfo(t *testing.T) {
+	//Version 0 media header with a time scale of 48000
+	mdhd := Mp4Atom{Name: "mdhd", Data: []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xBB, 0x80, 0, 0, 0, 0}}
+	//Samples of 960
+	stts := Mp4Atom{Name: "stts", Data: []byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 10, 0, 0, 0x03, 0xC0}}
+	sync := Mp4Atom{Name: "sgpd", Data: []byte{1, 0, 0, 0, 's', 'y', 'n', 'c', 0, 0, 0, 1, 0, 0, 0, 1, 0x02}}
+	roll := Mp4Atom{Name: "sgpd", Data: []byte{1, 0, 0, 0, 'r', 'o', 'l', 'l', 0, 0, 0, 2, 0, 0, 0, 1, 0xFF, 0xFC}}
+	moov := func(stbl ...Mp4Atom) Mp4Atom {
+		return Mp4Atom{Name: "moov", Children: []Mp4Atom{{Name: "trak", Children: []Mp4Atom{
+			{Name: "mdia", Children: []Mp4Atom{mdhd, {Name: "minf", Children: []Mp4Atom{
+				{Name: "stbl", Children: append([]Mp4Atom{{Name: "stsd", Children: []Mp4Atom{{Name: "Opus"}}}}, stbl...)},
+			}}}},
+		}}}}
+	}
+	tests := []struct {
+		moov       Mp4Atom
+		sampleRate int
+		expected   *GaplessInfo
+	}{
+		{moov(stts, sync, roll), 48000, &GaplessInfo{EncoderDelay: 3840}},
+		//The media time scale is converted to the sample rate
+		{moov(stts, roll), 24000, &GaplessInfo{EncoderDelay: 1920}},
+		{moov(stts, sync), 48000, nil},
+		{moov(roll), 48000, nil},
+	}
+
+	for ii, tt := range tests {
+		g := readRollGaplessInfo(tt.moov, tt.sampleRate)
+		if !reflect.DeepEqual(g, tt.expected) {
+			t.Errorf("[%d] readRollGaplessInfo() = %v, expected %v", ii, g, tt.expected)
+		}
+	}
+}
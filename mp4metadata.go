@@ -31,6 +31,9 @@ func processILSTAtom(ilst Mp4Atom) (mp4metadata, error) {
 		}
 		//Find first data atom
 		dataAtom := findAtom(metadataItem.Children, "data")
+		if dataAtom == nil {
+			continue
+		}
 		if len(dataAtom.Data) < 8 {
 			return nil, fmt.Errorf("invalid encoding: expected at least %d bytes for atom version and flags, got %d", 8, len(dataAtom.Data))
 		}
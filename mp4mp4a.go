@@ -12,8 +12,27 @@ func processMP4AAtom(mp4aAtom Mp4Atom) (mp4mp4a, mp4esds, error) {
 	//mp4a atom is a sample description stored as a child of a sample
 	//description atom (stsd) and it contains channel and sample rate info and
 	//also likely has a child esds atom that we need info from as well.
-	//https://developer.apple.com/library/archive/documentation/QuickTime/QTFF/QTFFChap2/qtff2.html#//apple_ref/doc/uid/TP40000939-CH204-61112
-	//https://developer.apple.com/library/archive/documentation/QuickTime/QTFF/QTFFChap3/qtff3.html#//apple_ref/doc/uid/TP40000939-CH205-75770
+	mp4a, children, err := processSoundSampleDescription(mp4aAtom)
+	if err != nil {
+		return mp4a, nil, err
+	}
+	esdsAtom := findAtom(children, "esds")
+	if esdsAtom == nil {
+		return mp4a, nil, nil
+	}
+	esds, err := processESDSAtom(*esdsAtom)
+	if err != nil {
+		return mp4a, nil, err
+	}
+	return mp4a, esds, nil
+}
+
+//processSoundSampleDescription reads the fields that are common to all sound
+//sample descriptions (mp4a, alac, fLaC, etc.) and returns them along with the
+//child atoms that follow them, which hold codec specific information.
+//https://developer.apple.com/library/archive/documentation/QuickTime/QTFF/QTFFChap2/qtff2.html#//apple_ref/doc/uid/TP40000939-CH204-61112
+//https://developer.apple.com/library/archive/documentation/QuickTime/QTFF/QTFFChap3/qtff3.html#//apple_ref/doc/uid/TP40000939-CH205-75770
+func processSoundSampleDescription(mp4aAtom Mp4Atom) (mp4mp4a, []Mp4Atom, error) {
 	if len(mp4aAtom.Data) < 28 {
 		return nil, nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", 28, len(mp4aAtom.Data))
 	}
@@ -67,15 +86,7 @@ func processMP4AAtom(mp4aAtom Mp4Atom) (mp4mp4a, mp4esds, error) {
 	if err != nil {
 		return mp4a, nil, err
 	}
-	esdsAtom := findAtom(children, "esds")
-	if esdsAtom == nil {
-		return mp4a, nil, nil
-	}
-	esds, err := processESDSAtom(*esdsAtom)
-	if err != nil {
-		return mp4a, nil, err
-	}
-	return mp4a, esds, nil
+	return mp4a, children, nil
 }
 
 type mp4esds map[string]interface{}
@@ -147,3 +158,13 @@ func (m mp4esds) AverageBitrate() int {
 	//Converting down to int here is very low risk for the sake of type sanity.
 	return int(b)
 }
+
+func (mp4a mp4mp4a) Channels() int {
+	c, _ := mp4a[ChannelsKey].(int)
+	return c
+}
+
+func (mp4a mp4mp4a) SampleRate() int {
+	sr, _ := mp4a[SampleRateKey].(float64)
+	return int(sr)
+}
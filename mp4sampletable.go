@@ -0,0 +1,359 @@
+package yurit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+//mp4SampleTable holds the tables from a sample table atom (stbl) that are
+//needed to find the time and location of every sample in a track.
+//https://developer.apple.com/library/archive/documentation/QuickTime/QTFF/QTFFChap2/qtff2.html#//apple_ref/doc/uid/TP40000939-CH204-BBCBEAIF
+type mp4SampleTable struct {
+	//timeToSample holds pairs of sample count and sample duration (stts)
+	timeToSample []mp4TimeToSampleEntry
+	//sampleToChunk holds the number of samples in each run of chunks (stsc)
+	sampleToChunk []mp4SampleToChunkEntry
+	//sampleSize is the size of every sample if they are all the same size, else
+	//0 and sampleSizes holds the size of each sample (stsz). A single size is
+	//not expanded, as the sample count comes straight from the file.
+	sampleSize  uint32
+	sampleSizes []uint32
+	//sampleCount is the number of samples in the track
+	sampleCount int
+	//chunkOffsets holds the file offset of each chunk (stco or co64)
+	chunkOffsets []int64
+}
+
+type mp4TimeToSampleEntry struct {
+	sampleCount    uint32
+	sampleDuration uint32
+}
+
+type mp4SampleToChunkEntry struct {
+	firstChunk      uint32
+	samplesPerChunk uint32
+}
+
+//processSampleTable reads the sample table of a track from the children of its
+//stbl atom. Any tables that are missing are left empty.
+func processSampleTable(stblAtom Mp4Atom) (*mp4SampleTable, error) {
+	var (
+		t   mp4SampleTable
+		err error
+	)
+	if a := findAtom(stblAtom.Children, "stts"); a != nil {
+		t.timeToSample, err = processSTTSAtom(*a)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if a := findAtom(stblAtom.Children, "stsc"); a != nil {
+		t.sampleToChunk, err = processSTSCAtom(*a)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if a := findAtom(stblAtom.Children, "stsz"); a != nil {
+		t.sampleSize, t.sampleCount, t.sampleSizes, err = processSTSZAtom(*a)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if a := findAtom(stblAtom.Children, "stco"); a != nil {
+		t.chunkOffsets, err = processChunkOffsetAtom(*a, 4)
+	} else if a := findAtom(stblAtom.Children, "co64"); a != nil {
+		t.chunkOffsets, err = processChunkOffsetAtom(*a, 8)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+//processTableAtomHeader checks that a table atom holds version, flags and an
+//entry count followed by count entries of entrySize bytes, skipping
+//headerSize bytes after the version and flags. It returns the entry count.
+func processTableAtomHeader(a Mp4Atom, headerSize int, entrySize int) (int, error) {
+	if len(a.Data) < 8+headerSize {
+		return 0, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", 8+headerSize, len(a.Data))
+	}
+	count := int(getUint32AsInt64(a.Data[4+headerSize : 8+headerSize]))
+	if (len(a.Data)-8-headerSize)/entrySize < count {
+		return 0, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", 8+headerSize+count*entrySize, len(a.Data))
+	}
+	return count, nil
+}
+
+//processSTTSAtom reads a time-to-sample atom.
+func processSTTSAtom(sttsAtom Mp4Atom) ([]mp4TimeToSampleEntry, error) {
+	count, err := processTableAtomHeader(sttsAtom, 0, 8)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]mp4TimeToSampleEntry, count)
+	for i := range entries {
+		b := sttsAtom.Data[8+i*8:]
+		entries[i] = mp4TimeToSampleEntry{
+			sampleCount:    uint32(getUint32AsInt64(b[0:4])),
+			sampleDuration: uint32(getUint32AsInt64(b[4:8])),
+		}
+	}
+	return entries, nil
+}
+
+//processSTSCAtom reads a sample-to-chunk atom. The sample description ID of
+//each entry is not kept.
+func processSTSCAtom(stscAtom Mp4Atom) ([]mp4SampleToChunkEntry, error) {
+	count, err := processTableAtomHeader(stscAtom, 0, 12)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]mp4SampleToChunkEntry, count)
+	for i := range entries {
+		b := stscAtom.Data[8+i*12:]
+		entries[i] = mp4SampleToChunkEntry{
+			firstChunk:      uint32(getUint32AsInt64(b[0:4])),
+			samplesPerChunk: uint32(getUint32AsInt64(b[4:8])),
+		}
+	}
+	return entries, nil
+}
+
+//processSTSZAtom reads a sample size atom. It returns the single size of all
+//samples and the sample count, along with the size of each sample if the
+//single size is 0.
+func processSTSZAtom(stszAtom Mp4Atom) (uint32, int, []uint32, error) {
+	if len(stszAtom.Data) < 12 {
+		return 0, 0, nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", 12, len(stszAtom.Data))
+	}
+	sampleSize := uint32(getUint32AsInt64(stszAtom.Data[4:8]))
+	if sampleSize != 0 {
+		return sampleSize, int(getUint32AsInt64(stszAtom.Data[8:12])), nil, nil
+	}
+	count, err := processTableAtomHeader(stszAtom, 4, 4)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	sizes := make([]uint32, count)
+	for i := range sizes {
+		sizes[i] = uint32(getUint32AsInt64(stszAtom.Data[12+i*4 : 16+i*4]))
+	}
+	return 0, count, sizes, nil
+}
+
+//processChunkOffsetAtom reads a chunk offset atom, where offsetSize is 4 for
+//stco and 8 for co64.
+func processChunkOffsetAtom(a Mp4Atom, offsetSize int) ([]int64, error) {
+	count, err := processTableAtomHeader(a, 0, offsetSize)
+	if err != nil {
+		return nil, err
+	}
+	offsets := make([]int64, count)
+	for i := range offsets {
+		b := a.Data[8+i*offsetSize : 8+(i+1)*offsetSize]
+		if offsetSize == 8 {
+			offsets[i] = int64(getUint64(b))
+		} else {
+			offsets[i] = getUint32AsInt64(b)
+		}
+	}
+	return offsets, nil
+}
+
+//sizeOf returns the size of sample n, which must be less than sampleCount.
+func (t mp4SampleTable) sizeOf(n int) uint32 {
+	if t.sampleSize != 0 {
+		return t.sampleSize
+	}
+	return t.sampleSizes[n]
+}
+
+//eachSample calls fn with the start time, in the track's time scale, file
+//offset and size of each sample in turn, found by walking through the chunks
+//and the time-to-sample entries side by side. The tables aren't expanded, as
+//their counts come straight from the file. The walk stops at the first error
+//returned by fn, which is returned.
+func (t mp4SampleTable) eachSample(fn func(time int64, offset int64, size uint32) error) error {
+	var (
+		sample int
+		time   int64
+		stts   = -1
+		left   uint32
+	)
+	for i, e := range t.sampleToChunk {
+		//The entry applies to every chunk until the first chunk of the next entry
+		lastChunk := uint32(len(t.chunkOffsets))
+		if i+1 < len(t.sampleToChunk) {
+			lastChunk = t.sampleToChunk[i+1].firstChunk - 1
+		}
+		for chunk := e.firstChunk; chunk >= 1 && chunk <= lastChunk && int(chunk) <= len(t.chunkOffsets); chunk++ {
+			offset := t.chunkOffsets[chunk-1]
+			for j := uint32(0); j < e.samplesPerChunk && sample < t.sampleCount; j++ {
+				for left == 0 {
+					stts++
+					if stts >= len(t.timeToSample) {
+						return nil
+					}
+					left = t.timeToSample[stts].sampleCount
+				}
+				size := t.sizeOf(sample)
+				if err := fn(time, offset, size); err != nil {
+					return err
+				}
+				offset += int64(size)
+				time += int64(t.timeToSample[stts].sampleDuration)
+				left--
+				sample++
+			}
+		}
+	}
+	return nil
+}
+
+//MP4SampleTable is the sample table of the audio track of an MP4 file, which
+//gives the time and location of every sample. Here a sample is an MP4 sample,
+//i.e. one frame of compressed audio, not a single PCM sample.
+type MP4SampleTable struct {
+	table     mp4SampleTable
+	timeScale int64
+}
+
+//readAudioSampleTable reads the sample table and media time scale of the first
+//track of the moov atom that has a sound sample description. Nil is returned if
+//there is no such track or it has no sample table.
+func readAudioSampleTable(moovAtom Mp4Atom) (*MP4SampleTable, error) {
+	for _, trak := range moovAtom.Children {
+		if trak.Name != "trak" || findSampleEntryAtom(trak.Children) == nil {
+			continue
+		}
+		mdhdAtom := findAtom(trak.Children, "mdhd")
+		stblAtom := findAtom(trak.Children, "stbl")
+		if mdhdAtom == nil || stblAtom == nil {
+			return nil, nil
+		}
+		t, err := processSampleTable(*stblAtom)
+		if err != nil {
+			return nil, err
+		}
+		return &MP4SampleTable{table: *t, timeScale: getMDHDTimeScale(*mdhdAtom)}, nil
+	}
+	return nil, nil
+}
+
+//Duration returns the total duration of the samples, which unlike the duration
+//in the movie header is exact and ignores any edit list.
+func (t MP4SampleTable) Duration() time.Duration {
+	if t.timeScale <= 0 {
+		return time.Duration(0)
+	}
+	return time.Duration(float64(t.TotalTime()) / float64(t.timeScale) * float64(time.Second))
+}
+
+//SampleCount returns the number of samples in the track.
+func (t MP4SampleTable) SampleCount() int {
+	return t.table.sampleCount
+}
+
+//TimeScale returns the number of time units per second of the track, from its
+//media header atom (mdhd).
+func (t MP4SampleTable) TimeScale() int64 {
+	return t.timeScale
+}
+
+//TotalTime returns the total duration of the samples in the track's time scale.
+func (t MP4SampleTable) TotalTime() int64 {
+	var total int64
+	for _, e := range t.table.timeToSample {
+		total += int64(e.sampleCount) * int64(e.sampleDuration)
+	}
+	return total
+}
+
+//SampleAt returns the index of the sample that is playing at time d from the
+//start of the track.
+func (t MP4SampleTable) SampleAt(d time.Duration) (int, error) {
+	if t.timeScale <= 0 {
+		return 0, errors.New("invalid time scale: 0")
+	}
+	if d < 0 || d > t.Duration() {
+		return 0, fmt.Errorf("invalid time: %v, expected between 0 and %v", d, t.Duration())
+	}
+	//Round to the nearest time unit, as a duration is usually a little short of
+	//the sample start that it was made from
+	ts := int64(math.Round(d.Seconds() * float64(t.timeScale)))
+	var (
+		sample int64
+		start  int64
+	)
+	for _, e := range t.table.timeToSample {
+		end := start + int64(e.sampleCount)*int64(e.sampleDuration)
+		if ts < end && e.sampleDuration > 0 {
+			return int(sample + (ts-start)/int64(e.sampleDuration)), nil
+		}
+		sample += int64(e.sampleCount)
+		start = end
+	}
+	//Seeking to the very end gives the last sample
+	if sample > 0 {
+		return int(sample - 1), nil
+	}
+	return 0, errors.New("sample table has no samples")
+}
+
+//SampleOffset returns the file offset of sample n.
+func (t MP4SampleTable) SampleOffset(n int) (int64, error) {
+	chunkOffset, first, err := t.chunkOfSample(n)
+	if err != nil {
+		return 0, err
+	}
+	if t.table.sampleSize != 0 {
+		return chunkOffset + int64(n-first)*int64(t.table.sampleSize), nil
+	}
+	for i := first; i < n; i++ {
+		chunkOffset += int64(t.table.sampleSizes[i])
+	}
+	return chunkOffset, nil
+}
+
+//ChunkOffsetAt returns the file offset of the chunk holding the sample that is
+//playing at time d, along with the index of the first sample of that chunk,
+//which is where reading should start from.
+func (t MP4SampleTable) ChunkOffsetAt(d time.Duration) (int64, int, error) {
+	n, err := t.SampleAt(d)
+	if err != nil {
+		return 0, 0, err
+	}
+	return t.chunkOfSample(n)
+}
+
+//chunkOfSample returns the file offset of the chunk holding sample n and the
+//index of the first sample in that chunk.
+func (t MP4SampleTable) chunkOfSample(n int) (int64, int, error) {
+	if n < 0 || n >= t.table.sampleCount {
+		return 0, 0, fmt.Errorf("invalid sample: %d, expected between 0 and %d", n, t.table.sampleCount-1)
+	}
+	first := 0
+	for i, e := range t.table.sampleToChunk {
+		//The entry applies to every chunk until the first chunk of the next entry
+		lastChunk := int64(len(t.table.chunkOffsets))
+		if i+1 < len(t.table.sampleToChunk) {
+			lastChunk = int64(t.table.sampleToChunk[i+1].firstChunk) - 1
+		}
+		chunks := lastChunk - int64(e.firstChunk) + 1
+		if e.firstChunk < 1 || e.samplesPerChunk == 0 || chunks <= 0 {
+			continue
+		}
+		if int64(n) < int64(first)+chunks*int64(e.samplesPerChunk) {
+			c := int64(n-first) / int64(e.samplesPerChunk)
+			chunk := int64(e.firstChunk) + c
+			if chunk > int64(len(t.table.chunkOffsets)) {
+				break
+			}
+			return t.table.chunkOffsets[chunk-1], first + int(c)*int(e.samplesPerChunk), nil
+		}
+		first += int(chunks) * int(e.samplesPerChunk)
+	}
+	return 0, 0, fmt.Errorf("sample %d is not in any chunk", n)
+}
@@ -0,0 +1,85 @@
+package yurit
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMP4SampleTableSeek(t *testing.T) {
+	//Samples of 1024 at 44100 Hz, with 3 chunks of 2 samples and then 2 chunks
+	//of 1 sample
+	st := MP4SampleTable{
+		table: mp4SampleTable{
+			timeToSample:  []mp4TimeToSampleEntry{{7, 1024}, {1, 512}},
+			sampleToChunk: []mp4SampleToChunkEntry{{1, 2}, {4, 1}},
+			sampleSizes:   []uint32{10, 11, 12, 13, 14, 15, 16, 17},
+			sampleCount:   8,
+			chunkOffsets:  []int64{100, 200, 300, 400, 500},
+		},
+		timeScale: 44100,
+	}
+	if tt := st.TotalTime(); tt != 7*1024+512 {
+		t.Errorf("TotalTime() = %d, expected %d", tt, 7*1024+512)
+	}
+	tests := []struct {
+		time        time.Duration
+		sample      int
+		chunkOffset int64
+		chunkSample int
+		offset      int64
+		makesError  bool
+	}{
+		{0, 0, 100, 0, 100, false},
+		{samplesToDuration(1024, 44100), 1, 100, 0, 110, false},
+		{samplesToDuration(3*1024+10, 44100), 3, 200, 2, 212, false},
+		{samplesToDuration(6*1024, 44100), 6, 400, 6, 400, false},
+		{st.Duration(), 7, 500, 7, 500, false},
+		{-time.Second, 0, 0, 0, 0, true},
+		{time.Second, 0, 0, 0, 0, true},
+	}
+	for ii, tt := range tests {
+		sample, err := st.SampleAt(tt.time)
+		if (err != nil) != tt.makesError {
+			t.Errorf("[%d] SampleAt(%v) expected error: %v - Error was: %v", ii, tt.time, tt.makesError, err)
+			continue
+		}
+		if tt.makesError {
+			continue
+		}
+		if sample != tt.sample {
+			t.Errorf("[%d] SampleAt(%v) = %d, expected %d", ii, tt.time, sample, tt.sample)
+		}
+		chunkOffset, chunkSample, err := st.ChunkOffsetAt(tt.time)
+		if err != nil || chunkOffset != tt.chunkOffset || chunkSample != tt.chunkSample {
+			t.Errorf("[%d] ChunkOffsetAt(%v) = %d, %d, %v, expected %d, %d", ii, tt.time, chunkOffset, chunkSample, err, tt.chunkOffset, tt.chunkSample)
+		}
+		offset, err := st.SampleOffset(sample)
+		if err != nil || offset != tt.offset {
+			t.Errorf("[%d] SampleOffset(%d) = %d, %v, expected %d", ii, sample, offset, err, tt.offset)
+		}
+	}
+}
+
+func TestMP4SampleTableFromFile(t *testing.T) {
+	f, err := os.Open("./testdata/without_tags/sample.m4a")
+	if err != nil {
+		t.Fatalf("error opening file: %v", err)
+	}
+	defer f.Close()
+	m, err := ReadMP4(f)
+	if err != nil {
+		t.Fatalf("ReadMP4() returned error: %v", err)
+	}
+	st := m.SampleTable()
+	if st == nil {
+		t.Fatalf("SampleTable() = nil")
+	}
+	if st.SampleCount() != 147 || st.TotalTime() != 147*1024 || st.TimeScale() != 44100 {
+		t.Errorf("SampleTable() has %d samples and time %d/%d, expected 147 samples and time %d/44100", st.SampleCount(), st.TotalTime(), st.TimeScale(), 147*1024)
+	}
+	offset, sample, err := st.ChunkOffsetAt(time.Second)
+	if err != nil || offset != 9422 || sample != 43 {
+		t.Errorf("ChunkOffsetAt(1s) = %d, %d, %v, expected 9422, 43", offset, sample, err)
+	}
+}
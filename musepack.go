@@ -0,0 +1,362 @@
+package yurit
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+//musepackStreamInfo holds information about the audio in a Musepack file, read
+//either from an SV7 header or from the packets at the start of an SV8 stream.
+type musepackStreamInfo map[string]interface{}
+
+//MusepackMetadata is a collection of metadata from a Musepack (.mpc) file,
+//including tags and stream information.
+type MusepackMetadata struct {
+	apeID3Tags
+	fileSize   int64
+	streamInfo musepackStreamInfo
+}
+
+//Musepack sample rates by index, shared by SV7 and SV8
+var musepackSampleRates = []int{44100, 48000, 37800, 32000}
+
+const (
+	//musepackFrameLength is the number of samples in each SV7 frame
+	musepackFrameLength = 1152
+	//musepackSynthDelay is the number of samples of decoder delay removed from
+	//SV7 streams that are not true gapless
+	musepackSynthDelay = 481
+)
+
+// ReadMusepack reads tags and stream information from a Musepack SV7 or SV8
+// file, returning the resulting metadata in a Metadata implementation, or
+// non-nil error if there was a problem.
+func ReadMusepack(r io.ReadSeeker) (*MusepackMetadata, error) {
+	var (
+		m   MusepackMetadata
+		err error
+	)
+	m.fileSize, err = r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	//Extract any ID3v2 tags, if any. This leaves the reader positioned right
+	//after the tag, or at the beginning if there is no tag.
+	m.id3v2Tags, err = ReadID3v2Tags(r)
+	if err != nil {
+		return nil, err
+	}
+	b, err := readBytes(r, 4)
+	if err != nil {
+		return nil, err
+	}
+	if string(b) == "MPCK" {
+		m.streamInfo, err = readMusepackSV8Packets(r)
+	} else if string(b[0:3]) == "MP+" {
+		m.streamInfo, err = readMusepackSV7Header(r, b[3])
+	} else {
+		return nil, errors.New("expected 'MPCK' or 'MP+'")
+	}
+	if err != nil {
+		return nil, err
+	}
+	//Look for an ID3v1 tag and an APE tag at the end of the file
+	m.id3v1tags, err = ReadID3v1Tags(r)
+	if err != nil {
+		return nil, err
+	}
+	m.apeTags, err = ReadAPEv2Tags(r)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+//readMusepackSV7Header reads the rest of an SV7 header after the 'MP+' magic
+//and the version byte, which is given as version.
+//https://trac.musepack.net/musepack/wiki/SV7Specification
+func readMusepackSV7Header(r io.Reader, version byte) (musepackStreamInfo, error) {
+	if version&0x0F != 7 {
+		return nil, fmt.Errorf("unsupported Musepack stream version: %d", version&0x0F)
+	}
+	b, err := readBytes(r, 24)
+	if err != nil {
+		return nil, err
+	}
+	si := musepackStreamInfo{}
+	si[VersionKey] = 7
+	frames := int64(binary.LittleEndian.Uint32(b[0:4]))
+	flags := binary.LittleEndian.Uint32(b[4:8])
+	si[TotalFramesKey] = frames
+	si["maxBand"] = int(flags>>24) & 0x3F
+	si["profile"] = int(flags>>20) & 0x0F
+	si["midSideStereo"] = flags>>30&0x01 == 1
+	si["intensityStereo"] = flags>>31 == 1
+	si[SampleRateKey] = musepackSampleRates[flags>>16&0x03]
+	si[ChannelsKey] = 2
+	//Gains are in hundredths of a dB
+	si["titlePeak"] = int(binary.LittleEndian.Uint16(b[8:10]))
+	si["titleGain"] = int(int16(binary.LittleEndian.Uint16(b[10:12])))
+	si["albumPeak"] = int(binary.LittleEndian.Uint16(b[12:14]))
+	si["albumGain"] = int(int16(binary.LittleEndian.Uint16(b[14:16])))
+	gapless := binary.LittleEndian.Uint32(b[16:20])
+	trueGapless := gapless>>31 == 1
+	lastFrameSamples := int64(gapless>>20) & 0x07FF
+	si["trueGapless"] = trueGapless
+	si["encoderVersion"] = int(b[20])
+	totalSamples := frames * musepackFrameLength
+	if trueGapless {
+		totalSamples -= musepackFrameLength - lastFrameSamples
+	} else {
+		totalSamples -= musepackSynthDelay
+	}
+	if totalSamples < 0 {
+		totalSamples = 0
+	}
+	si[TotalSamplesKey] = totalSamples
+	return si, nil
+}
+
+//readMusepackSV8Packets reads the packets at the start of an SV8 stream, right
+//after the 'MPCK' magic, until the first audio packet. Each packet starts with
+//a 2 character key and a variable length size that includes the key and the
+//size itself. Sizes are checked against the end of the file before reading
+//packets.
+//https://trac.musepack.net/musepack/wiki/SV8Specification
+func readMusepackSV8Packets(r io.ReadSeeker) (musepackStreamInfo, error) {
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.Seek(start, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	var si musepackStreamInfo
+	for {
+		key, err := readString(r, 2)
+		if err != nil {
+			return nil, err
+		}
+		//Audio packets and the stream end packet come after all of the header
+		//packets
+		if key == "AP" || key == "SE" {
+			break
+		}
+		size, sizeLen, err := readMusepackSV8Size(r)
+		if err != nil {
+			return nil, err
+		}
+		if size < uint64(2+sizeLen) {
+			return nil, fmt.Errorf("invalid Musepack packet size: %d", size)
+		}
+		pos, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		size -= uint64(2 + sizeLen)
+		if size > uint64(end-pos) {
+			return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", size, end-pos)
+		}
+		b, err := readBytes(r, uint(size))
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case "SH":
+			si, err = processMusepackSHPacket(b, si)
+		case "RG":
+			err = processMusepackRGPacket(b, si)
+		case "EI":
+			err = processMusepackEIPacket(b, si)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if si == nil {
+		return nil, errors.New("missing Musepack stream header packet")
+	}
+	return si, nil
+}
+
+//readMusepackSV8Size reads a variable length size where each byte holds 7 bits
+//of the value and the high bit is set on all bytes but the last.
+func readMusepackSV8Size(r io.Reader) (uint64, int, error) {
+	var n uint64
+	for i := 1; i <= 8; i++ {
+		b, err := readBytes(r, 1)
+		if err != nil {
+			return 0, 0, err
+		}
+		n = n<<7 | uint64(b[0]&0x7F)
+		if b[0]&0x80 == 0 {
+			return n, i, nil
+		}
+	}
+	return 0, 0, errors.New("invalid Musepack variable length size")
+}
+
+//getMusepackSV8Size works as readMusepackSV8Size but on a slice of bytes. A
+//length of 0 is returned if b does not hold a valid size.
+func getMusepackSV8Size(b []byte) (uint64, int) {
+	var n uint64
+	for i := 0; i < len(b) && i < 8; i++ {
+		n = n<<7 | uint64(b[i]&0x7F)
+		if b[i]&0x80 == 0 {
+			return n, i + 1
+		}
+	}
+	return 0, 0
+}
+
+//processMusepackSHPacket reads a stream header packet into si, creating it if
+//this is the first stream header packet. The RG and EI packets that follow are
+//added to the same stream info.
+//Bits after the CRC: VVVVVVVV [sample count] [beginning silence] AAABBBBB
+//CCCCDEEE
+//V = stream version, A = sample rate index, B = max used bands,
+//C = channels - 1, D = mid side stereo, E = audio block frames
+func processMusepackSHPacket(b []byte, si musepackStreamInfo) (musepackStreamInfo, error) {
+	if si == nil {
+		si = musepackStreamInfo{}
+	}
+	if len(b) < 5 {
+		return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", 5, len(b))
+	}
+	si[VersionKey] = int(b[4])
+	offset := 5
+	sampleCount, n := getMusepackSV8Size(b[offset:])
+	if n == 0 {
+		return nil, errors.New("invalid Musepack sample count")
+	}
+	offset += n
+	beginningSilence, n := getMusepackSV8Size(b[offset:])
+	if n == 0 {
+		return nil, errors.New("invalid Musepack beginning silence")
+	}
+	offset += n
+	if len(b) < offset+2 {
+		return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", offset+2, len(b))
+	}
+	sampleRateIndex := b[offset] >> 5
+	if int(sampleRateIndex) < len(musepackSampleRates) {
+		si[SampleRateKey] = musepackSampleRates[sampleRateIndex]
+	}
+	si["maxBand"] = int(b[offset]&0x1F) + 1
+	si[ChannelsKey] = int(b[offset+1]>>4) + 1
+	si["midSideStereo"] = b[offset+1]&0x08 != 0
+	si["beginningSilence"] = int64(beginningSilence)
+	if beginningSilence > sampleCount {
+		beginningSilence = sampleCount
+	}
+	si[TotalSamplesKey] = int64(sampleCount - beginningSilence)
+	return si, nil
+}
+
+//processMusepackRGPacket reads a ReplayGain packet into si. Gains and peaks are
+//stored as they are in the packet.
+func processMusepackRGPacket(b []byte, si musepackStreamInfo) error {
+	if si == nil {
+		//The stream header is required to come first
+		return errors.New("expected Musepack stream header packet before ReplayGain packet")
+	}
+	if len(b) < 9 {
+		return fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", 9, len(b))
+	}
+	si["replayGainVersion"] = int(b[0])
+	si["titleGain"] = getInt16AsInt(b[1:3])
+	si["titlePeak"] = getUint16AsInt(b[3:5])
+	si["albumGain"] = getInt16AsInt(b[5:7])
+	si["albumPeak"] = getUint16AsInt(b[7:9])
+	return nil
+}
+
+//processMusepackEIPacket reads an encoder info packet into si.
+//Bits: PPPPPPPN MMMMMMMM mmmmmmmm BBBBBBBB
+//P = profile * 8, N = PNS, M = major version, m = minor version, B = build
+func processMusepackEIPacket(b []byte, si musepackStreamInfo) error {
+	if si == nil {
+		return errors.New("expected Musepack stream header packet before encoder info packet")
+	}
+	if len(b) < 4 {
+		return fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", 4, len(b))
+	}
+	si["profile"] = float64(b[0]>>1) / 8
+	si["pns"] = b[0]&0x01 == 1
+	si["encoderVersion"] = fmt.Sprintf("%d.%d.%d", b[1], b[2], b[3])
+	return nil
+}
+
+//approximateAudioSize returns the size of the file without any tags.
+func (m MusepackMetadata) approximateAudioSize() int64 {
+	return m.fileSize - m.tagsSize()
+}
+
+//AverageBitrate returns the average bitrate of the file in bits per second,
+//calculated from the size of the file without any tags.
+func (m MusepackMetadata) AverageBitrate() int {
+	durationInSeconds := m.Duration().Seconds()
+	if durationInSeconds == 0 {
+		return 0
+	}
+	return int(float64(m.approximateAudioSize()*8) / durationInSeconds)
+}
+
+func (m MusepackMetadata) Channels() int {
+	c, _ := m.streamInfo[ChannelsKey].(int)
+	return c
+}
+
+//Chapters returns the chapters from the CHAP frames of the ID3v2 tag.
+func (m MusepackMetadata) Chapters() []Chapter {
+	return chaptersFromID3v2(m.id3v2Tags, m.Duration())
+}
+
+func (m MusepackMetadata) Duration() time.Duration {
+	sr := m.SampleRate()
+	if sr <= 0 {
+		return time.Duration(0)
+	}
+	seconds := float64(m.TotalSamples()) / float64(sr)
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func (m MusepackMetadata) FileType() FileType {
+	return MPC
+}
+
+func (m MusepackMetadata) SampleRate() int {
+	sr, _ := m.streamInfo[SampleRateKey].(int)
+	return sr
+}
+
+//StreamInfo returns the information read from the SV7 header or the SV8
+//stream header, ReplayGain and encoder info packets.
+func (m MusepackMetadata) StreamInfo() map[string]interface{} {
+	return m.streamInfo
+}
+
+//TotalSamples returns the number of samples per channel in the stream, not
+//including any silence or delay that the decoder is expected to skip.
+func (m MusepackMetadata) TotalSamples() int64 {
+	ts, _ := m.streamInfo[TotalSamplesKey].(int64)
+	return ts
+}
+
+//Version returns the stream version of the file, e.g. 7 or 8.
+func (m MusepackMetadata) Version() int {
+	v, _ := m.streamInfo[VersionKey].(int)
+	return v
+}
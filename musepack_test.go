@@ -0,0 +1,187 @@
+package yurit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func musepackTestSV7Header(frames, flags, gapless uint32) []byte {
+	b := append([]byte("MP+"), 0x17)
+	v := make([]byte, 24)
+	binary.LittleEndian.PutUint32(v[0:4], frames)
+	binary.LittleEndian.PutUint32(v[4:8], flags)
+	binary.LittleEndian.PutUint16(v[8:10], 0x7000)
+	binary.LittleEndian.PutUint16(v[10:12], uint16(0x10000-650))
+	binary.LittleEndian.PutUint16(v[12:14], 0x7500)
+	binary.LittleEndian.PutUint16(v[14:16], 300)
+	binary.LittleEndian.PutUint32(v[16:20], gapless)
+	v[20] = 116
+	return append(b, v...)
+}
+
+//musepackTestSize encodes n as an SV8 variable length size.
+func musepackTestSize(n uint64) []byte {
+	b := []byte{byte(n & 0x7F)}
+	for n >>= 7; n > 0; n >>= 7 {
+		b = append([]byte{byte(n&0x7F) | 0x80}, b...)
+	}
+	return b
+}
+
+func musepackTestPacket(key string, payload ...byte) []byte {
+	//The size includes the key and the size itself, which fits in 1 byte here
+	return append(append([]byte(key), musepackTestSize(uint64(3+len(payload)))...), payload...)
+}
+
+func musepackTestSV8Stream() []byte {
+	sh := []byte{0, 0, 0, 0, 8}
+	sh = append(sh, musepackTestSize(442000)...)
+	sh = append(sh, musepackTestSize(1000)...)
+	sh = append(sh, 0<<5|26, 1<<4|0x08|1)
+	return bytes.Join([][]byte{
+		[]byte("MPCK"),
+		musepackTestPacket("SH", sh...),
+		musepackTestPacket("RG", 1, 0xFD, 0x76, 0x70, 0x00, 0x01, 0x2C, 0x75, 0x00),
+		musepackTestPacket("EI", 161, 1, 30, 0),
+		musepackTestPacket("AP", 0, 0, 0),
+	}, nil)
+}
+
+func TestReadMusepackSV7Header(t *testing.T) {
+	//Mid side stereo, max band 27, profile 10, 44.1 kHz and max level 0x1234
+	flags := uint32(1<<30 | 27<<24 | 10<<20 | 0<<16 | 0x1234)
+	tests := []struct {
+		flags        uint32
+		gapless      uint32
+		sampleRate   int
+		trueGapless  bool
+		totalSamples int64
+	}{
+		{flags, 1<<31 | 500<<20, 44100, true, 100*1152 - (1152 - 500)},
+		{flags | 1<<16, 0, 48000, false, 100*1152 - 481},
+	}
+
+	for ii, tt := range tests {
+		b := musepackTestSV7Header(100, tt.flags, tt.gapless)
+		si, err := readMusepackSV7Header(bytes.NewReader(b[4:]), b[3])
+		if err != nil {
+			t.Errorf("[%d] readMusepackSV7Header() returned error: %v", ii, err)
+			continue
+		}
+		if si["maxBand"] != 27 || si["profile"] != 10 || si["midSideStereo"] != true || si["intensityStereo"] != false {
+			t.Errorf("[%d] readMusepackSV7Header() maxBand, profile, midSideStereo, intensityStereo = %v, %v, %v, %v, expected %v, %v, %v, %v",
+				ii, si["maxBand"], si["profile"], si["midSideStereo"], si["intensityStereo"], 27, 10, true, false)
+		}
+		if si[SampleRateKey] != tt.sampleRate || si["trueGapless"] != tt.trueGapless || si[TotalSamplesKey] != tt.totalSamples {
+			t.Errorf("[%d] readMusepackSV7Header() sample rate, true gapless, total samples = %v, %v, %v, expected %v, %v, %v",
+				ii, si[SampleRateKey], si["trueGapless"], si[TotalSamplesKey], tt.sampleRate, tt.trueGapless, tt.totalSamples)
+		}
+		if si["titleGain"] != -650 || si["albumGain"] != 300 || si["encoderVersion"] != 116 {
+			t.Errorf("[%d] readMusepackSV7Header() titleGain, albumGain, encoderVersion = %v, %v, %v, expected %v, %v, %v",
+				ii, si["titleGain"], si["albumGain"], si["encoderVersion"], -650, 300, 116)
+		}
+	}
+
+	_, err := readMusepackSV7Header(bytes.NewReader(make([]byte, 24)), 0x16)
+	if err == nil {
+		t.Errorf("readMusepackSV7Header() expected error for stream version 6")
+	}
+	_, err = readMusepackSV7Header(bytes.NewReader(make([]byte, 23)), 0x07)
+	if err == nil {
+		t.Errorf("readMusepackSV7Header() expected error for short header")
+	}
+}
+
+func TestReadMusepackSV8Packets(t *testing.T) {
+	b := musepackTestSV8Stream()
+	si, err := readMusepackSV8Packets(bytes.NewReader(b[4:]))
+	if err != nil {
+		t.Fatalf("readMusepackSV8Packets() returned error: %v", err)
+	}
+	expected := musepackStreamInfo{
+		VersionKey:          8,
+		SampleRateKey:       44100,
+		"maxBand":           27,
+		ChannelsKey:         2,
+		"midSideStereo":     true,
+		"beginningSilence":  int64(1000),
+		TotalSamplesKey:     int64(441000),
+		"replayGainVersion": 1,
+		"titleGain":         -650,
+		"titlePeak":         0x7000,
+		"albumGain":         300,
+		"albumPeak":         0x7500,
+		"profile":           float64(10),
+		"pns":               true,
+		"encoderVersion":    "1.30.0",
+	}
+	for k, v := range expected {
+		if si[k] != v {
+			t.Errorf("readMusepackSV8Packets()[%q] = %v, expected %v", k, si[k], v)
+		}
+	}
+
+	tests := [][]byte{
+		//No stream header before the audio
+		musepackTestPacket("AP"),
+		//ReplayGain before the stream header
+		bytes.Join([][]byte{musepackTestPacket("RG", make([]byte, 9)...), b[4:]}, nil),
+		//A packet size smaller than the key and the size
+		[]byte{'S', 'H', 0x01},
+		//Truncated packet
+		b[4:10],
+		//A 56 bit packet size, far larger than the file
+		[]byte{'S', 'H', 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x7F},
+	}
+	for ii, input := range tests {
+		_, err := readMusepackSV8Packets(bytes.NewReader(input))
+		if err == nil {
+			t.Errorf("[%d] readMusepackSV8Packets(%v) expected error", ii, input)
+		}
+	}
+}
+
+func TestReadMusepack(t *testing.T) {
+	audio := bytes.Repeat([]byte{0x55}, 1000)
+	id3v1 := make([]byte, 128)
+	copy(id3v1, "TAGID3v1 Title")
+	sv7Seconds := float64(100*1152-481) / 44100
+	ape := apeTestTag(append(apeTestItem("Title", "APE Title", 0), apeTestItem("Track", "2/9", 0)...), 2, true)
+
+	tests := []struct {
+		input    []byte
+		version  int
+		title    string
+		track    int
+		format   Format
+		duration time.Duration
+	}{
+		{bytes.Join([][]byte{musepackTestSV8Stream(), audio, ape, id3v1}, nil), 8, "APE Title", 2, APEv2, 10 * time.Second},
+		{bytes.Join([][]byte{musepackTestSV8Stream(), audio, id3v1}, nil), 8, "ID3v1 Title", 0, ID3v1, 10 * time.Second},
+		{bytes.Join([][]byte{musepackTestSV7Header(100, 0, 0), audio}, nil), 7, "", 0, UnknownFormat, time.Duration(sv7Seconds * float64(time.Second))},
+	}
+
+	for ii, tt := range tests {
+		m, err := ReadMusepack(bytes.NewReader(tt.input))
+		if err != nil {
+			t.Errorf("[%d] ReadMusepack() returned error: %v", ii, err)
+			continue
+		}
+		if m.FileType() != MPC || m.Version() != tt.version || m.Format() != tt.format {
+			t.Errorf("[%d] FileType(), Version(), Format() = %v, %v, %v, expected %v, %v, %v", ii, m.FileType(), m.Version(), m.Format(), MPC, tt.version, tt.format)
+		}
+		if x, _ := m.Track(); m.Title() != tt.title || x != tt.track {
+			t.Errorf("[%d] Title(), Track() = %q, %v, expected %q, %v", ii, m.Title(), x, tt.title, tt.track)
+		}
+		if m.Duration() != tt.duration {
+			t.Errorf("[%d] Duration() = %v, expected %v", ii, m.Duration(), tt.duration)
+		}
+	}
+
+	_, err := ReadMusepack(bytes.NewReader(append([]byte("MP3 "), audio...)))
+	if err == nil {
+		t.Errorf("ReadMusepack() expected error for unknown magic")
+	}
+}
@@ -16,30 +16,80 @@ import (
 const (
 	vorbisPacketIDType      byte = 1
 	vorbisPacketCommentType byte = 3
-	//vorbisPacketSetupType byte = 5
+	vorbisPacketSetupType   byte = 5
 )
 
 //OggMetadata is a collection of metadata and other useful data from an Ogg
-//container that contains Vorbis encoded audio
+//container that contains Vorbis or Opus encoded audio
 type OggMetadata struct {
 	fileType       FileType
 	vorbisIDHeader vorbisIDHeader
+	opusIDHeader   opusIDHeader
 	vorbisComment  vorbisComment
-	totalGranules  int64
+	//vorbisBlockFlags holds the block flag of each mode of a Vorbis stream
+	vorbisBlockFlags []bool
+	//firstGranule and lastGranule are the granule positions at the start and
+	//the end of the audio of the stream
+	firstGranule int64
+	lastGranule  int64
+	//serialNumber is the serial number of the stream, from its first page
+	serialNumber uint32
+	//audioOffset is the offset of the first page after the header packets
+	audioOffset int64
+	//endOffset is the offset of the end of the stream, which is the start of
+	//the next link of a chained file or the end of the file
+	endOffset int64
+	//links holds the metadata of each link of a chained file, starting with
+	//the first link
+	links []*OggMetadata
 }
 
 // ReadOggTags reads Ogg metadata from the io.ReadSeeker, returning the resulting
 // metadata in a Metadata implementation, or non-nil error if there was a problem.
 // See http://www.xiph.org/vorbis/doc/Vorbis_I_spec.html
 // and http://www.xiph.org/ogg/doc/framing.html for details.
+//
+// The header of every page in the file is read to find the granule positions of
+// the audio and the links of a chained file, as links may reuse serial numbers
+// and can't be found by bisection. This takes one small read for every 4 to 8
+// KB of a typical file, so reading the tags of a long file takes longer than
+// reading only its headers.
 func ReadOggTags(r io.ReadSeeker) (*OggMetadata, error) {
+	m, err := readOggLink(r)
+	if err != nil {
+		return m, err
+	}
+	err = m.readLinks(r)
+	return m, err
+}
+
+//readOggLink reads the header packets of the logical bitstream that starts at
+//the current position of r, leaving r at the first page after them.
+func readOggLink(r io.ReadSeeker) (*OggMetadata, error) {
 	m := &OggMetadata{}
 
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	h, err := readOggPageHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	m.serialNumber = h.serialNumber
+	_, err = r.Seek(start, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
 	idHeaderPacket, err := readPackets(r)
 	if err != nil {
 		return nil, err
 	}
 	m.fileType = OGG
+	if bytes.HasPrefix(idHeaderPacket, []byte("OpusHead")) {
+		return m, m.readOpusHeaders(r, idHeaderPacket)
+	}
 	if idHeaderPacket[0] != vorbisPacketIDType {
 		return nil, errors.New("expected 'vorbis' identification type 1")
 	}
@@ -69,9 +119,71 @@ func ReadOggTags(r io.ReadSeeker) (*OggMetadata, error) {
 	if err != nil {
 		return nil, err
 	}
+	setupHeaderPacket, err := skipVorbisSetupHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	//The setup header packet ends the comment header page when it isn't on a
+	//page of its own
+	if setupHeaderPacket == nil {
+		setupHeaderPacket = commentHeaderPacket
+	}
+	m.vorbisBlockFlags = vorbisModeBlockFlags(setupHeaderPacket)
+	m.audioOffset, err = r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
 
-	err = m.getTotalGranules(r)
-	return m, err
+//readOpusHeaders reads the identification header and the comment header of an
+//Opus stream, which is a Vorbis comment without the framing bit.
+//https://tools.ietf.org/html/rfc7845#section-5
+func (m *OggMetadata) readOpusHeaders(r io.ReadSeeker, idHeaderPacket []byte) error {
+	oih, err := processOpusIDHeader(idHeaderPacket[8:])
+	if err != nil {
+		return err
+	}
+	m.opusIDHeader = oih
+	commentHeaderPacket, err := readPackets(r)
+	if err != nil {
+		return err
+	}
+	if !bytes.HasPrefix(commentHeaderPacket, []byte("OpusTags")) {
+		return errors.New("expected 'OpusTags'")
+	}
+	err = m.loadVorbisComment(commentHeaderPacket[8:])
+	if err != nil {
+		return err
+	}
+	m.audioOffset, err = r.Seek(0, io.SeekCurrent)
+	return err
+}
+
+//skipVorbisSetupHeader reads past the setup header packet of a Vorbis stream if
+//it starts on the next page rather than on the page of the comment header, and
+//returns it. Nil is returned if the setup header isn't on the next page. The
+//end of the file is taken as the end of the headers, as a stream may have no
+//audio pages.
+func skipVorbisSetupHeader(r io.ReadSeeker) ([]byte, error) {
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	_, err = readOggPageHeader(r)
+	var b []byte
+	if err == nil {
+		b, err = readBytes(r, 7)
+	}
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	isSetupHeader := err == nil && b[0] == vorbisPacketSetupType && string(b[1:7]) == "vorbis"
+	_, err = r.Seek(start, io.SeekStart)
+	if err != nil || !isSetupHeader {
+		return nil, err
+	}
+	return readPackets(r)
 }
 
 func (m *OggMetadata) loadVorbisIDHeader(b []byte) error {
@@ -86,57 +198,131 @@ func (m *OggMetadata) loadVorbisComment(b []byte) error {
 	return err
 }
 
-//getTotalGranules finds the last page in an Ogg container and extracts the
-//absolute granule position, which in this case is the total number of granules
-//for the Ogg container
-//https://www.xiph.org/ogg/doc/framing.html
-func (m *OggMetadata) getTotalGranules(r io.ReadSeeker) error {
-	var err error
-	_, err = r.Seek(-14, io.SeekEnd)
+//readLinks reads the pages after the headers of the first link to find the
+//granule positions at the start and the end of its audio, and the links of a
+//chained file. A link is a logical bitstream that starts with a BOS page after
+//the EOS page of the previous link, so it may reuse the serial number of an
+//earlier link. The BOS pages of the streams of a multiplexed file all come
+//before its audio, so they don't start links. Streams with codecs other than
+//Vorbis and Opus are skipped. Anything between pages that isn't a page, such
+//as a damaged page header, is skipped by searching for the next page. A file
+//that is cut off, such as a radio capture, ends at its last whole page, whether
+//or not it is an EOS page.
+//https://www.xiph.org/ogg/doc/oggstream.html
+func (m *OggMetadata) readLinks(r io.ReadSeeker) error {
+	end, err := r.Seek(0, io.SeekEnd)
 	if err != nil {
 		return err
 	}
-	//Start looking backwards for page header capture pattern
-	for {
-		b, err := readBytes(r, 4)
+	first := *m
+	links := []*OggMetadata{&first}
+	link := links[0]
+	//ended is set by the EOS page of the stream of the link, and started by its
+	//first page with a granule position
+	ended, started := false, false
+	offset := m.audioOffset
+	for offset < end {
+		_, err = r.Seek(offset, io.SeekStart)
 		if err != nil {
 			return err
 		}
-		if string(b) == "OggS" {
-			break
-		} else if string(b[:3]) == "ggS" {
-			_, err = r.Seek(-5, io.SeekCurrent)
-		} else if string(b[:2]) == "gS" {
-			_, err = r.Seek(-6, io.SeekCurrent)
-		} else if b[0] == 'S' {
-			_, err = r.Seek(-7, io.SeekCurrent)
-		} else {
-			_, err = r.Seek(-8, io.SeekCurrent)
+		h, err := readOggPageHeader(r)
+		if err != nil || offset+int64(h.size()) > end {
+			//Look for the next page after a damaged page, unless this is a page
+			//cut off by the end of the file
+			offset, h, err = findOggPage(r, offset+1, end, func(*oggPageHeader) bool { return true })
+			if err != nil {
+				return err
+			}
+			if h == nil {
+				break
+			}
+			continue
 		}
-		if err != nil {
-			return err
+		if h.first() && ended && h.dataSize() >= 8 {
+			b, err := readBytes(r, 8)
+			if err != nil {
+				return err
+			}
+			if codec := oggCodec(b); codec == "Vorbis" || codec == "Opus" {
+				_, err = r.Seek(offset, io.SeekStart)
+				if err != nil {
+					return err
+				}
+				link.endOffset = offset
+				link, err = readOggLink(r)
+				if err != nil {
+					return err
+				}
+				links = append(links, link)
+				ended, started = false, false
+				offset = link.audioOffset
+				continue
+			}
+		} else if h.serialNumber == link.serialNumber && !ended {
+			if h.granulePosition != -1 {
+				if !started {
+					b, err := readBytes(r, uint(h.dataSize()))
+					if err != nil {
+						return err
+					}
+					link.firstGranule = link.startGranule(h, b)
+					started = true
+				}
+				link.lastGranule = h.granulePosition
+			}
+			ended = h.last()
 		}
+		offset += int64(h.size())
 	}
-	//Skip version byte
-	_, err = r.Seek(1, io.SeekCurrent)
-	if err != nil {
-		return err
+	link.endOffset = end
+	m.firstGranule, m.lastGranule, m.endOffset = first.firstGranule, first.lastGranule, first.endOffset
+	if len(links) > 1 {
+		m.links = links
 	}
-	//Read and check header_type_flag
-	headerTypeFlag, err := readBytes(r, 1)
-	if err != nil {
-		return err
+	return nil
+}
+
+//startGranule returns the granule position at the start of the audio of the
+//stream, from the first page of the stream with a granule position, which is
+//the position at the end of the page less the samples of the packets that
+//finish on it. A stream doesn't have to start at granule position 0, such as a
+//radio capture that starts in the middle of a stream. The first packet of a
+//Vorbis stream only primes the decoder, and each later packet gives a quarter
+//of the block of the previous packet and a quarter of its own. 0 is returned
+//if the first page is also the last one, as its granule position may cut off
+//the end of the audio, or if the modes of a Vorbis stream are unknown.
+//https://tools.ietf.org/html/rfc7845#section-4
+//https://xiph.org/vorbis/doc/Vorbis_I_spec.html#x1-115000A.2
+func (m OggMetadata) startGranule(h *oggPageHeader, data []byte) int64 {
+	if h.last() {
+		return 0
 	}
-	if headerTypeFlag[0]&0x04 != 0x04 {
-		return errors.New("Last page found is not marked as final page")
+	var samples int64
+	if m.opusIDHeader != nil {
+		for _, p := range h.packets(data) {
+			samples += int64(opusPacketSamples(p))
+		}
+	} else {
+		if m.vorbisBlockFlags == nil {
+			return 0
+		}
+		previous := 0
+		for _, p := range h.packets(data) {
+			size := m.vorbisIDHeader.packetBlockSize(p, m.vorbisBlockFlags)
+			if size == 0 {
+				continue
+			}
+			if previous != 0 {
+				samples += int64(previous/4 + size/4)
+			}
+			previous = size
+		}
 	}
-	//Read final absolute granule position
-	absoluteGranulePosition, err := readInt64Little(r)
-	if err != nil {
-		return err
+	if samples > h.granulePosition {
+		return 0
 	}
-	m.totalGranules = absoluteGranulePosition
-	return nil
+	return h.granulePosition - samples
 }
 
 // readPackets reads vorbis header packets from contiguous ogg pages in ReadSeeker.
@@ -151,7 +337,10 @@ func readPackets(r io.ReadSeeker) ([]byte, error) {
 	for {
 		// Read capture pattern
 		oggs, err := readString(r, 4)
-		if err != nil {
+		if err == io.EOF && !firstPage {
+			// The packet ends on the last page of the file
+			break
+		} else if err != nil {
 			return nil, err
 		}
 		if oggs != "OggS" {
@@ -219,6 +408,12 @@ func (m OggMetadata) AverageBitrate() int {
 	return m.vorbisIDHeader.AverageBitrate()
 }
 
+//Chapters returns the chapters from the CHAPTERxxx comments of the Vorbis
+//comment.
+func (m OggMetadata) Chapters() []Chapter {
+	return completeChapters(m.vorbisComment.Chapters(), m.Duration())
+}
+
 func (m OggMetadata) Comment() string {
 	return m.vorbisComment.Comment()
 }
@@ -231,8 +426,20 @@ func (m OggMetadata) Disc() (int, int) {
 	return m.vorbisComment.Disc()
 }
 
+//Duration returns the duration of the audio, which for a chained file is the
+//total of the durations of its links.
 func (m OggMetadata) Duration() time.Duration {
-	return m.vorbisIDHeader.Duration(m.totalGranules)
+	if m.links != nil {
+		var d time.Duration
+		for _, l := range m.links {
+			d += l.Duration()
+		}
+		return d
+	}
+	if m.opusIDHeader != nil {
+		return m.opusIDHeader.Duration(m.TotalGranules())
+	}
+	return m.vorbisIDHeader.Duration(m.TotalGranules())
 }
 
 func (m OggMetadata) FileType() FileType {
@@ -243,10 +450,33 @@ func (m OggMetadata) Format() Format {
 	return m.vorbisComment.Format()
 }
 
+//GaplessInfo returns the pre-skip of an Opus stream as the encoder delay, or nil
+//for a Vorbis stream. The padding is always zero, as Opus streams mark the end
+//of the audio with the granule position of the last page instead. All values
+//are samples at 48 kHz.
+func (m OggMetadata) GaplessInfo() *GaplessInfo {
+	if m.opusIDHeader == nil {
+		return nil
+	}
+	g := &GaplessInfo{EncoderDelay: m.opusIDHeader.PreSkip()}
+	if total := m.TotalGranules(); total > int64(g.EncoderDelay) {
+		g.ValidSamples = total - int64(g.EncoderDelay)
+	}
+	return g
+}
+
 func (m OggMetadata) Genre() string {
 	return m.vorbisComment.Genre()
 }
 
+//Links returns the metadata of each link of a chained Ogg file, which holds
+//several logical bitstreams one after another, such as the tracks of a radio
+//capture. The first link has the same metadata as the file itself. Nil is
+//returned for a file that isn't chained.
+func (m OggMetadata) Links() []*OggMetadata {
+	return m.links
+}
+
 func (m OggMetadata) Lyrics() string {
 	return m.vorbisComment.Lyrics()
 }
@@ -262,9 +492,17 @@ func (m OggMetadata) Title() string {
 	return m.vorbisComment.Title()
 }
 
-//Returns the total number of granules in this Ogg container
+//OpusIDHeader returns the information from the identification header of an
+//Opus stream, or nil for a Vorbis stream.
+func (m OggMetadata) OpusIDHeader() map[string]interface{} {
+	return m.opusIDHeader
+}
+
+//Returns the total number of granules in this Ogg container, or in the first
+//link of a chained file, which is the difference between the granule positions
+//at the start and the end of the audio
 func (m OggMetadata) TotalGranules() int64 {
-	return m.totalGranules
+	return m.lastGranule - m.firstGranule
 }
 
 func (m OggMetadata) Track() (int, int) {
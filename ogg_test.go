@@ -0,0 +1,265 @@
+package yurit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+// oggWithSerialNumber returns the pages of the Ogg file b with their serial
+// number changed.
+func oggWithSerialNumber(t *testing.T, b []byte, serialNumber uint32) []byte {
+	var out []byte
+	pr := newOggPageReader(bytes.NewReader(b), 0)
+	for {
+		page, _, err := pr.next()
+		if err == io.EOF {
+			return out
+		} else if err != nil {
+			t.Fatalf("error reading page: %v", err)
+		}
+		binary.LittleEndian.PutUint32(page.data[14:18], serialNumber)
+		binary.LittleEndian.PutUint32(page.data[22:26], oggPageCRC(page.data))
+		out = append(out, page.data...)
+	}
+}
+
+func TestReadChainedOgg(t *testing.T) {
+	b, err := ioutil.ReadFile("./testdata/with_tags/sample.ogg")
+	if err != nil {
+		t.Fatalf("error reading file: %v", err)
+	}
+	c, err := ioutil.ReadFile("./testdata/without_tags/sample.ogg")
+	if err != nil {
+		t.Fatalf("error reading file: %v", err)
+	}
+	single, err := ReadOggTags(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("ReadOggTags() returned error: %v", err)
+	}
+	if single.Links() != nil {
+		t.Errorf("Links() of a file that isn't chained = %v, expected nil", single.Links())
+	}
+	chained := bytes.Join([][]byte{oggWithSerialNumber(t, b, 1), oggWithSerialNumber(t, c, 2), oggWithSerialNumber(t, b, 3)}, nil)
+	m, err := ReadOggTags(bytes.NewReader(chained))
+	if err != nil {
+		t.Fatalf("ReadOggTags() returned error: %v", err)
+	}
+	links := m.Links()
+	if len(links) != 3 {
+		t.Fatalf("Links() returned %d links, expected %d", len(links), 3)
+	}
+	for ii, l := range links {
+		if l.TotalGranules() != 149880 {
+			t.Errorf("[%d] TotalGranules() = %d, expected %d", ii, l.TotalGranules(), 149880)
+		}
+		if l.serialNumber != uint32(ii+1) {
+			t.Errorf("[%d] serial number = %d, expected %d", ii, l.serialNumber, ii+1)
+		}
+	}
+	if links[0].Title() != single.Title() || links[2].Title() != single.Title() || links[1].Title() != "" {
+		t.Errorf("Title() of links = %q, %q, %q, expected %q, %q, %q", links[0].Title(), links[1].Title(), links[2].Title(), single.Title(), "", single.Title())
+	}
+	if m.Title() != single.Title() || m.TotalGranules() != 149880 {
+		t.Errorf("ReadOggTags() of chained file has title %q and %d granules, expected %q and %d", m.Title(), m.TotalGranules(), single.Title(), 149880)
+	}
+	if d := m.Duration(); d != 3*single.Duration() {
+		t.Errorf("Duration() = %v, expected %v", d, 3*single.Duration())
+	}
+	if links[1].Duration() > 4*time.Second || links[1].Duration() < 3*time.Second {
+		t.Errorf("Duration() of link = %v, expected %v", links[1].Duration(), single.Duration())
+	}
+	//Links can be used to seek within their own stream
+	offset, err := links[1].OffsetForGranule(bytes.NewReader(chained), 0)
+	if expected := int64(len(b) + 3924); err != nil || offset != expected {
+		t.Errorf("OffsetForGranule() of link = %d, %v, expected %d", offset, err, expected)
+	}
+}
+
+// oggTestPackets returns an Ogg page holding the packets, none of which may be
+// longer than 254 bytes.
+func oggTestPackets(headerType byte, granulePosition int64, packets ...[]byte) []byte {
+	b := make([]byte, oggPageHeaderSize, oggPageHeaderSize+255)
+	copy(b, "OggS")
+	b[5] = headerType
+	binary.LittleEndian.PutUint64(b[6:14], uint64(granulePosition))
+	binary.LittleEndian.PutUint32(b[14:18], 1)
+	for _, p := range packets {
+		b = append(b, byte(len(p)))
+	}
+	b[26] = byte(len(packets))
+	b = append(b, bytes.Join(packets, nil)...)
+	binary.LittleEndian.PutUint32(b[22:26], oggPageCRC(b))
+	return b
+}
+
+func TestReadOggWithoutAudio(t *testing.T) {
+	b, err := ioutil.ReadFile("./testdata/with_tags/sample.ogg")
+	if err != nil {
+		t.Fatalf("error reading file: %v", err)
+	}
+	full, err := ReadOggTags(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("ReadOggTags() returned error: %v", err)
+	}
+	//Keep the header pages only, with the last one marked as the final page
+	var headers, page []byte
+	pr := newOggPageReader(bytes.NewReader(b), 0)
+	for int64(len(headers)) < full.audioOffset {
+		p, _, err := pr.next()
+		if err != nil {
+			t.Fatalf("error reading page: %v", err)
+		}
+		page = p.data
+		headers = append(headers, page...)
+	}
+	page = headers[len(headers)-len(page):]
+	page[5] |= 0x04
+	binary.LittleEndian.PutUint32(page[22:26], oggPageCRC(page))
+	m, err := ReadOggTags(bytes.NewReader(headers))
+	if err != nil {
+		t.Fatalf("ReadOggTags() of headers only returned error: %v", err)
+	}
+	if m.Title() != full.Title() {
+		t.Errorf("Title() = %q, expected %q", m.Title(), full.Title())
+	}
+}
+
+func TestReadOggVorbisSetupOnCommentPage(t *testing.T) {
+	id := append([]byte("\x01vorbis"), 0, 0, 0, 0, 2, 0x44, 0xAC, 0, 0, 0, 0, 0, 0, 0, 0xF4, 0x01, 0, 0, 0, 0, 0, 0xB8, 1)
+	comment := append([]byte("\x03vorbis"), 0, 0, 0, 0, 1, 0, 0, 0, 10, 0, 0, 0)
+	comment = append(append(comment, "TITLE=Test"...), 1)
+	setup := []byte("\x05vorbis\x00")
+	audio := []byte{0, 1, 2, 3}
+
+	headers := bytes.Join([][]byte{oggTestPackets(0x02, 0, id), oggTestPackets(0, 0, comment, setup)}, nil)
+	tests := []struct {
+		input       []byte
+		audioOffset int
+	}{
+		//The setup header shares the page of the comment header, and no audio
+		//follows it
+		{bytes.Join([][]byte{oggTestPackets(0x02, 0, id), oggTestPackets(0x04, 0, comment, setup)}, nil), len(headers)},
+		{append(headers, oggTestPackets(0x04, 1024, audio)...), len(headers)},
+	}
+	for ii, tt := range tests {
+		m, err := ReadOggTags(bytes.NewReader(tt.input))
+		if err != nil {
+			t.Errorf("[%d] ReadOggTags() returned error: %v", ii, err)
+			continue
+		}
+		if m.Title() != "Test" || m.audioOffset != int64(tt.audioOffset) {
+			t.Errorf("[%d] Title(), audio offset = %q, %d, expected %q, %d", ii, m.Title(), m.audioOffset, "Test", tt.audioOffset)
+		}
+	}
+}
+
+// oggWithGranuleOffset returns the pages of the Ogg file b with the offset
+// added to the granule positions of the audio pages, as for a stream that was
+// captured from the middle.
+func oggWithGranuleOffset(t *testing.T, b []byte, offset int64) []byte {
+	var out []byte
+	pr := newOggPageReader(bytes.NewReader(b), 0)
+	for {
+		page, _, err := pr.next()
+		if err == io.EOF {
+			return out
+		} else if err != nil {
+			t.Fatalf("error reading page: %v", err)
+		}
+		if g := int64(binary.LittleEndian.Uint64(page.data[6:14])); g > 0 {
+			binary.LittleEndian.PutUint64(page.data[6:14], uint64(g+offset))
+			binary.LittleEndian.PutUint32(page.data[22:26], oggPageCRC(page.data))
+		}
+		out = append(out, page.data...)
+	}
+}
+
+func TestReadOggLinks(t *testing.T) {
+	b, err := ioutil.ReadFile("./testdata/with_tags/sample.ogg")
+	if err != nil {
+		t.Fatalf("error reading file: %v", err)
+	}
+	single, err := ReadOggTags(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("ReadOggTags() returned error: %v", err)
+	}
+	//A stream multiplexed with the audio, with its BOS page after the headers
+	//of the audio and its EOS page at the end of the file
+	other := oggTestPageWithCRC(9, 0, 0x02, "\x80theora")
+	multiplexed := bytes.Join([][]byte{b[:single.audioOffset], other, b[single.audioOffset:], oggTestPageWithCRC(9, 1, 0x04, "")}, nil)
+	shifted := oggWithGranuleOffset(t, b, 480000)
+	truncated := oggWithSerialNumber(t, b, 2)
+	truncated = truncated[:len(truncated)-1000]
+
+	tests := []struct {
+		name          string
+		input         []byte
+		links         int
+		totalGranules []int64
+		firstGranules []int64
+	}{
+		{"reused serial number", bytes.Join([][]byte{b, b, b}, nil), 3, []int64{149880, 149880, 149880}, []int64{0, 0, 0}},
+		{"multiplexed", multiplexed, 0, []int64{149880}, []int64{0}},
+		{"captured from the middle", shifted, 0, []int64{149880}, []int64{480000}},
+		{"chained capture", bytes.Join([][]byte{b, shifted}, nil), 2, []int64{149880, 149880}, []int64{0, 480000}},
+		{"cut off without an EOS page", bytes.Join([][]byte{b, truncated}, nil), 2, []int64{149880, 140352}, []int64{0, 0}},
+	}
+	for _, tt := range tests {
+		m, err := ReadOggTags(bytes.NewReader(tt.input))
+		if err != nil {
+			t.Errorf("%s: ReadOggTags() returned error: %v", tt.name, err)
+			continue
+		}
+		links := m.Links()
+		if len(links) != tt.links {
+			t.Errorf("%s: Links() returned %d links, expected %d", tt.name, len(links), tt.links)
+			continue
+		}
+		if links == nil {
+			links = []*OggMetadata{m}
+		}
+		for ii, l := range links {
+			if l.TotalGranules() != tt.totalGranules[ii] || l.firstGranule != tt.firstGranules[ii] {
+				t.Errorf("%s: [%d] TotalGranules(), first granule = %d, %d, expected %d, %d", tt.name, ii, l.TotalGranules(), l.firstGranule, tt.totalGranules[ii], tt.firstGranules[ii])
+			}
+		}
+		if tt.links == 0 && m.Duration() != single.Duration() {
+			t.Errorf("%s: Duration() = %v, expected %v", tt.name, m.Duration(), single.Duration())
+		}
+	}
+}
+
+func TestReadOggJunkBetweenPages(t *testing.T) {
+	b, err := ioutil.ReadFile("./testdata/without_tags/sample.ogg")
+	if err != nil {
+		t.Fatalf("error reading file: %v", err)
+	}
+	//The third page of the file starts at this offset
+	const page3 = 8133
+	damaged := append([]byte{}, b...)
+	damaged[page3] ^= 0x01
+	tests := [][]byte{
+		bytes.Join([][]byte{b[:page3], []byte("xyz"), b[page3:]}, nil),
+		damaged,
+		//A second link after junk
+		bytes.Join([][]byte{b, []byte("xyz"), oggWithSerialNumber(t, b, 2)}, nil),
+	}
+	for ii, input := range tests {
+		m, err := ReadOggTags(bytes.NewReader(input))
+		if err != nil {
+			t.Errorf("[%d] ReadOggTags() returned error: %v", ii, err)
+			continue
+		}
+		if m.TotalGranules() != 149880 {
+			t.Errorf("[%d] TotalGranules() = %d, expected %d", ii, m.TotalGranules(), 149880)
+		}
+	}
+	m, err := ReadOggTags(bytes.NewReader(tests[2]))
+	if err == nil && len(m.Links()) != 2 {
+		t.Errorf("Links() after junk returned %d links, expected %d", len(m.Links()), 2)
+	}
+}
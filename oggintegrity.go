@@ -0,0 +1,170 @@
+package yurit
+
+import (
+	"bytes"
+	"io"
+)
+
+//OggIntegrityReport is the result of checking every page of an Ogg file. The
+//file is free of errors when there are no issues.
+type OggIntegrityReport struct {
+	Pages int
+	//Streams holds the logical bitstreams of the file in the order that their
+	//first pages appear. The links of a chained file are separate streams, even
+	//if they share a serial number.
+	Streams []OggStream
+	Issues  []OggIntegrityIssue
+}
+
+//OggStream is a logical bitstream of an Ogg file. Codec is the codec of the
+//stream, found from its first packet, or an empty string if it isn't known.
+//BOSOffset and EOSOffset are the offsets of the first and last pages of the
+//stream, where -1 means that the page is missing.
+type OggStream struct {
+	SerialNumber    uint32
+	Codec           string
+	BOSOffset       int64
+	EOSOffset       int64
+	Pages           int
+	GranulePosition int64 //The last granule position of the stream
+}
+
+//OggIntegrityIssue is a problem found in an Ogg file. Offset is from the
+//beginning of the file, and Size is the number of bytes affected.
+//SerialNumber is the serial number of the stream with the problem, which is 0
+//for problems that aren't in any stream.
+type OggIntegrityIssue struct {
+	Type         OggIntegrityIssueType
+	Offset       int64
+	Size         int64
+	SerialNumber uint32
+}
+
+//OggIntegrityIssueType is the kind of problem found in an Ogg file
+type OggIntegrityIssueType string
+
+//All possible integrity issue types
+const (
+	//The CRC-32 of a page doesn't match the page
+	OggIssueCRCMismatch OggIntegrityIssueType = "CRC mismatch"
+	//Bytes between pages that aren't part of any page
+	OggIssueJunk OggIntegrityIssueType = "Junk"
+	//The sequence number of a page doesn't follow on from the page before it in
+	//the stream, with a size of 0
+	OggIssueSequenceGap OggIntegrityIssueType = "Sequence gap"
+	//The last page of the file is cut short by the end of the file
+	OggIssueTruncatedPage OggIntegrityIssueType = "Truncated page"
+	//The first page of a stream isn't marked as the beginning of the stream,
+	//with a size of 0
+	OggIssueMissingBOS OggIntegrityIssueType = "Missing BOS"
+	//The last page of a stream isn't marked as the end of the stream, with the
+	//offset of the last page and a size of 0
+	OggIssueMissingEOS OggIntegrityIssueType = "Missing EOS"
+)
+
+//OK returns whether no issues were found.
+func (r OggIntegrityReport) OK() bool {
+	return len(r.Issues) == 0
+}
+
+//oggCodecs maps the start of the first packet of a stream to its codec.
+var oggCodecs = []struct {
+	prefix string
+	codec  string
+}{
+	{"\x01vorbis", "Vorbis"},
+	{"OpusHead", "Opus"},
+	{"\x7fFLAC", "FLAC"},
+	{"Speex   ", "Speex"},
+	{"\x80theora", "Theora"},
+	{"fishead\x00", "Skeleton"},
+}
+
+//CheckOggIntegrity reads every page of the Ogg file in r from its beginning,
+//verifying the CRC-32 of each page and that the sequence numbers of the pages
+//of each logical bitstream follow on from each other. It lists the logical
+//bitstreams in the file and reports streams without a beginning or end of
+//stream page, junk between pages and a truncated last page. Unlike ReadOggTags,
+//it works on files that are cut short.
+func CheckOggIntegrity(r io.ReadSeeker) (*OggIntegrityReport, error) {
+	_, err := r.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	report := &OggIntegrityReport{}
+	addIssue := func(t OggIntegrityIssueType, offset int64, size int64, serialNumber uint32) {
+		report.Issues = append(report.Issues, OggIntegrityIssue{Type: t, Offset: offset, Size: size, SerialNumber: serialNumber})
+	}
+	streams := map[uint32]int{}
+	sequenceNumbers := map[uint32]uint32{}
+	//lastOffsets holds the offset of the last page of each stream by its index
+	lastOffsets := map[int]int64{}
+	pr := newOggPageReader(r, 0)
+	for {
+		offset := pr.offset
+		page, skipped, err := pr.next()
+		if skipped > 0 {
+			addIssue(OggIssueJunk, offset, skipped, 0)
+		}
+		if err == io.EOF {
+			break
+		} else if err == io.ErrUnexpectedEOF {
+			var serialNumber uint32
+			if len(page.data) >= 18 {
+				serialNumber = uint32(getUint32LittleAsInt64(page.data[14:18]))
+			}
+			addIssue(OggIssueTruncatedPage, page.offset, int64(len(page.data)), serialNumber)
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		report.Pages++
+		h := page.header
+		if !page.crcOK {
+			addIssue(OggIssueCRCMismatch, page.offset, int64(len(page.data)), h.serialNumber)
+		}
+		//A BOS page after the EOS page of a stream with the same serial number
+		//starts a new stream, as in a chained file whose links reuse it
+		i, ok := streams[h.serialNumber]
+		if !ok || h.first() && report.Streams[i].EOSOffset != -1 {
+			s := OggStream{SerialNumber: h.serialNumber, BOSOffset: -1, EOSOffset: -1}
+			if h.first() {
+				s.BOSOffset = page.offset
+				s.Codec = oggCodec(page.data[oggPageHeaderSize+len(h.segments):])
+			} else {
+				addIssue(OggIssueMissingBOS, page.offset, 0, h.serialNumber)
+			}
+			i = len(report.Streams)
+			streams[h.serialNumber] = i
+			report.Streams = append(report.Streams, s)
+		} else if h.sequenceNumber != sequenceNumbers[h.serialNumber]+1 {
+			addIssue(OggIssueSequenceGap, page.offset, 0, h.serialNumber)
+		}
+		sequenceNumbers[h.serialNumber] = h.sequenceNumber
+		lastOffsets[i] = page.offset
+		s := &report.Streams[i]
+		s.Pages++
+		if h.granulePosition != -1 {
+			s.GranulePosition = h.granulePosition
+		}
+		if h.last() {
+			s.EOSOffset = page.offset
+		}
+	}
+	for i, s := range report.Streams {
+		if s.EOSOffset == -1 {
+			addIssue(OggIssueMissingEOS, lastOffsets[i], 0, s.SerialNumber)
+		}
+	}
+	return report, nil
+}
+
+//oggCodec returns the codec of a stream from the packet data of its first page.
+func oggCodec(b []byte) string {
+	for _, c := range oggCodecs {
+		if bytes.HasPrefix(b, []byte(c.prefix)) {
+			return c.codec
+		}
+	}
+	return ""
+}
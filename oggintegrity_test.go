@@ -0,0 +1,107 @@
+package yurit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestOggPageCRC(t *testing.T) {
+	if crc := oggPageCRC([]byte("123456789")); crc != 0x89A1897F {
+		t.Errorf("oggPageCRC() = %#x, expected %#x", crc, 0x89A1897F)
+	}
+}
+
+// oggTestPageWithCRC returns an Ogg page like oggTestPage with the given header
+// type, sequence number and first bytes of data, and a correct CRC.
+func oggTestPageWithCRC(serialNumber uint32, sequenceNumber uint32, headerType byte, data string) []byte {
+	b := oggTestPage(serialNumber, 0, 50)
+	b[5] = headerType
+	binary.LittleEndian.PutUint32(b[18:22], sequenceNumber)
+	copy(b[oggPageHeaderSize+1:], data)
+	binary.LittleEndian.PutUint32(b[22:26], oggPageCRC(b))
+	return b
+}
+
+func TestCheckOggIntegrity(t *testing.T) {
+	b, err := ioutil.ReadFile("./testdata/without_tags/sample.ogg")
+	if err != nil {
+		t.Fatalf("error reading file: %v", err)
+	}
+	//The pages of the file used here start at these offsets
+	const (
+		page2 = 3924
+		page3 = 8133
+		page4 = 12315
+		page5 = 16612
+		page6 = 20869
+	)
+	stream := OggStream{SerialNumber: 2129003868, Codec: "Vorbis", BOSOffset: 0, EOSOffset: page6, Pages: 7, GranulePosition: 149880}
+	corrupt := append([]byte{}, b...)
+	corrupt[5000] ^= 0x01
+	//A Vorbis stream multiplexed with an Opus stream that has no EOS page, and a
+	//page without BOS of a third stream
+	multiplexed := bytes.Join([][]byte{
+		oggTestPageWithCRC(1, 0, 0x02, "\x01vorbis"),
+		oggTestPageWithCRC(2, 0, 0x02, "OpusHead"),
+		oggTestPageWithCRC(1, 1, 0x04, ""),
+		oggTestPageWithCRC(2, 1, 0x00, ""),
+		oggTestPageWithCRC(3, 5, 0x00, ""),
+	}, nil)
+
+	tests := []struct {
+		data    []byte
+		pages   int
+		streams []OggStream
+		issues  []OggIntegrityIssue
+	}{
+		{b, 7, []OggStream{stream}, nil},
+		{corrupt, 7, []OggStream{stream}, []OggIntegrityIssue{{OggIssueCRCMismatch, page2, page3 - page2, stream.SerialNumber}}},
+		{bytes.Join([][]byte{b[:page3], []byte("xyz"), b[page3:]}, nil), 7,
+			[]OggStream{{stream.SerialNumber, "Vorbis", 0, page6 + 3, 7, 149880}},
+			[]OggIntegrityIssue{{OggIssueJunk, page3, 3, 0}},
+		},
+		{bytes.Join([][]byte{b[:page3], b[page4:]}, nil), 6,
+			[]OggStream{{stream.SerialNumber, "Vorbis", 0, page6 - (page4 - page3), 6, 149880}},
+			[]OggIntegrityIssue{{OggIssueSequenceGap, page3, 0, stream.SerialNumber}},
+		},
+		{b[:len(b)-100], 6,
+			[]OggStream{{stream.SerialNumber, "Vorbis", 0, -1, 6, 137280}},
+			[]OggIntegrityIssue{
+				{OggIssueTruncatedPage, page6, int64(len(b) - 100 - page6), stream.SerialNumber},
+				{OggIssueMissingEOS, page5, 0, stream.SerialNumber},
+			},
+		},
+		//A chained file whose second link reuses the serial number of the first
+		{bytes.Join([][]byte{b, b}, nil), 14,
+			[]OggStream{stream, {stream.SerialNumber, "Vorbis", int64(len(b)), int64(len(b) + page6), 7, 149880}},
+			nil,
+		},
+		{multiplexed, 5,
+			[]OggStream{{1, "Vorbis", 0, 156, 2, 0}, {2, "Opus", 78, -1, 2, 0}, {3, "", -1, -1, 1, 0}},
+			[]OggIntegrityIssue{
+				{OggIssueMissingBOS, 312, 0, 3},
+				{OggIssueMissingEOS, 234, 0, 2},
+				{OggIssueMissingEOS, 312, 0, 3},
+			},
+		},
+	}
+	for ii, tt := range tests {
+		report, err := CheckOggIntegrity(bytes.NewReader(tt.data))
+		if err != nil {
+			t.Errorf("[%d] CheckOggIntegrity() returned error: %v", ii, err)
+			continue
+		}
+		if report.Pages != tt.pages {
+			t.Errorf("[%d] CheckOggIntegrity() found %d pages, expected %d", ii, report.Pages, tt.pages)
+		}
+		if !reflect.DeepEqual(report.Streams, tt.streams) {
+			t.Errorf("[%d] CheckOggIntegrity() streams = %+v, expected %+v", ii, report.Streams, tt.streams)
+		}
+		if !reflect.DeepEqual(report.Issues, tt.issues) {
+			t.Errorf("[%d] CheckOggIntegrity() issues = %+v, expected %+v", ii, report.Issues, tt.issues)
+		}
+	}
+}
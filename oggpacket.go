@@ -0,0 +1,107 @@
+package yurit
+
+//opusFrameSamples holds the number of samples at 48 kHz in each frame of an
+//Opus packet for the 32 configurations of its TOC byte.
+//https://tools.ietf.org/html/rfc6716#section-3.1
+var opusFrameSamples = [32]int{
+	//SILK-only, 10, 20, 40 and 60 ms for each bandwidth
+	480, 960, 1920, 2880, 480, 960, 1920, 2880, 480, 960, 1920, 2880,
+	//Hybrid, 10 and 20 ms for each bandwidth
+	480, 960, 480, 960,
+	//CELT-only, 2.5, 5, 10 and 20 ms for each bandwidth
+	120, 240, 480, 960, 120, 240, 480, 960, 120, 240, 480, 960, 120, 240, 480, 960,
+}
+
+//opusPacketSamples returns the number of samples at 48 kHz that an Opus packet
+//decodes to, from its TOC byte and, for a packet with an arbitrary number of
+//frames, its frame count byte.
+//https://tools.ietf.org/html/rfc6716#section-3.2
+func opusPacketSamples(p []byte) int {
+	if len(p) == 0 {
+		return 0
+	}
+	frames := 1
+	switch p[0] & 0x03 {
+	case 1, 2:
+		frames = 2
+	case 3:
+		if len(p) < 2 {
+			return 0
+		}
+		frames = int(p[1] & 0x3F)
+	}
+	return opusFrameSamples[p[0]>>3] * frames
+}
+
+//vorbisModeBlockFlags returns the block flag of each mode of a Vorbis stream,
+//which tells whether audio packets of the mode use the long block size, from
+//the end of the setup header packet. b may hold other packets before the setup
+//header. The modes are the last fields of the setup header before the framing
+//bit, and the fields before them can't be found without decoding the whole
+//setup header, so the modes are read backwards until a mode count before them
+//matches, as done by liboggz and FFmpeg. Nil is returned if no modes are found.
+//https://xiph.org/vorbis/doc/Vorbis_I_spec.html#x1-610004.2.4
+func vorbisModeBlockFlags(b []byte) []bool {
+	//Vorbis packs fields starting from the least significant bit of each byte
+	bit := func(i int) uint {
+		return uint(b[i/8]>>(uint(i)%8)) & 0x01
+	}
+	field := func(end int, size int) uint {
+		v := uint(0)
+		for i := end - 1; i >= end-size; i-- {
+			v = v<<1 | bit(i)
+		}
+		return v
+	}
+	//The framing bit is the last bit set in the packet
+	framing := len(b)*8 - 1
+	for framing >= 0 && bit(framing) == 0 {
+		framing--
+	}
+	//Each mode is a block flag (1 bit), a window type (16 bits) and a transform
+	//type (16 bits) that are always 0, and a mapping (8 bits) below 64. The modes
+	//follow a 6-bit count of modes minus 1.
+	const modeBits = 41
+	n, count := 0, 0
+	for start := framing; start >= modeBits+6 && n < 64; start -= modeBits {
+		if field(start, 8) > 63 || field(start-8, 16) != 0 || field(start-24, 16) != 0 {
+			break
+		}
+		n++
+		if int(field(start-modeBits, 6))+1 == n {
+			count = n
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+	flags := make([]bool, count)
+	for i := range flags {
+		flags[i] = bit(framing-modeBits*(count-i)) == 1
+	}
+	return flags
+}
+
+//packetBlockSize returns the block size of a Vorbis audio packet from the
+//mode number at its start, or 0 for a header packet or an unknown mode.
+//https://xiph.org/vorbis/doc/Vorbis_I_spec.html#x1-720004.3.1
+func (vih vorbisIDHeader) packetBlockSize(p []byte, blockFlags []bool) int {
+	if len(p) == 0 || p[0]&0x01 != 0 || len(blockFlags) == 0 {
+		return 0
+	}
+	//The mode number takes as many bits as are needed for the last mode
+	bits := uint(0)
+	for n := len(blockFlags) - 1; n > 0; n >>= 1 {
+		bits++
+	}
+	mode := int(p[0]>>1) & (1<<bits - 1)
+	if mode >= len(blockFlags) {
+		return 0
+	}
+	key := MinimumBlockSizeKey
+	if blockFlags[mode] {
+		key = MaximumBlockSizeKey
+	}
+	size, _ := vih[key].(int)
+	return size
+}
@@ -0,0 +1,105 @@
+package yurit
+
+import "testing"
+
+func TestOpusPacketSamples(t *testing.T) {
+	tests := []struct {
+		input  []byte
+		output int
+	}{
+		//SILK-only 20 ms, one frame
+		{[]byte{0x08}, 960},
+		//Hybrid 10 ms, two frames
+		{[]byte{0x61}, 960},
+		//CELT-only 2.5 ms, two frames of different sizes
+		{[]byte{0x82}, 240},
+		//CELT-only 20 ms, five frames
+		{[]byte{0xFB, 0x05}, 4800},
+		//Frame count byte missing
+		{[]byte{0xFB}, 0},
+		{nil, 0},
+	}
+	for ii, tt := range tests {
+		if n := opusPacketSamples(tt.input); n != tt.output {
+			t.Errorf("[%d] opusPacketSamples(% x) = %d, expected %d", ii, tt.input, n, tt.output)
+		}
+	}
+}
+
+// vorbisTestSetupEnd returns the end of a Vorbis setup header with the modes,
+// following the bytes of the fields before them.
+func vorbisTestSetupEnd(before []byte, modes ...bool) []byte {
+	b := append([]byte{}, before...)
+	n := len(b) * 8
+	write := func(v uint, size int) {
+		for i := 0; i < size; i++ {
+			if n/8 == len(b) {
+				b = append(b, 0)
+			}
+			b[n/8] |= byte(v>>uint(i)&0x01) << uint(n%8)
+			n++
+		}
+	}
+	write(uint(len(modes)-1), 6)
+	for ii, m := range modes {
+		if m {
+			write(1, 1)
+		} else {
+			write(0, 1)
+		}
+		write(0, 32)
+		write(uint(ii), 8)
+	}
+	//Framing bit
+	write(1, 1)
+	return b
+}
+
+func TestVorbisModeBlockFlags(t *testing.T) {
+	tests := []struct {
+		input  []byte
+		output []bool
+	}{
+		{vorbisTestSetupEnd([]byte{0xA5, 0x17, 0x3C, 0xFF, 0x80}, false, true), []bool{false, true}},
+		{vorbisTestSetupEnd([]byte{0xA5, 0x17, 0x3C, 0xFF, 0x80}, true), []bool{true}},
+		{vorbisTestSetupEnd([]byte{0xA5, 0x17, 0x3C, 0xFF, 0x80}, false, false, true, false), []bool{false, false, true, false}},
+		//The last mode has a mapping above 63
+		{[]byte{0xA5, 0x17, 0x3C, 0xFF, 0x80, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x01}, nil},
+		{[]byte{0, 0, 0}, nil},
+	}
+	for ii, tt := range tests {
+		flags := vorbisModeBlockFlags(tt.input)
+		if len(flags) != len(tt.output) {
+			t.Errorf("[%d] vorbisModeBlockFlags() = %v, expected %v", ii, flags, tt.output)
+			continue
+		}
+		for jj := range flags {
+			if flags[jj] != tt.output[jj] {
+				t.Errorf("[%d] vorbisModeBlockFlags() = %v, expected %v", ii, flags, tt.output)
+				break
+			}
+		}
+	}
+}
+
+func TestVorbisPacketBlockSize(t *testing.T) {
+	vih := vorbisIDHeader{MinimumBlockSizeKey: 256, MaximumBlockSizeKey: 2048}
+	flags := []bool{false, true, true}
+	tests := []struct {
+		input  []byte
+		output int
+	}{
+		{[]byte{0x00}, 256},
+		{[]byte{0x02}, 2048},
+		{[]byte{0xFC}, 2048},
+		//Mode 3 doesn't exist
+		{[]byte{0x06}, 0},
+		//Header packet
+		{[]byte{0x01}, 0},
+	}
+	for ii, tt := range tests {
+		if n := vih.packetBlockSize(tt.input, flags); n != tt.output {
+			t.Errorf("[%d] packetBlockSize(% x) = %d, expected %d", ii, tt.input, n, tt.output)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package yurit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+//oggPageHeaderSize is the size of an Ogg page header without its segment table.
+const oggPageHeaderSize = 27
+
+//oggMaxPageSize is the size of the largest possible Ogg page, with 255 segments
+//of 255 bytes.
+const oggMaxPageSize = oggPageHeaderSize + 255 + 255*255
+
+//oggPageHeader is the header of an Ogg page.
+//https://www.xiph.org/ogg/doc/framing.html
+type oggPageHeader struct {
+	headerType      byte
+	granulePosition int64
+	serialNumber    uint32
+	sequenceNumber  uint32
+	checksum        uint32
+	segments        []byte
+}
+
+//first returns whether the page is the first page of its stream (BOS).
+func (h oggPageHeader) first() bool {
+	return h.headerType&0x02 != 0
+}
+
+//last returns whether the page is the last page of its stream (EOS).
+func (h oggPageHeader) last() bool {
+	return h.headerType&0x04 != 0
+}
+
+//dataSize returns the number of bytes of packet data that follow the header.
+func (h oggPageHeader) dataSize() int {
+	n := 0
+	for _, s := range h.segments {
+		n += int(s)
+	}
+	return n
+}
+
+//size returns the size of the whole page including its header.
+func (h oggPageHeader) size() int {
+	return oggPageHeaderSize + len(h.segments) + h.dataSize()
+}
+
+//packets splits the packet data of the page into the packets that start and
+//finish on it. A packet continued from the previous page and a packet that
+//continues onto the next page are left out.
+func (h oggPageHeader) packets(data []byte) [][]byte {
+	var packets [][]byte
+	continued := h.headerType&0x01 != 0
+	start, n := 0, 0
+	for _, s := range h.segments {
+		n += int(s)
+		if n > len(data) {
+			break
+		}
+		if s < 255 {
+			if !continued {
+				packets = append(packets, data[start:n])
+			}
+			continued = false
+			start = n
+		}
+	}
+	return packets
+}
+
+//readOggPageHeader reads the header of the Ogg page at the current position of
+//r, leaving r at the start of the page's packet data.
+func readOggPageHeader(r io.Reader) (*oggPageHeader, error) {
+	b, err := readBytes(r, oggPageHeaderSize)
+	if err != nil {
+		return nil, err
+	}
+	if string(b[0:4]) != "OggS" {
+		return nil, errors.New("expected 'OggS'")
+	}
+	if b[4] != 0 {
+		return nil, errors.New("unsupported Ogg version")
+	}
+	h := &oggPageHeader{
+		headerType:      b[5],
+		granulePosition: int64(binary.LittleEndian.Uint64(b[6:14])),
+		serialNumber:    binary.LittleEndian.Uint32(b[14:18]),
+		sequenceNumber:  binary.LittleEndian.Uint32(b[18:22]),
+		checksum:        binary.LittleEndian.Uint32(b[22:26]),
+	}
+	h.segments, err = readBytes(r, uint(b[26]))
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+//findNextOggPage returns the offset and header of the first page at or after
+//offset and before end that has the given serial number, searching for the
+//capture pattern and skipping anything that isn't a valid page header. A nil
+//header is returned if there is no such page.
+func findNextOggPage(r io.ReadSeeker, offset int64, end int64, serialNumber uint32) (int64, *oggPageHeader, error) {
+	return findOggPage(r, offset, end, func(h *oggPageHeader) bool {
+		return h.serialNumber == serialNumber
+	})
+}
+
+//findOggPage works as findNextOggPage but returns the first page for which
+//match returns true.
+func findOggPage(r io.ReadSeeker, offset int64, end int64, match func(h *oggPageHeader) bool) (int64, *oggPageHeader, error) {
+	buf := make([]byte, 1<<16)
+	for offset < end {
+		_, err := r.Seek(offset, io.SeekStart)
+		if err != nil {
+			return 0, nil, err
+		}
+		n := len(buf)
+		if int64(n) > end-offset {
+			n = int(end - offset)
+		}
+		n, err = io.ReadFull(r, buf[:n])
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return 0, nil, err
+		}
+		i := bytes.Index(buf[:n], []byte("OggS"))
+		if i < 0 {
+			if n < 4 {
+				break
+			}
+			//Keep the last 3 bytes in case the capture pattern straddles the buffers
+			offset += int64(n - 3)
+			continue
+		}
+		pageOffset := offset + int64(i)
+		_, err = r.Seek(pageOffset, io.SeekStart)
+		if err != nil {
+			return 0, nil, err
+		}
+		h, err := readOggPageHeader(r)
+		if err != nil {
+			//Not a page header, just the capture pattern within packet data
+			offset = pageOffset + 1
+			continue
+		}
+		if match(h) {
+			return pageOffset, h, nil
+		}
+		offset = pageOffset + int64(h.size())
+	}
+	return 0, nil, nil
+}
+
+//oggCRCTable is a lookup table for the CRC-32 of Ogg pages, which uses the
+//polynomial 0x04C11DB7 without reflecting the bits.
+var oggCRCTable = makeOggCRCTable()
+
+func makeOggCRCTable() [256]uint32 {
+	var t [256]uint32
+	for i := range t {
+		crc := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04C11DB7
+			} else {
+				crc <<= 1
+			}
+		}
+		t[i] = crc
+	}
+	return t
+}
+
+//oggPageCRC returns the CRC-32 of a whole page, which is calculated with the
+//checksum field of the header set to 0.
+func oggPageCRC(page []byte) uint32 {
+	var crc uint32
+	for i, c := range page {
+		if i >= 22 && i < 26 {
+			c = 0
+		}
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^c]
+	}
+	return crc
+}
+
+//oggPage is a whole Ogg page read by an oggPageReader.
+type oggPage struct {
+	offset int64
+	header *oggPageHeader
+	//data holds the whole page including its header
+	data  []byte
+	crcOK bool
+}
+
+//oggPageReader reads the pages of an Ogg file one after another, skipping any
+//bytes between pages that aren't part of a page.
+type oggPageReader struct {
+	r      *bufio.Reader
+	offset int64
+}
+
+//newOggPageReader returns an oggPageReader for r, where offset is the current
+//position of r in the file.
+func newOggPageReader(r io.Reader, offset int64) *oggPageReader {
+	return &oggPageReader{r: bufio.NewReaderSize(r, 2*oggMaxPageSize), offset: offset}
+}
+
+//next returns the next page and the number of bytes skipped before it. A page
+//whose CRC doesn't match is only returned if it is followed by another capture
+//pattern or the end of the file, as otherwise its length can't be trusted and
+//the capture pattern is taken to be part of junk. At the end of the file io.EOF
+//is returned with the number of bytes skipped before the end. A page that is
+//cut short by the end of the file is returned with the bytes that are left and
+//io.ErrUnexpectedEOF.
+func (pr *oggPageReader) next() (*oggPage, int64, error) {
+	var skipped int64
+	for {
+		b, err := pr.r.Peek(oggPageHeaderSize)
+		if len(b) < 4 || string(b[0:4]) != "OggS" {
+			if len(b) == 0 {
+				if err == io.EOF {
+					return nil, skipped, io.EOF
+				}
+				return nil, skipped, err
+			}
+			if len(b) >= 4 {
+				pr.skip(1)
+				skipped++
+				continue
+			}
+			if err != io.EOF {
+				return nil, skipped, err
+			}
+			//Too few bytes are left for even a capture pattern
+			pr.skip(len(b))
+			return nil, skipped + int64(len(b)), io.EOF
+		}
+		if len(b) < oggPageHeaderSize {
+			if err != io.EOF {
+				return nil, skipped, err
+			}
+			return pr.truncated(b, skipped)
+		}
+		size := oggPageHeaderSize + int(b[26])
+		b, err = pr.r.Peek(size)
+		if len(b) < size {
+			if err != io.EOF {
+				return nil, skipped, err
+			}
+			return pr.truncated(b, skipped)
+		}
+		for _, s := range b[oggPageHeaderSize:] {
+			size += int(s)
+		}
+		//Peek the following capture pattern as well
+		b, err = pr.r.Peek(size + 4)
+		if len(b) < size {
+			if err != io.EOF {
+				return nil, skipped, err
+			}
+			return pr.truncated(b, skipped)
+		}
+		if b[4] != 0 {
+			//Not a version 0 page header, so a false capture pattern
+			pr.skip(1)
+			skipped++
+			continue
+		}
+		page := &oggPage{offset: pr.offset, data: make([]byte, size)}
+		copy(page.data, b)
+		page.header, err = readOggPageHeader(bytes.NewReader(page.data))
+		if err != nil {
+			return nil, skipped, err
+		}
+		page.crcOK = oggPageCRC(page.data) == page.header.checksum
+		if !page.crcOK && len(b) == size+4 && string(b[size:]) != "OggS" {
+			pr.skip(1)
+			skipped++
+			continue
+		}
+		pr.skip(size)
+		return page, skipped, nil
+	}
+}
+
+//truncated returns a page that is cut short by the end of the file.
+func (pr *oggPageReader) truncated(b []byte, skipped int64) (*oggPage, int64, error) {
+	page := &oggPage{offset: pr.offset, data: append([]byte{}, b...)}
+	pr.skip(len(b))
+	return page, skipped, io.ErrUnexpectedEOF
+}
+
+//skip moves past n bytes that have been peeked.
+func (pr *oggPageReader) skip(n int) {
+	pr.r.Discard(n)
+	pr.offset += int64(n)
+}
@@ -0,0 +1,67 @@
+package yurit
+
+import (
+	"fmt"
+	"io"
+)
+
+//OffsetForGranule returns the offset in r, the file that m was read from, of
+//the first page of the stream with an absolute granule position of at least g,
+//which is the page that finishes the packet holding granule g. The page is
+//found by bisecting over the pages of the stream between the headers and the
+//end of the stream, so only a few pages are read. Pages of other streams
+//multiplexed into the file are skipped. For Opus, g includes the pre-skip.
+func (m OggMetadata) OffsetForGranule(r io.ReadSeeker, g int64) (int64, error) {
+	if g < 0 || g > m.lastGranule {
+		return 0, fmt.Errorf("invalid granule position: %d, expected between 0 and %d", g, m.lastGranule)
+	}
+	//The bisection only moves to the start or end of pages of the stream. The
+	//page being looked for is the page at found or starts between lo and hi,
+	//where lo is the end of a page of the stream before granule g, and found is
+	//the start of a page of the stream at or after granule g. There are no
+	//pages of the stream on which a packet finishes between hi and found.
+	lo, hi := m.audioOffset, m.endOffset
+	found := int64(-1)
+	for hi-lo > oggMaxPageSize {
+		mid := lo + (hi-lo)/2
+		offset, h, err := m.nextGranulePage(r, mid, hi)
+		if err != nil {
+			return 0, err
+		}
+		if h == nil {
+			hi = mid
+		} else if h.granulePosition >= g {
+			found, hi = offset, mid
+		} else {
+			lo = offset + int64(h.size())
+		}
+	}
+	//Read the remaining pages that start before hi one by one
+	offset, h, err := m.nextGranulePage(r, lo, hi)
+	for err == nil && h != nil && h.granulePosition < g {
+		offset, h, err = m.nextGranulePage(r, offset+int64(h.size()), hi)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if h != nil {
+		return offset, nil
+	}
+	if found < 0 {
+		return 0, fmt.Errorf("no page found for granule position %d", g)
+	}
+	return found, nil
+}
+
+//nextGranulePage returns the offset and header of the first page of the stream
+//at or after offset and before end on which a packet finishes. Pages on which
+//no packet finishes have a granule position of -1 and are skipped.
+func (m OggMetadata) nextGranulePage(r io.ReadSeeker, offset int64, end int64) (int64, *oggPageHeader, error) {
+	for {
+		pageOffset, h, err := findNextOggPage(r, offset, end, m.serialNumber)
+		if err != nil || h == nil || h.granulePosition != -1 {
+			return pageOffset, h, err
+		}
+		offset = pageOffset + int64(h.size())
+	}
+}
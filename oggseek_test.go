@@ -0,0 +1,95 @@
+package yurit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// oggTestPage returns an Ogg page with a single segment of size bytes.
+func oggTestPage(serialNumber uint32, granulePosition int64, size int) []byte {
+	b := make([]byte, oggPageHeaderSize+1+size)
+	copy(b, "OggS")
+	binary.LittleEndian.PutUint64(b[6:14], uint64(granulePosition))
+	binary.LittleEndian.PutUint32(b[14:18], serialNumber)
+	b[26] = 1
+	b[27] = byte(size)
+	//Capture patterns in the packet data must not be taken for pages
+	copy(b[oggPageHeaderSize+1:], "OggS")
+	return b
+}
+
+func TestOggOffsetForGranule(t *testing.T) {
+	var (
+		b       []byte
+		offsets = map[int64]int64{}
+	)
+	b = append(b, oggTestPage(1, 0, 200)...)
+	audioOffset := int64(len(b))
+	//1000 pages of stream 1 with 100 granules each, multiplexed with stream 2,
+	//and with a page on which no packet finishes every tenth page
+	for i := int64(1); i <= 1000; i++ {
+		b = append(b, oggTestPage(2, i*100000, 250)...)
+		if i%10 == 0 {
+			b = append(b, oggTestPage(1, -1, 250)...)
+		}
+		offsets[i*100] = int64(len(b))
+		b = append(b, oggTestPage(1, i*100, 250)...)
+	}
+	m := OggMetadata{serialNumber: 1, audioOffset: audioOffset, lastGranule: 100000, endOffset: int64(len(b))}
+	r := bytes.NewReader(b)
+
+	tests := []struct {
+		granule    int64
+		offset     int64
+		makesError bool
+	}{
+		{0, offsets[100], false},
+		{1, offsets[100], false},
+		{100, offsets[100], false},
+		{101, offsets[200], false},
+		{999, offsets[1000], false},
+		{1000, offsets[1000], false},
+		{54321, offsets[54400], false},
+		{100000, offsets[100000], false},
+		{-1, 0, true},
+		{100001, 0, true},
+	}
+	for ii, tt := range tests {
+		offset, err := m.OffsetForGranule(r, tt.granule)
+		if (err != nil) != tt.makesError {
+			t.Errorf("[%d] OffsetForGranule(%d) expected error: %v - Error was: %v", ii, tt.granule, tt.makesError, err)
+			continue
+		}
+		if offset != tt.offset {
+			t.Errorf("[%d] OffsetForGranule(%d) = %d, expected %d", ii, tt.granule, offset, tt.offset)
+		}
+	}
+}
+
+func TestOggOffsetForGranuleMultiplexed(t *testing.T) {
+	var (
+		b       []byte
+		offsets = map[int64]int64{}
+	)
+	b = append(b, oggTestPage(1, 0, 200)...)
+	audioOffset := int64(len(b))
+	//Runs of pages of stream 2 between the pages of stream 1, some of them
+	//longer than the largest page, so that the bisection lands in them
+	for i := int64(1); i <= 40; i++ {
+		for j := 0; j < 300*int(i%3); j++ {
+			b = append(b, oggTestPage(2, i*1000+int64(j), 250)...)
+		}
+		offsets[i*100] = int64(len(b))
+		b = append(b, oggTestPage(1, i*100, 250)...)
+	}
+	m := OggMetadata{serialNumber: 1, audioOffset: audioOffset, lastGranule: 4000, endOffset: int64(len(b))}
+	r := bytes.NewReader(b)
+
+	for _, g := range []int64{1, 150, 1999, 2000, 2001, 3050, 3999, 4000} {
+		offset, err := m.OffsetForGranule(r, g)
+		if expected := offsets[(g+99)/100*100]; err != nil || offset != expected {
+			t.Errorf("OffsetForGranule(%d) = %d, %v, expected %d", g, offset, err, expected)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package yurit
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+//opusSampleRate is the rate of the granule positions of an Ogg Opus stream,
+//which is always 48 kHz whatever the sample rate of the original audio.
+const opusSampleRate = 48000
+
+//opusIDHeader holds general information about an Opus audio stream.
+//https://tools.ietf.org/html/rfc7845#section-5.1
+type opusIDHeader map[string]interface{}
+
+//processOpusIDHeader reads the identification header of an Opus audio stream,
+//following the OpusHead magic signature.
+//Version 1, channels 1, pre-skip 2, input sample rate 4, output gain 2,
+//channel mapping family 1
+func processOpusIDHeader(b []byte) (opusIDHeader, error) {
+	if err := checkLen(b, 11); err != nil {
+		return nil, err
+	}
+	oih := opusIDHeader{}
+	oih[VersionKey] = b[0]
+	oih[ChannelsKey] = b[1]
+	oih["preSkip"] = int(binary.LittleEndian.Uint16(b[2:4]))
+	oih["inputSampleRate"] = getUint32LittleAsInt64(b[4:8])
+	//Output gain is a Q7.8 number of dB
+	oih["outputGain"] = float64(int16(binary.LittleEndian.Uint16(b[8:10]))) / 256
+	oih["channelMappingFamily"] = b[10]
+	return oih, nil
+}
+
+func (oih opusIDHeader) Duration(totalGranules int64) time.Duration {
+	samples := totalGranules - int64(oih.PreSkip())
+	if samples <= 0 {
+		return time.Duration(0)
+	}
+	return time.Duration(float64(samples) / opusSampleRate * float64(time.Second))
+}
+
+//PreSkip returns the number of samples at 48 kHz to discard from the start of
+//the decoded audio.
+func (oih opusIDHeader) PreSkip() int {
+	p, _ := oih["preSkip"].(int)
+	return p
+}
@@ -0,0 +1,26 @@
+package yurit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProcessOpusIDHeader(t *testing.T) {
+	b := []byte{1, 2, 0x38, 0x01, 0x44, 0xAC, 0, 0, 0x00, 0xFF, 0}
+	oih, err := processOpusIDHeader(b)
+	if err != nil {
+		t.Fatalf("processOpusIDHeader() returned error: %v", err)
+	}
+	if oih.PreSkip() != 312 {
+		t.Errorf("PreSkip() = %d, expected %d", oih.PreSkip(), 312)
+	}
+	if oih["inputSampleRate"] != int64(44100) || oih["outputGain"] != -1.0 {
+		t.Errorf("processOpusIDHeader() = %v, unexpected header values", oih)
+	}
+	if d := oih.Duration(48312); d != time.Second {
+		t.Errorf("Duration(48312) = %v, expected %v", d, time.Second)
+	}
+	if _, err := processOpusIDHeader(b[:10]); err == nil {
+		t.Errorf("processOpusIDHeader() with short header expected error")
+	}
+}
@@ -0,0 +1,141 @@
+package yurit
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+//ttaHeader holds the information from the header of a True Audio (.tta) file.
+//http://tausoft.org/wiki/True_Audio_Codec_Format
+type ttaHeader map[string]interface{}
+
+//TTAMetadata is a collection of metadata from a True Audio (.tta) file,
+//including tags and header information.
+type TTAMetadata struct {
+	apeID3Tags
+	fileSize int64
+	header   ttaHeader
+}
+
+//ttaHeaderLength is the length of a TTA1 header including the magic and CRC.
+const ttaHeaderLength = 22
+
+// ReadTTA reads tags and header information from a True Audio file, returning
+// the resulting metadata in a Metadata implementation, or non-nil error if
+// there was a problem.
+func ReadTTA(r io.ReadSeeker) (*TTAMetadata, error) {
+	var (
+		m   TTAMetadata
+		err error
+	)
+	m.fileSize, err = r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	//Extract any ID3v2 tags, if any. This leaves the reader positioned right
+	//after the tag, or at the beginning if there is no tag.
+	m.id3v2Tags, err = ReadID3v2Tags(r)
+	if err != nil {
+		return nil, err
+	}
+	b, err := readBytes(r, ttaHeaderLength)
+	if err != nil {
+		return nil, err
+	}
+	m.header, err = processTTAHeader(b)
+	if err != nil {
+		return nil, err
+	}
+	//Look for an ID3v1 tag and an APE tag at the end of the file
+	m.id3v1tags, err = ReadID3v1Tags(r)
+	if err != nil {
+		return nil, err
+	}
+	m.apeTags, err = ReadAPEv2Tags(r)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+//processTTAHeader reads a TTA1 header, in which all values are little endian.
+//Bytes: 4 magic ('TTA1'), 2 audio format, 2 channels, 2 bits per sample,
+//4 sample rate, 4 total samples per channel, 4 CRC32
+func processTTAHeader(b []byte) (ttaHeader, error) {
+	if len(b) < ttaHeaderLength {
+		return nil, fmt.Errorf("invalid encoding: expected at least %d bytes, got %d", ttaHeaderLength, len(b))
+	}
+	if string(b[0:4]) != "TTA1" {
+		return nil, errors.New("expected 'TTA1'")
+	}
+	h := ttaHeader{}
+	h[FormatKey] = int(binary.LittleEndian.Uint16(b[4:6]))
+	h[ChannelsKey] = int(binary.LittleEndian.Uint16(b[6:8]))
+	h[SampleSizeKey] = int(binary.LittleEndian.Uint16(b[8:10]))
+	h[SampleRateKey] = getUint32LittleAsInt64(b[10:14])
+	h[TotalSamplesKey] = getUint32LittleAsInt64(b[14:18])
+	h["crc"] = getUint32LittleAsInt64(b[18:22])
+	return h, nil
+}
+
+//approximateAudioSize returns the size of the file without any tags.
+func (m TTAMetadata) approximateAudioSize() int64 {
+	return m.fileSize - m.tagsSize()
+}
+
+//AverageBitrate returns the average bitrate of the file in bits per second,
+//calculated from the size of the file without any tags.
+func (m TTAMetadata) AverageBitrate() int {
+	durationInSeconds := m.Duration().Seconds()
+	if durationInSeconds == 0 {
+		return 0
+	}
+	return int(float64(m.approximateAudioSize()*8) / durationInSeconds)
+}
+
+//BitsPerSample returns the number of bits in each sample, e.g. 16 or 24.
+func (m TTAMetadata) BitsPerSample() int {
+	b, _ := m.header[SampleSizeKey].(int)
+	return b
+}
+
+func (m TTAMetadata) Channels() int {
+	c, _ := m.header[ChannelsKey].(int)
+	return c
+}
+
+//Chapters returns the chapters from the CHAP frames of the ID3v2 tag.
+func (m TTAMetadata) Chapters() []Chapter {
+	return chaptersFromID3v2(m.id3v2Tags, m.Duration())
+}
+
+func (m TTAMetadata) Duration() time.Duration {
+	sr := m.SampleRate()
+	if sr <= 0 {
+		return time.Duration(0)
+	}
+	seconds := float64(m.TotalSamples()) / float64(sr)
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func (m TTAMetadata) FileType() FileType {
+	return TTA
+}
+
+func (m TTAMetadata) SampleRate() int {
+	sr, _ := m.header[SampleRateKey].(int64)
+	return int(sr)
+}
+
+//TotalSamples returns the number of samples per channel in the file.
+func (m TTAMetadata) TotalSamples() int64 {
+	ts, _ := m.header[TotalSamplesKey].(int64)
+	return ts
+}
@@ -0,0 +1,81 @@
+package yurit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func ttaTestHeader(channels, bits uint16, sampleRate, totalSamples uint32) []byte {
+	b := make([]byte, ttaHeaderLength)
+	copy(b, "TTA1")
+	binary.LittleEndian.PutUint16(b[4:6], 1)
+	binary.LittleEndian.PutUint16(b[6:8], channels)
+	binary.LittleEndian.PutUint16(b[8:10], bits)
+	binary.LittleEndian.PutUint32(b[10:14], sampleRate)
+	binary.LittleEndian.PutUint32(b[14:18], totalSamples)
+	binary.LittleEndian.PutUint32(b[18:22], 0xDEADBEEF)
+	return b
+}
+
+func TestProcessTTAHeader(t *testing.T) {
+	tests := []struct {
+		input      []byte
+		expected   ttaHeader
+		makesError bool
+	}{
+		{ttaTestHeader(2, 24, 96000, 960000), ttaHeader{
+			FormatKey:       1,
+			ChannelsKey:     2,
+			SampleSizeKey:   24,
+			SampleRateKey:   int64(96000),
+			TotalSamplesKey: int64(960000),
+			"crc":           int64(0xDEADBEEF),
+		}, false},
+		{ttaTestHeader(2, 24, 96000, 960000)[:21], nil, true},
+		{append([]byte("TTA2"), ttaTestHeader(2, 24, 96000, 960000)[4:]...), nil, true},
+	}
+
+	for ii, tt := range tests {
+		h, err := processTTAHeader(tt.input)
+		if (err != nil) != tt.makesError {
+			t.Errorf("[%d] processTTAHeader(%v) expected error: %v - Error was: %v", ii, tt.input, tt.makesError, err)
+			continue
+		}
+		if !reflect.DeepEqual(h, tt.expected) {
+			t.Errorf("[%d] processTTAHeader(%v) = %v, expected %v", ii, tt.input, h, tt.expected)
+		}
+	}
+}
+
+func TestReadTTA(t *testing.T) {
+	audio := bytes.Repeat([]byte{0x55}, 4410*4)
+	ape := apeTestTag(append(apeTestItem("Artist", "Artist", 0), apeTestItem("Year", "2004-05-06", 0)...), 2, false)
+	b := bytes.Join([][]byte{ttaTestHeader(2, 16, 44100, 4410), audio, ape}, nil)
+
+	var m Metadata
+	m, err := ReadTTA(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("ReadTTA() returned error: %v", err)
+	}
+	if m.FileType() != TTA || m.Format() != APEv2 {
+		t.Errorf("FileType(), Format() = %v, %v, expected %v, %v", m.FileType(), m.Format(), TTA, APEv2)
+	}
+	if m.Artist() != "Artist" || m.Year() != 2004 {
+		t.Errorf("Artist(), Year() = %q, %v, expected %q, %v", m.Artist(), m.Year(), "Artist", 2004)
+	}
+	if m.Duration() != 100*time.Millisecond {
+		t.Errorf("Duration() = %v, expected %v", m.Duration(), 100*time.Millisecond)
+	}
+	//The header is counted as audio, the APE tag isn't
+	if b, expected := m.AverageBitrate(), (ttaHeaderLength+len(audio))*8*10; b != expected {
+		t.Errorf("AverageBitrate() = %v, expected %v", b, expected)
+	}
+
+	_, err = ReadTTA(bytes.NewReader(audio))
+	if err == nil {
+		t.Errorf("ReadTTA() expected error for missing header")
+	}
+}
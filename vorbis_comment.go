@@ -7,6 +7,7 @@ package yurit
 import (
 	"errors"
 	"io"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -153,6 +154,44 @@ func (vc vorbisComment) Composer() string {
 	return vc["artist"]
 }
 
+//Chapters returns the chapters given by CHAPTERxxx=HH:MM:SS.sss and
+//CHAPTERxxxNAME comments, ordered by their numbers. Chapter ends are not set.
+//https://wiki.xiph.org/Chapter_Extension
+func (vc vorbisComment) Chapters() []Chapter {
+	//Map chapter numbers to their keys, which are usually 3 digits but may not be
+	keys := map[int]string{}
+	var numbers []int
+	for k := range vc {
+		if !strings.HasPrefix(k, "chapter") {
+			continue
+		}
+		n, err := strconv.Atoi(k[len("chapter"):])
+		if err != nil || n < 0 {
+			continue
+		}
+		if _, ok := parseChapterTime(vc[k]); !ok {
+			continue
+		}
+		//CHAPTER1 and CHAPTER001 are the same chapter, so keep only one of the
+		//keys, picking the same one whatever order the map is read in
+		if prev, ok := keys[n]; ok {
+			if k < prev {
+				keys[n] = k
+			}
+			continue
+		}
+		keys[n] = k
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	var chapters []Chapter
+	for _, n := range numbers {
+		start, _ := parseChapterTime(vc[keys[n]])
+		chapters = append(chapters, Chapter{Title: vc[keys[n]+"name"], Start: start})
+	}
+	return chapters
+}
+
 func (vc vorbisComment) Disc() (int, int) {
 	// https://wiki.xiph.org/Field_names
 	x, _ := strconv.Atoi(vc["discnumber"])